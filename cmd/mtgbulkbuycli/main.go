@@ -1,22 +1,54 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/ilyalavrinov/tgbots/pkg/mtgbulk"
 	"github.com/jedib0t/go-pretty/table"
 	"github.com/tealeg/xlsx"
+	"gopkg.in/gcfg.v1"
 )
 
 const (
 	filenameArg   = "from"
-	filenameUsage = "file with list of cards to be processed"
+	filenameUsage = "file with list of cards to be processed: text, MTG Arena or MTGO export, MTGO .dek or CSV of Moxfield and Archidekt"
 )
 
 var filename = flag.String(filenameArg, "", filenameUsage)
+var sellers = flag.String("sellers", "", "comma separated sellers to search at, all by default")
+var disabledSellers = flag.String("disable-sellers", "", "comma separated sellers not to search at")
+var deliveryFee = flag.Int("delivery-fee", 0, "delivery fee paid once per seller which has no terms")
+var termsFile = flag.String("terms", "", "file with seller terms in sections like [terms \"MtgSale\"]")
+var pickup = flag.Bool("pickup", false, "pick orders up where sellers allow it")
+var dumpFile = flag.String("dump", "scryfall.all.dump", "Scryfall dump of all cards downloaded by tools/scryfall_bulk")
+var indexFile = flag.String("index", "scryfall.index.tsv", "card index, built from the dump if missing or outdated")
+
+// readTerms loads seller terms in the same format as mtgbulkbuy bot config
+func readTerms(filename string) (map[string]mtgbulk.SellerTerms, error) {
+	var cfg struct {
+		Terms map[string]*mtgbulk.SellerTerms
+	}
+	if err := gcfg.ReadFileInto(&cfg, filename); err != nil {
+		return nil, err
+	}
+	terms := make(map[string]mtgbulk.SellerTerms, len(cfg.Terms))
+	for name, t := range cfg.Terms {
+		terms[name] = *t
+	}
+	return terms, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
 
 func main() {
 	flag.Parse()
@@ -32,7 +64,35 @@ func main() {
 		os.Exit(1)
 	}
 
-	result, err := mtgbulk.ProcessText(f)
+	req, err := mtgbulk.ParseText(f)
+	if err != nil {
+		fmt.Printf("could not read card list; error: %s", err)
+		os.Exit(1)
+	}
+	req.Sellers = splitList(*sellers)
+	req.DisabledSellers = splitList(*disabledSellers)
+	req.DeliveryFee = *deliveryFee
+	req.Pickup = *pickup
+	if *termsFile != "" {
+		if req.Terms, err = readTerms(*termsFile); err != nil {
+			fmt.Printf("could not read seller terms; error: %s", err)
+			os.Exit(1)
+		}
+	}
+	lib, err := mtgbulk.OpenLibrary(*dumpFile, *indexFile)
+	if err != nil {
+		fmt.Printf("could not open card library; error: %s", err)
+		os.Exit(1)
+	}
+	result, err := mtgbulk.ProcessByNames(context.Background(), lib, req)
+	if result != nil {
+		for _, u := range result.UnknownCards {
+			fmt.Println(u.Error())
+		}
+		for name, known := range result.Resolved {
+			fmt.Printf("%q is searched as %q\n", name, known)
+		}
+	}
 	if err != nil {
 		fmt.Printf("could not get result; error: %s", err)
 		os.Exit(1)
@@ -41,6 +101,9 @@ func main() {
 	for name, cards := range result.AllSortedCards {
 		fmt.Printf("%s ==> total found %d\n", name, len(cards.Prices))
 	}
+	for _, f := range result.Failures {
+		fmt.Printf("search of %q at %s has failed: %s\n", f.Card, f.Seller, f.Err)
+	}
 	fmt.Printf("Unavailable cards:\n")
 	for _, name := range result.NotAvailableCards {
 		fmt.Println(name)
@@ -51,11 +114,11 @@ func main() {
 		var total float32
 		t := table.NewWriter()
 		t.SetOutputMirror(os.Stdout)
-		t.AppendHeader(table.Row{"Cardname", "Qty", "Price", "Seller"})
+		t.AppendHeader(table.Row{"Cardname", "Qty", "Price", "Seller", "Details"})
 		rows := make([]table.Row, 0)
 		for name, prices := range result.MinPricesNoDelivery {
 			for _, p := range prices {
-				rows = append(rows, table.Row{name, p.Quantity, p.Price, p.SellerFullName()})
+				rows = append(rows, table.Row{name, p.Quantity, p.Price, p.SellerFullName(), p.Details()})
 				total += p.Price
 			}
 		}
@@ -67,6 +130,34 @@ func main() {
 		t.Render()
 	}
 
+	if plan := result.WithDelivery; plan != nil && len(plan.Sellers) > 0 {
+		fmt.Println("Min price with delivery rule:")
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Cardname", "Qty", "Price", "Seller", "Details"})
+		rows := make([]table.Row, 0)
+		for name, items := range plan.Items {
+			for _, p := range items {
+				rows = append(rows, table.Row{name, p.Quantity, p.Price, p.SellerFullName(), p.Details()})
+			}
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i][0].(string) < rows[j][0].(string)
+		})
+		t.AppendRows(rows)
+		t.AppendFooter(table.Row{"", "", "Cards", plan.CardsCost})
+		t.AppendFooter(table.Row{"", "", "Delivery", plan.DeliveryCost})
+		t.AppendFooter(table.Row{"", "", "Total", plan.Total})
+		t.Render()
+		for _, seller := range plan.Sellers {
+			fmt.Printf("%s: delivery %.0f", seller, plan.Delivery[seller])
+			if lack, found := plan.BelowMinOrder[seller]; found {
+				fmt.Printf(", %.0f below minimal order", lack)
+			}
+			fmt.Println()
+		}
+	}
+
 	res := *filename + ".matrix.out"
 	os.Remove(res)
 	f, err = os.Create(res)
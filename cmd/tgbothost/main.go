@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/internal/familyguy"
+	"github.com/ilyalavrinov/tgbots/internal/mtgbulkbuy"
+	"github.com/ilyalavrinov/tgbots/internal/torrents"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+	"gopkg.in/gcfg.v1"
+)
+
+const cfg_filename = "tgbothost.cfg"
+
+type config struct {
+	tgbotbase.HostConfig
+	Bot map[string]*struct {
+		Kind   string
+		Config string
+	}
+}
+
+// setups maps bot kind to the function which reads bot config and registers the bot at the host
+var setups = map[string]func(host *tgbotbase.Host, name, filename string) error{
+	"towarisch": func(host *tgbotbase.Host, name, filename string) error {
+		cfg, err := towarisch.NewConfig(filename)
+		if err != nil {
+			return err
+		}
+		return towarisch.Setup(host, name, cfg)
+	},
+	"familyguy": func(host *tgbotbase.Host, name, filename string) error {
+		cfg, err := familyguy.NewConfig(filename)
+		if err != nil {
+			return err
+		}
+		return familyguy.Setup(host, name, cfg)
+	},
+	"mtgbulkbuy": func(host *tgbotbase.Host, name, filename string) error {
+		cfg, err := mtgbulkbuy.NewConfig(filename)
+		if err != nil {
+			return err
+		}
+		return mtgbulkbuy.Setup(host, name, cfg)
+	},
+	"torrents": func(host *tgbotbase.Host, name, filename string) error {
+		cfg, err := torrents.NewConfig(filename)
+		if err != nil {
+			return err
+		}
+		return torrents.Setup(host, name, cfg)
+	},
+}
+
+func main() {
+	rand.Seed(time.Now().UTC().UnixNano())
+
+	log.Print("Starting bot host")
+
+	err := run(cfg_filename)
+	if err != nil {
+		log.Printf("Bot host could not be started due to error: %s", err)
+	}
+
+	log.Print("Bot host has stopped working")
+}
+
+func run(filename string) error {
+	var cfg config
+	if err := gcfg.ReadFileInto(&cfg, filename); err != nil {
+		return fmt.Errorf("cannot read config %q: %w", filename, err)
+	}
+	if len(cfg.Bot) == 0 {
+		return fmt.Errorf("no bots configured in %q", filename)
+	}
+
+	// bots are set up in the order of their names, so errors of the config are reported the same way every time
+	names := make([]string, 0, len(cfg.Bot))
+	for name := range cfg.Bot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	host := tgbotbase.NewHost(context.TODO(), cfg.HostConfig)
+	for _, name := range names {
+		botCfg := cfg.Bot[name]
+		setup, found := setups[botCfg.Kind]
+		if !found {
+			return fmt.Errorf("bot %q has unknown kind %q", name, botCfg.Kind)
+		}
+		if err := setup(host, name, botCfg.Config); err != nil {
+			return fmt.Errorf("cannot set up bot %q: %w", name, err)
+		}
+	}
+
+	return host.Start()
+}
@@ -5,43 +5,37 @@ import (
 	"os"
 	"strconv"
 	"strings"
-)
 
-type config struct {
-	allowedUsers         map[int64]bool
-	token                string
-	transmissionPassword string
-}
+	"github.com/ilyalavrinov/tgbots/internal/torrents"
+)
 
-func readConfig() (config, error) {
+func readConfig() (torrents.Config, error) {
 	users := os.Getenv("TGTORRENTSBOT_USERS")
 	usersSeparated := strings.Split(users, ",")
 	if len(users) == 0 {
-		return config{}, fmt.Errorf("no allowed users found")
+		return torrents.Config{}, fmt.Errorf("no allowed users found")
 	}
 
 	tgtoken := os.Getenv("TGTORRENTSBOT_TOKEN")
 	if tgtoken == "" {
-		return config{}, fmt.Errorf("no token found")
+		return torrents.Config{}, fmt.Errorf("no token found")
 	}
 
 	transmissionPWD := os.Getenv("TGTORRENTSBOT_TRANSMISSION_PASSWORD")
 	if transmissionPWD == "" {
-		return config{}, fmt.Errorf("transmission password not set")
+		return torrents.Config{}, fmt.Errorf("transmission password not set")
 	}
 
-	allowedUsers := make(map[int64]bool, len(usersSeparated))
+	var cfg torrents.Config
 	for _, u := range usersSeparated {
 		id, err := strconv.ParseInt(u, 10, 64)
 		if err != nil {
-			return config{}, fmt.Errorf("cannot convert user %s to int64 id: %w", u, err)
+			return torrents.Config{}, fmt.Errorf("cannot convert user %s to int64 id: %w", u, err)
 		}
-		allowedUsers[id] = true
+		cfg.Torrents.AllowedUser = append(cfg.Torrents.AllowedUser, id)
 	}
+	cfg.TGBot.Token = tgtoken
+	cfg.Torrents.TransmissionPassword = transmissionPWD
 
-	return config{
-		allowedUsers:         allowedUsers,
-		token:                tgtoken,
-		transmissionPassword: transmissionPWD,
-	}, nil
+	return cfg, nil
 }
@@ -1,12 +1,11 @@
 package main
 
 import (
-	"fmt"
-	"net/url"
+	"context"
 	"os"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/ilyalavrinov/tgbots/internal/torrents"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 	"golang.org/x/exp/slog"
 )
 
@@ -21,30 +20,12 @@ func main() {
 	slog.Info("run exited", "err", err)
 }
 
-func run(cfg config) error {
-	bot, err := tgbotapi.NewBotAPI(cfg.token)
-	if err != nil {
-		return fmt.Errorf("cannot start telegram bot, err: %w", err)
-	}
-
-	bturlRaw := fmt.Sprintf("http://transmission:%s@127.0.0.1:9091/transmission/rpc", cfg.transmissionPassword)
-	bturl, err := url.Parse(bturlRaw)
-	if err != nil {
-		return fmt.Errorf("cannot parse transmission url: %w", err)
+func run(cfg torrents.Config) error {
+	host := tgbotbase.NewStandaloneHost(context.TODO(), tgbotbase.HostConfig{})
+	if err := torrents.Setup(host, "torrents", cfg); err != nil {
+		return err
 	}
-	btclient, err := transmissionrpc.New(bturl, nil)
-	if err != nil {
-		return fmt.Errorf("cannot connect to transmission: %w", err)
-	}
-	cmdHandler := newCommandHanler(cfg, bot, btclient)
 
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 30
-	updates := bot.GetUpdatesChan(updateConfig)
-
-	slog.Info("running", "tgbot.Self.UserName", bot.Self.UserName)
-	for update := range updates {
-		cmdHandler.handleUpdate(update)
-	}
-	return nil
+	slog.Info("running")
+	return host.Start()
 }
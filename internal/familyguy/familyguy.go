@@ -2,6 +2,7 @@ package familyguy
 
 import (
 	"context"
+	"errors"
 
 	log "github.com/sirupsen/logrus"
 
@@ -14,6 +15,10 @@ import (
 type Config struct {
 	tgbotbase.Config
 	Redis tgbotbase.RedisConfig
+
+	Owners struct {
+		ID []string
+	}
 }
 
 func NewConfig(filename string) (Config, error) {
@@ -43,21 +48,37 @@ func Start(cfg_filename string) error {
 
 	log.Printf("Starting bot with full config: %+v", fullcfg)
 
+	host := tgbotbase.NewStandaloneHost(context.TODO(), tgbotbase.HostConfig{Redis: fullcfg.Redis})
+	if err := Setup(host, "familyguy", fullcfg); err != nil {
+		log.Printf("My bot cannot be set up due to error: %s", err)
+		return err
+	}
+	err = host.Start()
+
+	log.Print("Stopping my bot")
+	return err
+}
+
+// Setup creates familyguy bot at the host and registers all its handlers
+func Setup(host *tgbotbase.Host, name string, fullcfg Config) error {
+	if host.Redis == nil {
+		return errors.New("familyguy requires Redis to be configured")
+	}
+
 	tgcfg := tgbotbase.Config{TGBot: fullcfg.TGBot,
 		Proxy_SOCKS5: fullcfg.Proxy_SOCKS5}
-	bot := tgbotbase.NewBot(tgcfg)
+	bot, err := host.NewBot(name, tgcfg)
+	if err != nil {
+		return err
+	}
 
-	rediscfg := fullcfg.Redis
-	redispool := tgbotbase.NewRedisPool(context.TODO(), rediscfg)
-	propstorage := tgbotbase.NewRedisPropertyStorage(redispool)
-	kidstorage := kidsweekscore.NewRedisStorage(redispool)
-	cron := tgbotbase.NewCron()
+	propstorage := bot.Properties()
+	kidstorage := kidsweekscore.NewRedisStorage(host.Redis, bot.KeyPrefix())
+	cron := host.Cron
 
-	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(kidsweekscore.NewKidScoreHandler(kidstorage)))
+	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(kidsweekscore.NewKidScoreHandler(kidstorage, propstorage)))
 	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(kidsweekscore.NewKidScoreResult(kidstorage, cron, propstorage)))
 	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(yadiskphoto.NewDailyPhoto(cron, propstorage)))
-	bot.Start()
-
-	log.Print("Stopping my bot")
+	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(tgbotbase.NewChatsHandler(bot.ChatRegistry(), fullcfg.Owners.ID)))
 	return nil
 }
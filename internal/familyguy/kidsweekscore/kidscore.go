@@ -16,12 +16,14 @@ import (
 type kidScoreHandler struct {
 	tgbotbase.BaseHandler
 
-	storage Storage
+	storage   Storage
+	localizer *tgbotbase.Localizer
 }
 
-func NewKidScoreHandler(storage Storage) tgbotbase.IncomingMessageHandler {
+func NewKidScoreHandler(storage Storage, props tgbotbase.PropertyStorage) tgbotbase.IncomingMessageHandler {
 	return &kidScoreHandler{
-		storage: storage,
+		storage:   storage,
+		localizer: tgbotbase.NewLocalizer(texts, props),
 	}
 }
 
@@ -85,13 +87,14 @@ func (h *kidScoreHandler) HandleOne(msg tgbotapi.Message) {
 		return
 	}
 
-	replyText := "Принято!"
+	tr := h.localizer.For(ctx, tgbotbase.UserID(msg.From.ID), tgbotbase.ChatID(msg.Chat.ID))
+	replyText := tr.T("score.accepted")
 	positives, negatives, err := scoresThisWeek(ctx, h.storage, msg.Chat.ID, targetChild)
 	if err != nil {
 		log.WithFields(log.Fields{"err": err, "kid": targetChild}).Error("Cannot get this week scores")
 	}
 
-	replyText = fmt.Sprintf("%s Сейчас %d плюсов и %d минусов", replyText, positives, negatives)
+	replyText = fmt.Sprintf("%s %s", replyText, tr.T("score.now", tr.N("score.positives", positives, positives), tr.N("score.negatives", negatives, negatives)))
 	replyMsg := tgbotapi.NewMessage(msg.Chat.ID, replyText)
 	replyMsg.BaseChat.ReplyToMessageID = msg.MessageID
 	h.OutMsgCh <- replyMsg
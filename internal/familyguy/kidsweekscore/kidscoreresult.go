@@ -18,6 +18,8 @@ type kidScoreResult struct {
 	storage Storage
 	cron    tgbotbase.Cron
 	props   tgbotbase.PropertyStorage
+
+	localizer *tgbotbase.Localizer
 }
 
 var _ tgbotbase.BackgroundMessageHandler = &kidScoreResult{}
@@ -27,6 +29,8 @@ func NewKidScoreResult(storage Storage, cron tgbotbase.Cron, props tgbotbase.Pro
 		storage: storage,
 		cron:    cron,
 		props:   props,
+
+		localizer: tgbotbase.NewLocalizer(texts, props),
 	}
 }
 
@@ -54,8 +58,9 @@ func (h *kidScoreResult) Run() {
 
 		when := tgbotbase.CalcNextTriggerDay(time.Now(), time.Sunday, dur)
 		job := kidScoreResultJob{
-			chatID:  prop.Chat,
-			storage: h.storage,
+			chatID:    prop.Chat,
+			storage:   h.storage,
+			localizer: h.localizer,
 		}
 		job.OutMsgCh = h.OutMsgCh
 
@@ -74,6 +79,7 @@ type kidScoreResultJob struct {
 	chatID     tgbotbase.ChatID
 	chatIDCopy tgbotbase.ChatID
 	storage    Storage
+	localizer  *tgbotbase.Localizer
 }
 
 var _ tgbotbase.CronJob = &kidScoreResultJob{}
@@ -87,7 +93,8 @@ func (job *kidScoreResultJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 		log.WithFields(log.Fields{"err": err, "chat": job.chatID}).Error("Could not load settings")
 		return
 	}
-	msg := "Недельные результаты:"
+	tr := job.localizer.For(ctx, 0, job.chatID)
+	msg := tr.T("result.header")
 	for kid := range settings.kidsAliases {
 		positives, negatives, err := scoresThisWeek(ctx, job.storage, int64(job.chatID), kid)
 		if err != nil {
@@ -100,7 +107,7 @@ func (job *kidScoreResultJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 		moneyToKid := int(totalMoney * float32(positives) / float32(total))
 		log.WithFields(log.Fields{"kid": kid, "+": positives, "-": negatives, "total": total, "age": age, "totalMoney": totalMoney, "moneyToKid": moneyToKid}).Debug("week money calculation")
 		msg = fmt.Sprintf("%s\n\n%s: '+' %d; '-' %d", msg, kid, positives, negatives)
-		msg = fmt.Sprintf("%s\n%d в копилку; %d на приставку", msg, moneyToKid, int(totalMoney)-moneyToKid)
+		msg = fmt.Sprintf("%s\n%s", msg, tr.T("result.money", moneyToKid, int(totalMoney)-moneyToKid))
 	}
 
 	job.OutMsgCh <- tgbotapi.NewMessage(int64(job.chatID), msg)
@@ -0,0 +1,28 @@
+package kidsweekscore
+
+import "github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+
+var texts = tgbotbase.NewCatalog("ru")
+
+func init() {
+	texts.Add("ru", map[string]string{
+		"score.accepted": "Принято!",
+		"score.now":      "Сейчас %s и %s",
+		"result.header":  "Недельные результаты:",
+		"result.money":   "%d в копилку; %d на приставку",
+	})
+	texts.Add("en", map[string]string{
+		"score.accepted": "Got it!",
+		"score.now":      "Now %s and %s",
+		"result.header":  "Weekly results:",
+		"result.money":   "%d to the piggy bank; %d to the game console",
+	})
+	texts.AddPlurals("ru", map[string]tgbotbase.Plural{
+		"score.positives": {One: "%d плюс", Few: "%d плюса", Many: "%d плюсов"},
+		"score.negatives": {One: "%d минус", Few: "%d минуса", Many: "%d минусов"},
+	})
+	texts.AddPlurals("en", map[string]tgbotbase.Plural{
+		"score.positives": {One: "%d plus", Other: "%d pluses"},
+		"score.negatives": {One: "%d minus", Other: "%d minuses"},
+	})
+}
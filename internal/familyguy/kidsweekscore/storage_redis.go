@@ -14,6 +14,7 @@ import (
 
 type redisStorage struct {
 	client *redis.Client
+	keys   tgbotbase.KeyPrefix
 }
 
 var _ Storage = &redisStorage{}
@@ -22,9 +23,11 @@ const (
 	ttl = 3 * 7 * 24 * time.Hour
 )
 
-func NewRedisStorage(pool tgbotbase.RedisPool) *redisStorage {
+// NewRedisStorage keeps scores and settings under keys with the prefix of the bot
+func NewRedisStorage(pool tgbotbase.RedisPool, keys tgbotbase.KeyPrefix) *redisStorage {
 	return &redisStorage{
 		client: pool.GetConnByName("kidsweekscore"),
+		keys:   keys,
 	}
 }
 
@@ -36,18 +39,18 @@ func key(chatId int64, childName string, timestamp time.Time) string {
 }
 
 func (s *redisStorage) add(ctx context.Context, chatId int64, childName string, timestamp time.Time, val string) error {
-	return s.client.Set(ctx, key(chatId, childName, timestamp), val, ttl).Err()
+	return s.client.Set(ctx, s.keys.Key(key(chatId, childName, timestamp)), val, ttl).Err()
 }
 
 func (s *redisStorage) get(ctx context.Context, chatId int64, childName string, t1, t2 time.Time) ([]string, error) {
-	keys, err := s.client.Keys(ctx, fmt.Sprintf("kidscore:%d:kid:%s:*", chatId, childName)).Result()
+	keys, err := s.client.Keys(ctx, s.keys.Key(fmt.Sprintf("kidscore:%d:kid:%s:*", chatId, childName))).Result()
 	if err != nil {
 		return nil, err
 	}
 
 	result := make([]string, 0, len(keys))
 	for _, k := range keys {
-		parts := strings.Split(k, ":")
+		parts := strings.Split(s.keys.Trim(k), ":")
 		if len(parts) != 5 {
 			return nil, errors.New(fmt.Sprintf("Key %q cannot be correctly split", k))
 		}
@@ -73,18 +76,18 @@ func (s *redisStorage) get(ctx context.Context, chatId int64, childName string,
 }
 
 func (s *redisStorage) loadSettings(ctx context.Context, chatId int64) (settings, error) {
-	parents, err := s.client.LRange(ctx, fmt.Sprintf("kidscore:%d:parents", chatId), 0, -1).Result()
+	parents, err := s.client.LRange(ctx, s.keys.Key(fmt.Sprintf("kidscore:%d:parents", chatId)), 0, -1).Result()
 	if err != nil {
 		return settings{}, err
 	}
 
-	keys, err := s.client.Keys(ctx, fmt.Sprintf("kidscore:%d:kidAlias:*", chatId)).Result()
+	keys, err := s.client.Keys(ctx, s.keys.Key(fmt.Sprintf("kidscore:%d:kidAlias:*", chatId))).Result()
 	if err != nil {
 		return settings{}, err
 	}
 	kids := make(map[string][]string, len(keys))
 	for _, k := range keys {
-		parts := strings.Split(k, ":")
+		parts := strings.Split(s.keys.Trim(k), ":")
 		if len(parts) != 4 {
 			return settings{}, errors.New(fmt.Sprintf("Key %q cannot be correctly split", k))
 		}
@@ -99,7 +102,7 @@ func (s *redisStorage) loadSettings(ctx context.Context, chatId int64) (settings
 
 	kidsBirthdays := make(map[string]time.Time)
 	for k := range kids {
-		bdayStr, err := s.client.Get(ctx, fmt.Sprintf("kidscore:%d:kidAge:%s", chatId, k)).Result()
+		bdayStr, err := s.client.Get(ctx, s.keys.Key(fmt.Sprintf("kidscore:%d:kidAge:%s", chatId, k))).Result()
 		if err != nil {
 			return settings{}, err
 		}
@@ -110,7 +113,7 @@ func (s *redisStorage) loadSettings(ctx context.Context, chatId int64) (settings
 		kidsBirthdays[k] = bday
 	}
 
-	rateStr, err := s.client.Get(ctx, fmt.Sprintf("kidscore:%d:baseRate", chatId)).Result()
+	rateStr, err := s.client.Get(ctx, s.keys.Key(fmt.Sprintf("kidscore:%d:baseRate", chatId))).Result()
 	if err != nil {
 		return settings{}, err
 	}
@@ -2,10 +2,9 @@ package yadiskphoto
 
 import (
 	"context"
-	"io"
 	"math/rand"
-	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -71,12 +70,22 @@ func (h *dailyPhoto) Run() {
 			continue
 		}
 
+		count := 1
+		if propCount, _ := h.props.GetProperty(ctx, "yadiskDailyPhotoCount", 0, prop.Chat); propCount != "" {
+			count, err = strconv.Atoi(propCount)
+			if err != nil || count < 1 {
+				log.WithFields(log.Fields{"err": err, "chat": prop.Chat, "count": propCount}).Error("incorrect photo count property")
+				count = 1
+			}
+		}
+
 		when := tgbotbase.CalcNextTimeFromMidnight(time.Now(), dur)
 		job := dailyPhotoJob{
 			chatID:   prop.Chat,
 			rootPath: propRoot,
 			username: propUsername,
 			password: propPassword,
+			count:    count,
 		}
 		job.OutMsgCh = h.OutMsgCh
 
@@ -91,10 +100,13 @@ type dailyPhotoJob struct {
 	rootPath string
 	username string
 	password string
+	count    int
 }
 
 var _ tgbotbase.CronJob = &dailyPhotoJob{}
 
+const maxPhotosPerDay = 10 // Telegram limit for an album
+
 func (job *dailyPhotoJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 	defer cron.AddJob(scheduledWhen.Add(24*time.Hour), job)
 
@@ -105,22 +117,29 @@ func (job *dailyPhotoJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 		return
 	}
 
-	targetFile := files[rand.Intn(len(files))]
-	remote, err := client.ReadStream(targetFile)
-	if err != nil {
-		log.WithFields(log.Fields{"file": targetFile, "error": err}).Error("Could not open remote file for reading")
-		return
+	count := job.count
+	if count > maxPhotosPerDay {
+		count = maxPhotosPerDay
+	}
+	if count > len(files) {
+		count = len(files)
 	}
 
-	tmp, err := os.CreateTemp("", "yadiskDailyPhoto_")
-
-	_, err = io.Copy(tmp, remote)
-	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Error("Could not copy remote file into a local")
+	photos := make([]tgbotbase.MediaFile, 0, count)
+	for _, i := range rand.Perm(len(files))[:count] {
+		targetFile := files[i]
+		data, err := client.Read(targetFile)
+		if err != nil {
+			log.WithFields(log.Fields{"file": targetFile, "error": err}).Error("Could not read remote file")
+			continue
+		}
+		photos = append(photos, tgbotbase.MediaFile{Name: path.Base(targetFile), Data: data})
+	}
+	if len(photos) == 0 {
 		return
 	}
 
-	job.OutMsgCh <- tgbotapi.NewPhotoUpload(int64(job.chatID), tmp.Name())
+	job.OutMsgCh <- tgbotbase.NewMediaGroupUpload(job.chatID, photos)
 }
 
 func getFileList(client *gowebdav.Client, fpath string) []string {
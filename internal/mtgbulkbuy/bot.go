@@ -1,32 +1,145 @@
 package mtgbulkbuy
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"time"
 
+	"github.com/ilyalavrinov/tgbots/pkg/mtgbulk"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 	"gopkg.in/gcfg.v1"
 )
 
-type config struct {
+type Config struct {
 	tgbotbase.Config
+	// Redis is used by the standalone bot only; bots at tgbothost use Redis of the host
+	Redis tgbotbase.RedisConfig
+	// Sellers limits searching to the listed sellers and skips the disabled ones
+	Sellers struct {
+		Enable  []string
+		Disable []string
+	}
+	// Cache keeps found prices; results are cached in memory unless Redis DB is set
+	Cache struct {
+		TTL     string // like "1h", mtgbulk.DefaultPriceTTL if empty
+		RedisDB string // name of Redis DB, requires [redis] section or Redis configured at the host
+	}
+	// Delivery describes costs of ordering from a seller
+	Delivery struct {
+		Fee    int  // paid once per seller which has no terms
+		Pickup bool // pick orders up where sellers allow it
+	}
+	// Terms of sellers keyed by platform or trader like "dimon@MtgTrade"
+	Terms map[string]*mtgbulk.SellerTerms
+	// Library locates card names; the index is built from Scryfall dump downloaded by tools/scryfall_bulk
+	Library struct {
+		Dump  string // defaultDumpPath if empty
+		Index string // defaultIndexPath if empty
+	}
 }
 
-func Start(cfgFilename string) error {
-	flag.Parse()
+const (
+	defaultDumpPath  = "scryfall.all.dump"
+	defaultIndexPath = "scryfall.index.tsv"
+)
+
+// library opens the card index, building it from the dump if needed
+func (cfg Config) library() (mtgbulk.Library, error) {
+	dump, index := cfg.Library.Dump, cfg.Library.Index
+	if dump == "" {
+		dump = defaultDumpPath
+	}
+	if index == "" {
+		index = defaultIndexPath
+	}
+	lib, err := mtgbulk.OpenLibrary(dump, index)
+	if err != nil {
+		Errorw("Cannot open card library",
+			"dump", dump,
+			"index", index,
+			"err", err)
+		return nil, err
+	}
+	return lib, nil
+}
 
-	var cfg config
+// terms returns seller terms in the form used by search requests
+func (cfg Config) terms() map[string]mtgbulk.SellerTerms {
+	terms := make(map[string]mtgbulk.SellerTerms, len(cfg.Terms))
+	for name, t := range cfg.Terms {
+		terms[name] = *t
+	}
+	return terms
+}
 
+// NewConfig reads mtgbulkbuy bot configuration from the file
+func NewConfig(cfgFilename string) (Config, error) {
+	var cfg Config
 	if err := gcfg.ReadFileInto(&cfg, cfgFilename); err != nil {
+		Errorw("Cannot read config file",
+			"filename", cfgFilename,
+			"err", err)
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func Start(cfgFilename string) error {
+	flag.Parse()
+
+	cfg, err := NewConfig(cfgFilename)
+	if err != nil {
 		Fatalw("Cannot read config file",
 			"filename", cfgFilename)
 		return err
 	}
 
-	tgbot := tgbotbase.NewBot(tgbotbase.Config{TGBot: cfg.TGBot, Proxy_SOCKS5: cfg.Proxy_SOCKS5})
-	tgbot.AddHandler(tgbotbase.NewIncomingMessageDealer(NewSearchHandler()))
+	host := tgbotbase.NewStandaloneHost(context.TODO(), tgbotbase.HostConfig{Redis: cfg.Redis})
+	if err := Setup(host, "mtgbulkbuy", cfg); err != nil {
+		return err
+	}
 
 	Info("Starting bot")
-	tgbot.Start()
+	err = host.Start()
 	Info("Stopping bot")
+	return err
+}
+
+// Setup creates mtgbulkbuy bot at the host and registers its handlers
+func Setup(host *tgbotbase.Host, name string, cfg Config) error {
+	tgbot, err := host.NewBot(name, tgbotbase.Config{TGBot: cfg.TGBot, Proxy_SOCKS5: cfg.Proxy_SOCKS5})
+	if err != nil {
+		return err
+	}
+	cache, err := newPriceCache(host, cfg)
+	if err != nil {
+		return err
+	}
+	lib, err := cfg.library()
+	if err != nil {
+		return err
+	}
+	tgbot.AddHandler(tgbotbase.NewIncomingMessageDealer(NewSearchHandler(cfg, lib, cache)))
 	return nil
 }
+
+func newPriceCache(host *tgbotbase.Host, cfg Config) (mtgbulk.PriceCache, error) {
+	var ttl time.Duration
+	if cfg.Cache.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(cfg.Cache.TTL); err != nil {
+			Errorw("Cannot parse cache TTL",
+				"ttl", cfg.Cache.TTL,
+				"err", err)
+			return nil, err
+		}
+	}
+	if cfg.Cache.RedisDB == "" {
+		return mtgbulk.NewMemoryPriceCache(ttl), nil
+	}
+	if host.Redis == nil {
+		return nil, errors.New("price cache in Redis requires Redis to be configured")
+	}
+	return mtgbulk.NewRedisPriceCache(host.Redis.GetConnByName(cfg.Cache.RedisDB), ttl), nil
+}
@@ -1,9 +1,14 @@
 package mtgbulkbuy
 
 import (
-	"io/ioutil"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ilyalavrinov/tgbots/pkg/mtgbulk"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
@@ -11,54 +16,239 @@ import (
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
+// searchTimeout limits the whole search of a card list
+const searchTimeout = 10 * time.Minute
+
+// progressInterval is the minimal period between updates of the progress message
+const progressInterval = 5 * time.Second
+
+// refreshCommand at the first line of a card list or in the caption of a file ignores cached prices
+const refreshCommand = "/refresh"
+
+// maxCardListSize limits files with card lists
+const maxCardListSize = 1 << 20
+
+// maxSearches limits card lists searched at the same time; other lists wait for their turn
+const maxSearches = 3
+
 type searchHandler struct {
 	tgbotbase.BaseHandler
+	cfg   Config
+	terms map[string]mtgbulk.SellerTerms
+	lib   mtgbulk.Library
+	cache mtgbulk.PriceCache
+	files tgbotbase.FileDownloader
+	// searches is a semaphore of running searches
+	searches chan struct{}
 }
 
-func NewSearchHandler() tgbotbase.IncomingMessageHandler {
-	handler := searchHandler{}
+func NewSearchHandler(cfg Config, lib mtgbulk.Library, cache mtgbulk.PriceCache) tgbotbase.IncomingMessageHandler {
+	handler := searchHandler{
+		cfg:      cfg,
+		terms:    cfg.terms(),
+		lib:      lib,
+		cache:    cache,
+		searches: make(chan struct{}, maxSearches),
+	}
 	return &handler
 }
 
 func (h *searchHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- tgbotbase.ServiceMsg) tgbotbase.HandlerTrigger {
 	h.OutMsgCh = outMsgCh
-	return tgbotbase.NewHandlerTrigger(regexp.MustCompile(".*"), nil)
+	return tgbotbase.NewHandlerTrigger(regexp.MustCompile(".*"), nil).WithDocuments()
+}
+
+func (h *searchHandler) SetFileDownloader(files tgbotbase.FileDownloader) {
+	h.files = files
+}
+
+// cardList returns the card list from the message text or from the file sent with the message
+func (h *searchHandler) cardList(msg tgbotapi.Message) (string, bool, error) {
+	if msg.Document == nil {
+		text, refresh := strings.CutPrefix(strings.TrimSpace(msg.Text), refreshCommand)
+		return text, refresh, nil
+	}
+
+	refresh := strings.HasPrefix(strings.TrimSpace(msg.Caption), refreshCommand)
+	if msg.Document.FileSize > maxCardListSize {
+		return "", refresh, fmt.Errorf("File %q is too large for a card list", msg.Document.FileName)
+	}
+	if h.files == nil {
+		return "", refresh, errors.New("Files are not supported, send the card list as a message")
+	}
+	data, err := h.files.DownloadFile(msg.Document.FileID)
+	if err != nil {
+		Errorw("Could not download card list",
+			"file", msg.Document.FileName,
+			"err", err)
+		return "", refresh, fmt.Errorf("Could not download file %q", msg.Document.FileName)
+	}
+	return string(data), refresh, nil
 }
 
 func (h *searchHandler) Name() string {
 	return "bulk_search"
 }
 
+// HandleOne searches in background, so that the bot keeps handling other messages during the search
 func (h *searchHandler) HandleOne(msg tgbotapi.Message) {
-	r := strings.NewReader(msg.Text)
-	res, err := mtgbulk.ProcessText(r)
+	go h.search(msg)
+}
+
+func (h *searchHandler) search(msg tgbotapi.Message) {
+	progress := <-h.Replier().Reply(msg, "searching...")
+	if progress.Err != nil {
+		Errorw("Could not send progress message",
+			"err", progress.Err)
+	} else {
+		defer h.Replier().Delete(tgbotbase.ChatID(msg.Chat.ID), progress.Message.MessageID)
+	}
+	h.searches <- struct{}{}
+	defer func() { <-h.searches }()
+
+	var res *mtgbulk.NamesResult
+	var req mtgbulk.NamesRequest
+	text, refresh, err := h.cardList(msg)
+	if err == nil {
+		req, err = mtgbulk.ParseText(strings.NewReader(text))
+	}
+	if err == nil {
+		req.Sellers = h.cfg.Sellers.Enable
+		req.DisabledSellers = h.cfg.Sellers.Disable
+		req.Cache = h.cache
+		req.ForceRefresh = refresh
+		req.DeliveryFee = h.cfg.Delivery.Fee
+		req.Terms = h.terms
+		req.Pickup = h.cfg.Delivery.Pickup
+		if progress.Err == nil {
+			req.Progress = h.progressReporter(tgbotbase.ChatID(msg.Chat.ID), progress.Message.MessageID)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+		res, err = mtgbulk.ProcessByNames(ctx, h.lib, req)
+		cancel()
+		if err == context.DeadlineExceeded && res != nil && len(res.AllSortedCards) > 0 {
+			// search has not finished in time, but the offers found so far are still useful
+			Errorw("Search has timed out, replying with partial result",
+				"found", len(res.AllSortedCards))
+			err = nil
+		}
+	}
+	var data []byte
+	if err == nil {
+		if data, err = resultXlsx(res); err != nil {
+			Errorw("Could not make xlsx with the result",
+				"err", err)
+		}
+	}
 	var reply tgbotapi.Chattable
 	if err != nil {
 		r := tgbotapi.NewMessage(msg.Chat.ID, err.Error())
 		r.BaseChat.ReplyToMessageID = msg.MessageID
 		reply = r
 	} else {
-		fxls := xlsx.NewFile()
-		sh, err := fxls.AddSheet("min_prices_all")
+		reply = tgbotbase.NewDocumentFromBytes(tgbotbase.ChatID(msg.Chat.ID), "mtgbulk.xlsx", data)
+	}
+
+	h.OutMsgCh <- reply
+	if res != nil && (len(res.UnknownCards) > 0 || len(res.Resolved) > 0) {
+		h.Replier().Reply(msg, namesText(res))
+	}
+	if err == nil && len(res.Failures) > 0 {
+		h.Replier().Reply(msg, failuresText(res.Failures))
+	}
+	if err == nil && res.CachedSearches > 0 {
+		age := time.Since(res.OldestFetch()).Round(time.Minute)
+		h.Replier().Reply(msg, fmt.Sprintf("Some prices are taken from cache and may be up to %s old; start the list with %s to search again", age, refreshCommand))
+	}
+}
+
+// resultXlsx makes a workbook with the possession table and the purchase plan with delivery
+func resultXlsx(res *mtgbulk.NamesResult) ([]byte, error) {
+	fxls := xlsx.NewFile()
+	sh, err := fxls.AddSheet("min_prices_all")
+	if err != nil {
+		return nil, err
+	}
+	minPrices := make(map[string]int, len(res.MinPricesNoDelivery))
+	for card, pp := range res.MinPricesNoDelivery {
+		minPrices[card] = int(pp[0].Price)
+	}
+	t := mtgbulk.NewPossessionTable(res.MinPricesMatrix)
+	if err := t.ToXlsxSheet(sh, minPrices); err != nil {
+		return nil, err
+	}
+	if res.WithDelivery != nil {
+		sh, err := fxls.AddSheet("with_delivery")
 		if err != nil {
-			r := tgbotapi.NewMessage(msg.Chat.ID, err.Error())
-			r.BaseChat.ReplyToMessageID = msg.MessageID
-			reply = r
-		} else {
-			minPrices := make(map[string]int, len(res.MinPricesNoDelivery))
-			for card, pp := range res.MinPricesNoDelivery {
-				minPrices[card] = int(pp[0].Price)
-			}
-			t := mtgbulk.NewPossessionTable(res.MinPricesMatrix)
-			t.ToXlsxSheet(sh, minPrices)
+			return nil, err
 		}
+		if err := res.WithDelivery.ToXlsxSheet(sh); err != nil {
+			return nil, err
+		}
+	}
 
-		f, err := ioutil.TempFile("", "*-mtgbulk.xlsx")
-		fxls.Write(f)
-		f.Close()
+	var buf bytes.Buffer
+	if err := fxls.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
-		reply = tgbotapi.NewDocumentUpload(msg.Chat.ID, f.Name())
+// progressReporter edits the progress message not more often than progressInterval
+func (h *searchHandler) progressReporter(chat tgbotbase.ChatID, messageID int) func(mtgbulk.Progress) {
+	last := time.Now()
+	return func(p mtgbulk.Progress) {
+		if time.Since(last) < progressInterval || p.Done == p.Total {
+			return
+		}
+		last = time.Now()
+		h.Replier().EditText(chat, messageID, fmt.Sprintf("searching... %d/%d", p.Done, p.Total))
 	}
+}
 
-	h.OutMsgCh <- reply
+// namesText lists cards which have not been searched with suggestions and names which have been corrected
+func namesText(res *mtgbulk.NamesResult) string {
+	lines := make([]string, 0, len(res.UnknownCards)+len(res.Resolved)+2)
+	if len(res.UnknownCards) > 0 {
+		lines = append(lines, "Unknown cards have not been searched:")
+		for _, u := range res.UnknownCards {
+			line := u.Name
+			if len(u.Suggestions) > 0 {
+				line += " - did you mean " + strings.Join(u.Suggestions, ", ") + "?"
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(res.Resolved) > 0 {
+		names := make([]string, 0, len(res.Resolved))
+		for name := range res.Resolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		lines = append(lines, "Card names have been corrected:")
+		for _, name := range names {
+			lines = append(lines, fmt.Sprintf("%s -> %s", name, res.Resolved[name]))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// failuresText lists sellers where some cards could not be searched
+func failuresText(failures []mtgbulk.SearchFailure) string {
+	cards := make(map[string][]string)
+	for _, f := range failures {
+		cards[f.Seller] = append(cards[f.Seller], f.Card)
+	}
+	sellers := make([]string, 0, len(cards))
+	for s := range cards {
+		sellers = append(sellers, s)
+	}
+	sort.Strings(sellers)
+
+	lines := []string{"Result is incomplete, search has failed at:"}
+	for _, s := range sellers {
+		sort.Strings(cards[s])
+		lines = append(lines, fmt.Sprintf("%s: %s", s, strings.Join(cards[s], ", ")))
+	}
+	return strings.Join(lines, "\n")
 }
@@ -1,122 +1,103 @@
-package main
+package torrents
 
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/gocolly/colly"
 	"github.com/hekmon/cunits/v2"
 	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 	"golang.org/x/exp/slog"
 	"golang.org/x/sys/unix"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
 type pendingData struct {
 	torrentID      int64
 	originialMsgId int
 	originalChatId int64
+	progressMsgId  int // 0 if progress message has not been sent
+	lastProgress   string
 }
 
 type commandHandler struct {
-	cfg                config
-	tgbot              *tgbotapi.BotAPI
+	tgbotbase.BaseHandler
+	allowedUsers       map[int64]bool
 	transmissionClient *transmissionrpc.Client
 
-	outCh chan tgbotapi.Chattable
-
 	pendingWatchlist map[int64]pendingData
 	pendingCh        chan pendingData
 }
 
-func newCommandHanler(cfg config, tgbot *tgbotapi.BotAPI, btclient *transmissionrpc.Client) *commandHandler {
+var _ tgbotbase.IncomingMessageHandler = &commandHandler{}
+
+func newCommandHanler(cfg Config, btclient *transmissionrpc.Client) *commandHandler {
+	allowedUsers := make(map[int64]bool, len(cfg.Torrents.AllowedUser))
+	for _, u := range cfg.Torrents.AllowedUser {
+		allowedUsers[u] = true
+	}
 	h := &commandHandler{
-		cfg:                cfg,
-		tgbot:              tgbot,
+		allowedUsers:       allowedUsers,
 		transmissionClient: btclient,
-		outCh:              make(chan tgbotapi.Chattable),
 		pendingWatchlist:   make(map[int64]pendingData),
 		pendingCh:          make(chan pendingData),
 	}
 
-	go h.sendReplies()
-	go h.watchPending()
-
 	return h
 }
 
-func (h *commandHandler) sendReplies() {
-	for outMsg := range h.outCh {
-		slog.Info("send message")
-
-		msg, err := h.tgbot.Send(outMsg)
-		var (
-			chatId    int64 = -1
-			chatName  string
-			replyToId int = -1
-		)
-		if msg.Chat != nil {
-			chatId = msg.Chat.ID
-			chatName = msg.Chat.UserName
-		}
-		if msg.ReplyToMessage != nil {
-			replyToId = msg.ReplyToMessage.MessageID
-		}
-
-		lgr := slog.With("msg.Chat.ID", chatId, "msg.Chat.UserName", chatName, "msg.ReplyToMessage.MessageID", replyToId)
-
-		if err != nil {
-			lgr.Error("send failed", "err", err)
-		} else {
-			lgr.Info("send success")
-		}
-	}
+func (h *commandHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- tgbotbase.ServiceMsg) tgbotbase.HandlerTrigger {
+	h.OutMsgCh = outMsgCh
+	go h.watchPending()
+	return tgbotbase.NewHandlerTrigger(regexp.MustCompile("^/"), nil)
 }
 
-func (h *commandHandler) handleUpdate(update tgbotapi.Update) {
-	if update.Message == nil {
-		slog.Info("received update which is not a message")
-		return
-	}
+func (h *commandHandler) Name() string {
+	return "torrents"
+}
 
-	lgr := slog.Default().With("from.id", update.Message.From.ID, "from.username", update.Message.From.UserName, "chat.id", update.Message.Chat.ID, "chat.name", update.Message.Chat.Title)
-	if !h.cfg.allowedUsers[update.Message.From.ID] {
+func (h *commandHandler) HandleOne(msg tgbotapi.Message) {
+	lgr := slog.Default().With("from.id", msg.From.ID, "from.username", msg.From.UserName, "chat.id", msg.Chat.ID, "chat.name", msg.Chat.Title)
+	if !h.allowedUsers[int64(msg.From.ID)] {
 		lgr.Warn("message from not-allowed user")
 		return
 	}
 
-	if !update.Message.IsCommand() {
+	if !msg.IsCommand() {
 		lgr.Warn("message is not a command")
 		return
 	}
 
-	cmd := update.Message.Command()
+	cmd := msg.Command()
 	lgr = lgr.With("command", cmd)
 	var handlerErr error
 	switch cmd {
 	case "add", "addtorrent":
-		handlerErr = h.handleAdd(update.Message, lgr)
+		handlerErr = h.handleAdd(&msg, lgr)
 	case "stats":
-		handlerErr = h.handleStats(update.Message, lgr)
+		handlerErr = h.handleStats(&msg, lgr)
 	case "list", "listtorrents":
-		handlerErr = h.handleList(update.Message, lgr)
+		handlerErr = h.handleList(&msg, lgr)
 	case "delete", "deletetorrents":
-		handlerErr = h.handleDelete(update.Message, lgr)
+		handlerErr = h.handleDelete(&msg, lgr)
 	default:
 		lgr.Warn("unknown command")
-		replyMsg := tgbotapi.NewMessage(update.Message.Chat.ID, "unknown command")
-		replyMsg.ReplyToMessageID = update.Message.MessageID
-		h.outCh <- replyMsg
+		replyMsg := tgbotapi.NewMessage(msg.Chat.ID, "unknown command")
+		replyMsg.ReplyToMessageID = msg.MessageID
+		h.OutMsgCh <- replyMsg
 		return
 	}
 
 	if handlerErr != nil {
 		lgr.Error("handler error", "err", handlerErr)
-		replyMsg := tgbotapi.NewMessage(update.Message.Chat.ID, "oops, something went wrong")
-		replyMsg.ReplyToMessageID = update.Message.MessageID
+		replyMsg := tgbotapi.NewMessage(msg.Chat.ID, "oops, something went wrong")
+		replyMsg.ReplyToMessageID = msg.MessageID
+		h.OutMsgCh <- replyMsg
 	}
 }
 
@@ -133,16 +114,18 @@ func (h *commandHandler) handleAdd(msg *tgbotapi.Message, lgr *slog.Logger) erro
 		return fmt.Errorf("cannot add torrent to transmission: %w", err)
 	}
 
+	delivery := <-h.Replier().Reply(*msg, fmt.Sprintf("ok! %s: queued", *torrent.Name))
+	if delivery.Err != nil {
+		lgr.Error("progress message not sent", "err", delivery.Err)
+	}
+
 	h.pendingCh <- pendingData{
 		torrentID:      *torrent.ID,
 		originalChatId: msg.Chat.ID,
 		originialMsgId: msg.MessageID,
+		progressMsgId:  delivery.Message.MessageID,
 	}
 
-	reply := tgbotapi.NewMessage(msg.Chat.ID, "ok!")
-	reply.ReplyToMessageID = msg.MessageID
-	h.outCh <- reply
-
 	lgr.Info("torrent added", "torrent.Name", *torrent.Name)
 	return nil
 }
@@ -191,7 +174,7 @@ Disk free space: %s`,
 		diskAvailMem)
 	reply := tgbotapi.NewMessage(msg.Chat.ID, statsText)
 	reply.ReplyToMessageID = msg.MessageID
-	h.outCh <- reply
+	h.OutMsgCh <- reply
 	return nil
 }
 
@@ -216,12 +199,12 @@ func (h *commandHandler) handleList(msg *tgbotapi.Message, lgr *slog.Logger) err
 	finishedFull := append([]string{"Finished downloads:"}, finished...)
 	finishedFullText := strings.Join(finishedFull, "\n\n")
 	finishedMsg := tgbotapi.NewMessage(msg.Chat.ID, finishedFullText)
-	h.outCh <- finishedMsg
+	h.OutMsgCh <- finishedMsg
 
 	unfinishedFull := append([]string{"In Progress downloads:"}, inprogress...)
 	unfinishedFullText := strings.Join(unfinishedFull, "\n\n")
 	unfinishedMsg := tgbotapi.NewMessage(msg.Chat.ID, unfinishedFullText)
-	h.outCh <- unfinishedMsg
+	h.OutMsgCh <- unfinishedMsg
 
 	return nil
 }
@@ -266,16 +249,33 @@ func (h *commandHandler) watchPending() {
 				torrent := torrentData[0]
 				isFinished := (*torrent.Status == transmissionrpc.TorrentStatusStopped || *torrent.Status == transmissionrpc.TorrentStatusSeed || *torrent.Status == transmissionrpc.TorrentStatusSeedWait)
 				if !isFinished {
+					h.updateProgress(torrentID, data, torrent)
 					continue
 				}
+				if data.progressMsgId != 0 {
+					h.Replier().EditText(tgbotbase.ChatID(data.originalChatId), data.progressMsgId, fmt.Sprintf("%s: done", *torrent.Name))
+				}
 
 				finishedText := fmt.Sprintf("Download finished!\nName: %s\nSize: %s; time spent: %s", *torrent.Name, torrent.TotalSize, *torrent.TimeDownloading)
 				replyMsg := tgbotapi.NewMessage(data.originalChatId, finishedText)
 				replyMsg.ReplyToMessageID = data.originialMsgId
-				h.outCh <- replyMsg
+				h.OutMsgCh <- replyMsg
 				slog.Info("pending done", "torrent_id", torrentID)
 				delete(h.pendingWatchlist, torrentID)
 			}
 		}
 	}
 }
+
+func (h *commandHandler) updateProgress(torrentID int64, data pendingData, torrent transmissionrpc.Torrent) {
+	if data.progressMsgId == 0 {
+		return
+	}
+	progress := fmt.Sprintf("%s: %.1f%%; ETA: %s (status: %s)", *torrent.Name, *torrent.PercentComplete*100, time.Duration(*torrent.ETA)*time.Second, torrent.Status)
+	if progress == data.lastProgress { // Telegram refuses edits which don't change the text
+		return
+	}
+	h.Replier().EditText(tgbotbase.ChatID(data.originalChatId), data.progressMsgId, progress)
+	data.lastProgress = progress
+	h.pendingWatchlist[torrentID] = data
+}
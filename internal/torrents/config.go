@@ -0,0 +1,31 @@
+package torrents
+
+import (
+	"fmt"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+	"gopkg.in/gcfg.v1"
+)
+
+type Config struct {
+	tgbotbase.Config
+	Torrents struct {
+		AllowedUser          []int64
+		TransmissionPassword string
+	}
+}
+
+// NewConfig reads torrents bot configuration from the file
+func NewConfig(filename string) (Config, error) {
+	var cfg Config
+	if err := gcfg.ReadFileInto(&cfg, filename); err != nil {
+		return cfg, fmt.Errorf("cannot read config %q: %w", filename, err)
+	}
+	if len(cfg.Torrents.AllowedUser) == 0 {
+		return cfg, fmt.Errorf("no allowed users found")
+	}
+	if cfg.Torrents.TransmissionPassword == "" {
+		return cfg, fmt.Errorf("transmission password not set")
+	}
+	return cfg, nil
+}
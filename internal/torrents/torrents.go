@@ -0,0 +1,29 @@
+package torrents
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hekmon/transmissionrpc/v3"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+)
+
+// Setup creates torrents bot at the host and registers its command handler
+func Setup(host *tgbotbase.Host, name string, cfg Config) error {
+	bturlRaw := fmt.Sprintf("http://transmission:%s@127.0.0.1:9091/transmission/rpc", cfg.Torrents.TransmissionPassword)
+	bturl, err := url.Parse(bturlRaw)
+	if err != nil {
+		return fmt.Errorf("cannot parse transmission url: %w", err)
+	}
+	btclient, err := transmissionrpc.New(bturl, nil)
+	if err != nil {
+		return fmt.Errorf("cannot connect to transmission: %w", err)
+	}
+
+	bot, err := host.NewBot(name, tgbotbase.Config{TGBot: cfg.TGBot, Proxy_SOCKS5: cfg.Proxy_SOCKS5})
+	if err != nil {
+		return fmt.Errorf("cannot start telegram bot, err: %w", err)
+	}
+	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(newCommandHanler(cfg, btclient)))
+	return nil
+}
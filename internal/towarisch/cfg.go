@@ -11,7 +11,11 @@ type Config struct {
 	tgbotbase.Config
 	Redis   tgbotbase.RedisConfig
 	Weather struct {
-		Token string
+		// Provider is 'openweathermap' or 'openmeteo'; by default OpenWeatherMap is used if there is a token
+		Provider string
+		Token    string
+		// Cities is a city index made by tools/openweathermap_city_parser; the bundled one is used if empty
+		Cities string
 	}
 
 	Owners struct {
@@ -2,11 +2,8 @@ package cmd
 
 import (
 	"context"
-	"io"
 	"log"
 	"net/http"
-	"os"
-	"path"
 	"strings"
 	"time"
 
@@ -18,12 +15,14 @@ type kittiesHandler struct {
 	tgbotbase.BaseHandler
 	properties tgbotbase.PropertyStorage
 	cron       tgbotbase.Cron
+	localizer  *tgbotbase.Localizer
 }
 
 func NewKittiesHandler(cron tgbotbase.Cron, properties tgbotbase.PropertyStorage) tgbotbase.BackgroundMessageHandler {
 	handler := kittiesHandler{
 		properties: properties,
-		cron:       cron}
+		cron:       cron,
+		localizer:  tgbotbase.NewLocalizer(texts, properties)}
 	return &handler
 }
 
@@ -49,7 +48,7 @@ func (h *kittiesHandler) Run() {
 			continue
 		}
 		when := tgbotbase.CalcNextTimeFromMidnight(now, dur)
-		job := kittiesJob{chatID: prop.Chat}
+		job := kittiesJob{chatID: prop.Chat, localizer: h.localizer}
 		job.OutMsgCh = h.OutMsgCh
 		h.cron.AddJob(when, &job)
 	}
@@ -57,7 +56,8 @@ func (h *kittiesHandler) Run() {
 
 type kittiesJob struct {
 	tgbotbase.BaseHandler
-	chatID tgbotbase.ChatID
+	chatID    tgbotbase.ChatID
+	localizer *tgbotbase.Localizer
 }
 
 func (job *kittiesJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
@@ -71,29 +71,22 @@ func (job *kittiesJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 		return
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Cat could not be loaded, %s has replied with %s. Aborting loading", url, resp.Status)
+		return
+	}
 
-	// TODO: via filecache or something
 	actualURL := resp.Request.URL.String()
 	log.Printf("Cat received from %s", actualURL)
 	actualURLParts := strings.Split(actualURL, "/")
 	filename := actualURLParts[len(actualURLParts)-1] // getting last piece as actual filename
-	fpath := path.Join("/tmp", filename)
-	file, err := os.Create(fpath)
-	if err != nil {
-		log.Printf("Could not create new file for a cat %s due to error: %s. Skipping this one", filename, err)
-		return
-	}
-	// Use io.Copy to just dump the response body to the file. This supports huge files
-	_, err = io.Copy(file, resp.Body)
+
+	picMsg, err := tgbotbase.NewPhotoFromReader(job.chatID, filename, resp.Body)
 	if err != nil {
-		// TODO: remove created file
-		log.Printf("Could not store a catpic from the Internet to %s due to error: %s", filename, err)
+		log.Printf("Could not load a catpic %s from the Internet due to error: %s", filename, err)
 		return
 	}
-	file.Close()
-
-	picMsg := tgbotapi.NewPhotoUpload(int64(job.chatID), fpath)
-	picMsg.Caption = "утренний котик!"
+	picMsg.Caption = job.localizer.For(context.TODO(), 0, job.chatID).T("kitties.caption")
 
 	job.OutMsgCh <- picMsg
 }
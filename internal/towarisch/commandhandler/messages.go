@@ -0,0 +1,129 @@
+package cmd
+
+import "github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+
+// texts keeps replies of towarisch handlers; Russian is the default language
+var texts = tgbotbase.NewCatalog("ru")
+
+func init() {
+	texts.Add("ru", map[string]string{
+		"remind.fired":             "Напоминаю",
+		"remind.accepted":          "Принято, напомню около %s",
+		"remind.no_time":           "Не понял, когда напомнить",
+		"remind.in_past":           "Это время уже прошло",
+		"remind.failed":            "Не смог сохранить напоминание :(",
+		"remind.fired_text":        "Напоминаю: %s",
+		"remind.id":                "Номер напоминания: #%d",
+		"remind.list_header":       "Твои напоминания:",
+		"remind.list_empty":        "Напоминаний нет",
+		"remind.unremind_usage":    "Укажи номер напоминания: /unremind <номер>",
+		"remind.cancelled":         "Напоминание #%d отменено",
+		"remind.not_found":         "Не нашёл напоминание #%d",
+		"remind.accepted_repeat":   "Принято, буду напоминать, начиная с %s",
+		"remind.repeating":         "(повторяется)",
+		"remind.late":              "(должен был напомнить в %s, но был недоступен)",
+		"remind.button_10m":        "+10 мин",
+		"remind.button_1h":         "+1 час",
+		"remind.button_tomorrow":   "Завтра",
+		"remind.button_done":       "Готово",
+		"remind.button_outdated":   "Это напоминание уже неактуально",
+		"remind.button_not_owner":  "Это не твоё напоминание",
+		"remind.done":              "✅ Выполнено",
+		"remind.snoozed":           "⏰ Отложено до %s",
+		"todo.usage":               "Список дел: /todo [add] <дело> [когда], /todo done <номер>, /todo list",
+		"todo.failed":              "Не смог обновить список дел :(",
+		"todo.added":               "Добавил дело #%d",
+		"todo.added_due":           "Добавил дело #%d, напомню около %s",
+		"todo.due":                 "дело #%d: %s",
+		"todo.not_found":           "Не нашёл дело #%d",
+		"todo.done":                "Готово: %s",
+		"todo.list_header":         "Список дел:",
+		"todo.list_empty":          "Список дел пуст",
+		"todo.until":               "(до %s)",
+		"weather.no_city":          "Не смог распарсить город :(",
+		"weather.err_place":        "Не знаю такого места :(",
+		"weather.err_unavailable":  "Сервис погоды сейчас недоступен, попробуй позже",
+		"weather.err_unauthorized": "Сервис погоды не принимает мой ключ, напиши хозяину бота",
+		"weather.err_bad_response": "Сервис погоды ответил что-то непонятное :(",
+		"weather.current":          "Сейчас в %s: %s, %.1f градусов (ощущается как %.1f), влажность %d%%",
+		"weather.wind":             ", ветер %.0f м/с",
+		"weather.wind_gust":        ", ветер %.0f м/с, порывы до %.0f м/с",
+		"weather.precip":           ", вероятность осадков %d%%",
+		"weather.precip_mm":        ", вероятность осадков %d%% (%.1f мм)",
+		"weather.point":            "%s: %.1f\u2103 (ощущается как %.1f\u2103), %s",
+		"weather.summary":          "За день: от %.1f\u2103 до %.1f\u2103, %s",
+		"weather.alert_header":     "Внимание! Завтра в %s ожидается:",
+		"weather.alert_frost":      "\n- мороз до %.0f\u2103",
+		"weather.alert_rain":       "\n- осадки %.1f мм",
+		"weather.alert_wind":       "\n- ветер с порывами до %.0f м/с",
+		"weather.night":            "Иди спи, нечего гулять по ночам",
+		"weather.choose_city":      "Какой именно город?",
+		"weather.saved":            "Запомнил место: %s",
+		"weather.save_failed":      "Не смог запомнить место :(",
+		"weather.save_usage":       "Напиши город или координаты: «погода запомни Нижний Новгород» или «погода запомни 56.33, 44.01»; можно ответить «погода запомни» на сообщение с геопозицией",
+		"weather.no_forecast":      "Я не смог сделать прогноз :(",
+		"weather.forecast":         "Прогнозирую на %s в %s:\n",
+		"kitties.caption":          "утренний котик!",
+		"news.nn_header":           "Нижегородские вести:",
+	})
+	texts.Add("en", map[string]string{
+		"remind.fired":             "Reminding you",
+		"remind.accepted":          "Got it, will remind you around %s",
+		"remind.no_time":           "Could not understand when to remind you",
+		"remind.in_past":           "This time has already passed",
+		"remind.failed":            "Could not save the reminder :(",
+		"remind.fired_text":        "Reminding you: %s",
+		"remind.id":                "Reminder number: #%d",
+		"remind.list_header":       "Your reminders:",
+		"remind.list_empty":        "No reminders",
+		"remind.unremind_usage":    "Tell me the reminder number: /unremind <number>",
+		"remind.cancelled":         "Reminder #%d has been cancelled",
+		"remind.not_found":         "Could not find reminder #%d",
+		"remind.accepted_repeat":   "Got it, will remind you starting from %s",
+		"remind.repeating":         "(repeating)",
+		"remind.late":              "(was due at %s, but I was unavailable)",
+		"remind.button_10m":        "+10 min",
+		"remind.button_1h":         "+1 hour",
+		"remind.button_tomorrow":   "Tomorrow",
+		"remind.button_done":       "Done",
+		"remind.button_outdated":   "This reminder is no longer relevant",
+		"remind.button_not_owner":  "This is not your reminder",
+		"remind.done":              "✅ Done",
+		"remind.snoozed":           "⏰ Snoozed until %s",
+		"todo.usage":               "Todo list: /todo [add] <task> [when], /todo done <number>, /todo list",
+		"todo.failed":              "Could not update the todo list :(",
+		"todo.added":               "Added task #%d",
+		"todo.added_due":           "Added task #%d, will remind you around %s",
+		"todo.due":                 "task #%d: %s",
+		"todo.not_found":           "Could not find task #%d",
+		"todo.done":                "Done: %s",
+		"todo.list_header":         "Todo list:",
+		"todo.list_empty":          "The todo list is empty",
+		"todo.until":               "(due %s)",
+		"weather.no_city":          "Could not figure out the city :(",
+		"weather.err_place":        "I don't know this place :(",
+		"weather.err_unavailable":  "Weather service is unavailable now, try again later",
+		"weather.err_unauthorized": "Weather service rejects my key, please tell the bot owner",
+		"weather.err_bad_response": "Weather service responded with something strange :(",
+		"weather.current":          "Now in %s: %s, %.1f degrees (feels like %.1f), humidity %d%%",
+		"weather.wind":             ", wind %.0f m/s",
+		"weather.wind_gust":        ", wind %.0f m/s, gusts up to %.0f m/s",
+		"weather.precip":           ", precipitation chance %d%%",
+		"weather.precip_mm":        ", precipitation chance %d%% (%.1f mm)",
+		"weather.point":            "%s: %.1f\u2103 (feels like %.1f\u2103), %s",
+		"weather.summary":          "Daytime: from %.1f\u2103 to %.1f\u2103, %s",
+		"weather.alert_header":     "Heads up! Tomorrow in %s expect:",
+		"weather.alert_frost":      "\n- frost down to %.0f\u2103",
+		"weather.alert_rain":       "\n- %.1f mm of precipitation",
+		"weather.alert_wind":       "\n- wind gusts up to %.0f m/s",
+		"weather.night":            "Go to sleep, no walking at night",
+		"weather.choose_city":      "Which city exactly?",
+		"weather.saved":            "Saved the place: %s",
+		"weather.save_failed":      "Could not save the place :(",
+		"weather.save_usage":       "Tell me a city or coordinates: \"weather save Nizhny Novgorod\" or \"weather save 56.33, 44.01\"; you can also reply \"weather save\" to a shared location",
+		"weather.no_forecast":      "Could not make a forecast :(",
+		"weather.forecast":         "Forecast for %s in %s:\n",
+		"kitties.caption":          "morning kitty!",
+		"news.nn_header":           "Nizhny Novgorod news:",
+	})
+}
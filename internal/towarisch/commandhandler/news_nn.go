@@ -16,12 +16,14 @@ type newsNNHandler struct {
 	tgbotbase.BaseHandler
 	properties tgbotbase.PropertyStorage
 	cron       tgbotbase.Cron
+	localizer  *tgbotbase.Localizer
 }
 
 func NewNewsNNHandler(cron tgbotbase.Cron, properties tgbotbase.PropertyStorage) tgbotbase.BackgroundMessageHandler {
 	handler := newsNNHandler{
 		properties: properties,
-		cron:       cron}
+		cron:       cron,
+		localizer:  tgbotbase.NewLocalizer(texts, properties)}
 	return &handler
 }
 
@@ -47,7 +49,7 @@ func (h *newsNNHandler) Run() {
 			continue
 		}
 		when := tgbotbase.CalcNextTimeFromMidnight(now, dur)
-		job := newsNNJob{chatID: prop.Chat}
+		job := newsNNJob{chatID: prop.Chat, localizer: h.localizer}
 		job.OutMsgCh = h.OutMsgCh
 		h.cron.AddJob(when, &job)
 	}
@@ -55,7 +57,8 @@ func (h *newsNNHandler) Run() {
 
 type newsNNJob struct {
 	tgbotbase.BaseHandler
-	chatID tgbotbase.ChatID
+	chatID    tgbotbase.ChatID
+	localizer *tgbotbase.Localizer
 }
 
 func (job *newsNNJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
@@ -72,7 +75,7 @@ func (job *newsNNJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 		return
 	}
 
-	text := "Нижегородские вести:"
+	text := job.localizer.For(context.TODO(), 0, job.chatID).T("news.nn_header")
 	for _, n := range news {
 		text = fmt.Sprintf("%s\n%s", text, n.ToMarkdown())
 	}
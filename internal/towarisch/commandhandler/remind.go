@@ -5,137 +5,332 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
 const timeFormat_Out_Confirm = "2006-01-02 15:04:05 MST"
+const timeFormat_Out_List = "2006-01-02 15:04"
 
 type remindCronJob struct {
-	outMsgCh chan<- tgbotapi.Chattable
-	storage  ReminderStorage
-
+	h        *remindHandler
 	reminder Reminder
+	at       time.Time     // when the job is scheduled, later than the reminder time for retries
+	retry    time.Duration // delay before the next attempt, zero for the first one
 }
 
-func newRemindCronJob(storage ReminderStorage, outMsgCh chan<- tgbotapi.Chattable, reminder Reminder) remindCronJob {
-	job := remindCronJob{
-		outMsgCh: outMsgCh,
-		storage:  storage,
-		reminder: reminder}
-	storage.AddReminder(reminder)
-	return job
-}
+// reminderLateThreshold is a delay after which a fired reminder mentions its original time
+const reminderLateThreshold = time.Minute
+
+// reminderRetryDelay is a delay before resending an undelivered reminder, doubled after every failure up to reminderRetryMaxDelay
+const reminderRetryDelay = time.Minute
+const reminderRetryMaxDelay = time.Hour
+
+// reminderCatchUpGrace is a maximum delay of a reminder missed while the bot was down; older reminders are dropped
+const reminderCatchUpGrace = 12 * time.Hour
 
 func (j *remindCronJob) Do(scheduled time.Time, cron tgbotbase.Cron) {
-	msg := tgbotapi.NewMessage(int64(j.reminder.chat), "Напоминаю")
-	msg.BaseChat.ReplyToMessageID = j.reminder.replyTo
+	r := j.reminder
+	late := time.Since(r.t)
+	if late > reminderCatchUpGrace {
+		log.Printf("Reminder %d is late for %s, dropping it", r.id, late)
+		j.h.finish(r, true)
+		return
+	}
+
+	tr := j.h.localizer.For(context.TODO(), r.user, r.chat)
+	text := tr.T("remind.fired")
+	if r.text != "" {
+		text = tr.T("remind.fired_text", r.text)
+	}
+	if late > reminderLateThreshold {
+		loc := userLocation(j.h.properties, r.user, r.chat)
+		text = fmt.Sprintf("%s %s", text, tr.T("remind.late", r.t.In(loc).Format(timeFormat_Out_List)))
+	}
+	msg := tgbotapi.NewMessage(int64(r.chat), text)
+	msg.BaseChat.ReplyToMessageID = r.replyTo
+	msg.BaseChat.ReplyMarkup = reminderButtons(tr, r.id)
+
+	delivery := <-tgbotbase.SendWithAck(j.h.OutMsgCh, msg)
+	chatInactive := errors.Is(delivery.Err, tgbotbase.ErrChatInactive)
+	if delivery.Err != nil && !chatInactive && r.repeat == nil {
+		log.Printf("Reminder for chat %d has not been delivered, retrying; error: %s", r.chat, delivery.Err)
+		j.reschedule(cron)
+		return
+	}
+	if r.repeat == nil && !chatInactive {
+		// keeping fired reminder until the user presses one of its buttons
+		r.fired = true
+		r.firedAt = time.Now()
+		j.h.storage.AddReminder(r)
+		j.h.jobs.remove(r.id)
+		return
+	}
+	j.h.finish(r, !chatInactive)
+}
 
-	j.outMsgCh <- msg
-	j.storage.RemoveReminder(j.reminder)
+// reschedule runs a copy of the job after the retry delay, which is doubled for the next attempt;
+// nothing is done if the reminder has been cancelled meanwhile
+func (j *remindCronJob) reschedule(cron tgbotbase.Cron) {
+	delay := j.retry
+	if delay == 0 {
+		delay = reminderRetryDelay
+	}
+	next := &remindCronJob{h: j.h, reminder: j.reminder, at: time.Now().Add(delay), retry: min(2*delay, reminderRetryMaxDelay)}
+	if !j.h.jobs.replace(j, next) {
+		return
+	}
+	cron.AddJob(next.at, next)
+}
+
+// finish removes the fired reminder; a recurring one is scheduled to its next time if reschedule is set
+func (h *remindHandler) finish(r Reminder, reschedule bool) {
+	h.storage.RemoveReminder(r)
+	h.jobs.remove(r.id)
+	if r.repeat == nil || !reschedule {
+		return
+	}
+	now := time.Now()
+	for !r.t.After(now) {
+		r.t = r.repeat.Next(r.t)
+	}
+	log.Printf("Recurring reminder %d is rescheduled to %s", r.id, r.t)
+	h.schedule(r)
+}
+
+// reminderJobs keeps scheduled jobs by reminder ID so they could be cancelled
+type reminderJobs struct {
+	mutex sync.Mutex
+	byID  map[int64]*remindCronJob
+}
+
+func (js *reminderJobs) add(j *remindCronJob) {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if js.byID == nil {
+		js.byID = make(map[int64]*remindCronJob)
+	}
+	js.byID[j.reminder.id] = j
+}
+
+// replace puts the new job instead of the old one if the old one is still scheduled
+func (js *reminderJobs) replace(old, next *remindCronJob) bool {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	if js.byID[old.reminder.id] != old {
+		return false
+	}
+	js.byID[next.reminder.id] = next
+	return true
+}
+
+func (js *reminderJobs) remove(id int64) *remindCronJob {
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+	j := js.byID[id]
+	delete(js.byID, id)
+	return j
 }
 
 type remindHandler struct {
 	tgbotbase.BaseHandler
 	cron       tgbotbase.Cron
 	storage    ReminderStorage
+	todos      TodoStorage
 	properties tgbotbase.PropertyStorage
+	localizer  *tgbotbase.Localizer
+	jobs       reminderJobs
 }
 
-func NewRemindHandler(cron tgbotbase.Cron, storage ReminderStorage, properties tgbotbase.PropertyStorage) *remindHandler {
+func NewRemindHandler(cron tgbotbase.Cron, storage ReminderStorage, todos TodoStorage, properties tgbotbase.PropertyStorage) *remindHandler {
 	handler := &remindHandler{
 		cron:       cron,
 		storage:    storage,
-		properties: properties}
+		todos:      todos,
+		properties: properties,
+		localizer:  tgbotbase.NewLocalizer(texts, properties)}
 
 	return handler
 }
 
-func determineReminderTime(msg string) (time.Time, error) {
-	reAfter := regexp.MustCompile("через (\\d*) *([\\wа-я]+)")
-	// TODO: uncomment during implementation
-	//reAt := regexp.MustCompile("в (\\d{1,2}):(\\d{1,2})")
-	//reTomorrow := regexp.MustCompile("завтра")
-	//reDayAfterTomorrow := regexp.MustCompile("послезавтра")
-	// TODO: add days of week parsing
-
-	now := time.Now()
-	if reAfter.MatchString(msg) {
-		log.Printf("Message '%s' matches 'after' regexp %s", msg, reAfter)
-		matches := reAfter.FindStringSubmatch(msg)
-		timeQuantity := matches[1] // (\d+)
-		timePeriod := matches[2]   // ([\wа-я]+)
+const defaultReminderHour = 9
 
-		log.Printf("Reminder command matched: quantity '%s' period '%s'", timeQuantity, timePeriod)
+var errNoReminderTime = errors.New("no time in reminder")
+var errReminderInPast = errors.New("reminder time has passed")
 
-		var q int = 1
-		if len(timeQuantity) > 0 {
-			q, _ = strconv.Atoi(timeQuantity)
+// determineReminder finds time of the reminder in the message and returns it with the rest of the message as a reminder text;
+// a reminder for a day without time is set to the morning
+func determineReminder(msg string, now time.Time) (Reminder, error) {
+	if rec, found := nltime.ParseRecurrence(msg); found {
+		msg = rec.Strip(msg)
+		r := Reminder{repeat: &rec}
+		res, found := nltime.Parse(msg, now)
+		switch {
+		case found && res.HasTime && !rec.HasWeekday && rec.Unit < nltime.Day:
+			r.t = res.Time
+		case found && res.HasTime:
+			r.t = rec.First(now, res.Time.Hour(), res.Time.Minute())
+		case found:
+			r.t = rec.First(res.Time.Add(-time.Nanosecond), defaultReminderHour, 0)
+		case rec.Unit < nltime.Day:
+			r.t = rec.Next(now)
+		default:
+			r.t = rec.First(now, defaultReminderHour, 0)
 		}
-		period := time.Minute
-		matchedSecond, _ := regexp.MatchString("секунд", timePeriod)
-		matchedMinute, _ := regexp.MatchString("минут", timePeriod)
-		matchedHour, _ := regexp.MatchString("час", timePeriod)
-		matchedDay, _ := regexp.MatchString("день|дня|дней", timePeriod)
-		matchedWeek, _ := regexp.MatchString("недел", timePeriod)
-		matchedMonth, _ := regexp.MatchString("месяц", timePeriod)
-		matchedYear, _ := regexp.MatchString("год|лет", timePeriod)
-		if matchedSecond {
-			period = time.Second
-		} else if matchedMinute {
-			period = time.Minute
-		} else if matchedHour {
-			period = time.Hour
-		} else if matchedDay {
-			period = 24 * time.Hour
-		} else if matchedWeek {
-			period = 7 * 24 * time.Hour
-		} else if matchedMonth {
-			period = 30 * 24 * time.Hour
-		} else if matchedYear {
-			period = 365 * 24 * time.Hour
-		} else {
-			log.Printf("Time period %s doesn't match any known format", timePeriod)
-			err := errors.New("Time period doesn't match any known")
-			return now, err
+		if found {
+			msg = res.Strip(msg)
 		}
+		log.Printf("Recurring reminder in '%s' starts at %s", msg, r.t)
+		r.text = msg
+		return r, nil
+	}
 
-		return now.Add(period * time.Duration(q)), nil
+	res, found := nltime.Parse(msg, now)
+	if !found {
+		return Reminder{}, errNoReminderTime
 	}
+	log.Printf("Reminder time in '%s' is %s", msg, res.Time)
 
-	return now, nil
+	t := res.Time
+	if !res.HasTime {
+		t = t.Add(defaultReminderHour * time.Hour)
+	}
+	if !t.After(now) {
+		return Reminder{}, errReminderInPast
+	}
+	return Reminder{t: t, text: res.Strip(msg)}, nil
+}
+
+// schedule stores the reminder and adds its job to cron
+func (h *remindHandler) schedule(r Reminder) {
+	h.storage.AddReminder(r)
+	job := &remindCronJob{h: h, reminder: r, at: r.t}
+	h.jobs.add(job)
+	h.cron.AddJob(job.at, job)
+}
+
+// unschedule removes the reminder from the storage and cancels its job
+func (h *remindHandler) unschedule(r Reminder) {
+	if job := h.jobs.remove(r.id); job != nil {
+		h.cron.RemoveJob(job.at, job)
+	}
+	h.storage.RemoveReminder(r)
 }
 
 func (h *remindHandler) HandleOne(msg tgbotapi.Message) {
-	t, err := determineReminderTime(msg.Text)
-	if err != nil {
-		log.Printf("Could not determine time from message '%s' with error: %s", msg.Text, err)
+	switch msg.Command() {
+	case "todo":
+		h.todo(msg)
+	case "reminders":
+		h.list(msg)
+	case "unremind":
+		h.cancel(msg)
+	default:
+		h.add(msg)
 	}
+}
 
-	job := newRemindCronJob(h.storage, h.OutMsgCh, Reminder{
-		chat:    tgbotbase.ChatID(msg.Chat.ID),
-		replyTo: msg.MessageID,
-		t:       t})
-	h.cron.AddJob(t, &job)
+func (h *remindHandler) reply(msg tgbotapi.Message, text string) {
+	replyMsg := tgbotapi.NewMessage(msg.Chat.ID, text)
+	replyMsg.BaseChat.ReplyToMessageID = msg.MessageID
+	h.OutMsgCh <- replyMsg
+}
 
-	tz, _ := h.properties.GetProperty(context.TODO(), "timezone", tgbotbase.UserID(msg.From.ID), tgbotbase.ChatID(msg.Chat.ID))
-	loc, err := time.LoadLocation(tz)
+func (h *remindHandler) add(msg tgbotapi.Message) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	args := msg.CommandArguments()
+	r, err := determineReminder(args, time.Now().In(userLocation(h.properties, user, chat)))
 	if err != nil {
-		log.Printf("Could not load timezone %s correctly; location loaded with error: %s", tz, err)
-	} else {
-		t = t.In(loc)
+		log.Printf("Could not determine time from message '%s' with error: %s", args, err)
+		key := "remind.no_time"
+		if errors.Is(err, errReminderInPast) {
+			key = "remind.in_past"
+		}
+		h.reply(msg, tr.T(key))
+		return
 	}
 
-	replyText := fmt.Sprintf("Принято, напомню около %s", t.Format(timeFormat_Out_Confirm))
-	replyMsg := tgbotapi.NewMessage(msg.Chat.ID, replyText)
-	replyMsg.BaseChat.ReplyToMessageID = msg.MessageID
-	h.OutMsgCh <- replyMsg
+	r.id, err = h.storage.NextID()
+	if err != nil {
+		log.Printf("Could not get new reminder ID due to error: %s", err)
+		h.reply(msg, tr.T("remind.failed"))
+		return
+	}
+	r.chat = chat
+	r.user = user
+	r.replyTo = msg.MessageID
+	h.schedule(r)
+
+	accepted := tr.T("remind.accepted", r.t.Format(timeFormat_Out_Confirm))
+	if r.repeat != nil {
+		accepted = tr.T("remind.accepted_repeat", r.t.Format(timeFormat_Out_Confirm))
+	}
+	h.reply(msg, accepted+"\n"+tr.T("remind.id", r.id))
+}
+
+// userReminders returns pending reminders of the user in the chat sorted by time
+func (h *remindHandler) userReminders(user tgbotbase.UserID, chat tgbotbase.ChatID) []Reminder {
+	reminders := make([]Reminder, 0)
+	for _, r := range h.storage.LoadAll() {
+		if r.user == user && r.chat == chat && !r.fired {
+			reminders = append(reminders, r)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].t.Before(reminders[j].t) })
+	return reminders
+}
 
+func (h *remindHandler) list(msg tgbotapi.Message) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	reminders := h.userReminders(user, chat)
+	if len(reminders) == 0 {
+		h.reply(msg, tr.T("remind.list_empty"))
+		return
+	}
+	loc := userLocation(h.properties, user, chat)
+	text := tr.T("remind.list_header")
+	for _, r := range reminders {
+		text = fmt.Sprintf("%s\n#%d %s %s", text, r.id, r.t.In(loc).Format(timeFormat_Out_List), r.text)
+		if r.repeat != nil {
+			text = fmt.Sprintf("%s %s", text, tr.T("remind.repeating"))
+		}
+	}
+	h.reply(msg, text)
+}
+
+func (h *remindHandler) cancel(msg tgbotapi.Message) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(msg.CommandArguments()), "#"), 10, 64)
+	if err != nil {
+		h.reply(msg, tr.T("remind.unremind_usage"))
+		return
+	}
+	for _, r := range h.userReminders(user, chat) {
+		if r.id != id {
+			continue
+		}
+		h.unschedule(r)
+		h.reply(msg, tr.T("remind.cancelled", id))
+		return
+	}
+	h.reply(msg, tr.T("remind.not_found", id))
 }
 
 func (h *remindHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- tgbotbase.ServiceMsg) tgbotbase.HandlerTrigger {
@@ -143,11 +338,14 @@ func (h *remindHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- tg
 
 	allReminders := h.storage.LoadAll()
 	for _, r := range allReminders {
-		job := newRemindCronJob(h.storage, outMsgCh, r)
-		h.cron.AddJob(r.t, &job)
+		if !r.fired {
+			h.schedule(r)
+		} else if time.Since(r.firedTime()) > firedReminderRetention {
+			h.storage.RemoveReminder(r)
+		}
 	}
 
-	return tgbotbase.NewHandlerTrigger(nil, []string{"remind", "todo"})
+	return tgbotbase.NewHandlerTrigger(nil, []string{"remind", "todo", "reminders", "unremind"})
 }
 
 func (h *remindHandler) Name() string {
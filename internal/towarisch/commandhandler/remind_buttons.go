@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// firedReminderRetention is how long a fired reminder is kept for its buttons to work
+const firedReminderRetention = 7 * 24 * time.Hour
+
+const reminderCallbackPrefix = "remind:"
+
+const (
+	reminderActionSnooze10m = "10m"
+	reminderActionSnooze1h  = "1h"
+	reminderActionTomorrow  = "tomorrow"
+	reminderActionDone      = "done"
+)
+
+// firedTime returns when the reminder has been sent; records made before firedAt was stored had it in t
+func (r Reminder) firedTime() time.Time {
+	if r.firedAt.IsZero() {
+		return r.t
+	}
+	return r.firedAt
+}
+
+func reminderCallbackData(id int64, action string) string {
+	return fmt.Sprintf("%s%d:%s", reminderCallbackPrefix, id, action)
+}
+
+func reminderButtons(tr tgbotbase.Texts, id int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("remind.button_10m"), reminderCallbackData(id, reminderActionSnooze10m)),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("remind.button_1h"), reminderCallbackData(id, reminderActionSnooze1h)),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("remind.button_tomorrow"), reminderCallbackData(id, reminderActionTomorrow)),
+		tgbotapi.NewInlineKeyboardButtonData(tr.T("remind.button_done"), reminderCallbackData(id, reminderActionDone))))
+}
+
+func (h *remindHandler) CallbackPrefix() string {
+	return reminderCallbackPrefix
+}
+
+func (h *remindHandler) HandleCallback(q tgbotapi.CallbackQuery) string {
+	var chat tgbotbase.ChatID
+	if q.Message != nil && q.Message.Chat != nil {
+		chat = tgbotbase.ChatID(q.Message.Chat.ID)
+	}
+	user := tgbotbase.UserID(q.From.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	splits := strings.SplitN(strings.TrimPrefix(q.Data, reminderCallbackPrefix), ":", 2)
+	if len(splits) != 2 {
+		log.Printf("Unexpected reminder callback data '%s'", q.Data)
+		return ""
+	}
+	id, err := strconv.ParseInt(splits[0], 10, 64)
+	if err != nil {
+		log.Printf("Unexpected reminder ID in callback data '%s'", q.Data)
+		return ""
+	}
+	action := splits[1]
+
+	var r Reminder
+	found := false
+	for _, stored := range h.storage.LoadAll() {
+		if stored.id == id {
+			r, found = stored, true
+			break
+		}
+	}
+	if !found || (!r.fired && r.repeat == nil) {
+		return tr.T("remind.button_outdated")
+	}
+	if r.user != 0 && r.user != user {
+		return tr.T("remind.button_not_owner")
+	}
+
+	var outcome string
+	if action == reminderActionDone {
+		if r.repeat == nil {
+			h.storage.RemoveReminder(r)
+		}
+		outcome = tr.T("remind.done")
+	} else {
+		loc := userLocation(h.properties, r.user, r.chat)
+		now := time.Now().In(loc)
+		var t time.Time
+		switch action {
+		case reminderActionSnooze10m:
+			t = now.Add(10 * time.Minute)
+		case reminderActionSnooze1h:
+			t = now.Add(time.Hour)
+		case reminderActionTomorrow:
+			clock := r.t.In(loc)
+			t = time.Date(now.Year(), now.Month(), now.Day()+1, clock.Hour(), clock.Minute(), 0, 0, loc)
+		default:
+			log.Printf("Unexpected reminder action in callback data '%s'", q.Data)
+			return ""
+		}
+
+		snoozed := r
+		snoozed.t = t
+		snoozed.fired = false
+		snoozed.firedAt = time.Time{}
+		if r.repeat != nil {
+			// the recurring reminder keeps its schedule, a one-time copy is snoozed
+			snoozed.repeat = nil
+			if snoozed.id, err = h.storage.NextID(); err != nil {
+				log.Printf("Could not get new reminder ID due to error: %s", err)
+				return tr.T("remind.failed")
+			}
+		}
+		h.schedule(snoozed)
+		outcome = tr.T("remind.snoozed", t.Format(timeFormat_Out_List))
+	}
+
+	if q.Message != nil {
+		h.Replier().EditText(chat, q.Message.MessageID, fmt.Sprintf("%s\n%s", q.Message.Text, outcome))
+	}
+	return outcome
+}
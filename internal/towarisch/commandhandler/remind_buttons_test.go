@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// emptyProperties has no properties set, so defaults are used
+type emptyProperties struct{}
+
+func (emptyProperties) GetProperty(ctx context.Context, name string, user tgbotbase.UserID, chat tgbotbase.ChatID) (string, error) {
+	return "", nil
+}
+
+func (emptyProperties) SetPropertyForUser(ctx context.Context, name string, user tgbotbase.UserID, value interface{}) error {
+	return nil
+}
+
+func (emptyProperties) SetPropertyForChat(ctx context.Context, name string, chat tgbotbase.ChatID, value interface{}) error {
+	return nil
+}
+
+func (emptyProperties) SetPropertyForUserInChat(ctx context.Context, name string, user tgbotbase.UserID, chat tgbotbase.ChatID, value interface{}) error {
+	return nil
+}
+
+func (emptyProperties) GetEveryHavingProperty(ctx context.Context, name string) ([]tgbotbase.PropertyValue, error) {
+	return nil, nil
+}
+
+func newTestRemindHandler() (*remindHandler, *memoryReminderStorage, *testCron) {
+	storage := newMemoryReminderStorage()
+	cron := &testCron{}
+	h := NewRemindHandler(cron, storage, nil, emptyProperties{})
+	return h, storage, cron
+}
+
+func reminderCallback(user int, id int64, action string) tgbotapi.CallbackQuery {
+	return tgbotapi.CallbackQuery{From: &tgbotapi.User{ID: user}, Data: reminderCallbackData(id, action)}
+}
+
+func TestReminderSnooze(t *testing.T) {
+	h, storage, cron := newTestRemindHandler()
+	scheduled := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.Local)
+	storage.lastID = 10
+	storage.AddReminder(Reminder{id: 1, t: scheduled, user: 5, text: "зарядка", fired: true, firedAt: scheduled.Add(3 * time.Hour)})
+
+	before := time.Now()
+	h.HandleCallback(reminderCallback(5, 1, reminderActionSnooze10m))
+	r := storage.reminders[1]
+	if r.fired || r.t.Before(before.Add(10*time.Minute)) || r.t.After(time.Now().Add(10*time.Minute)) {
+		t.Errorf("expected pending reminder in 10 minutes, got %+v", r)
+	}
+	if len(cron.jobs[r.t]) != 1 {
+		t.Errorf("expected snoozed reminder to be scheduled, got %v", cron.jobs)
+	}
+
+	storage.AddReminder(Reminder{id: 1, t: scheduled, user: 5, fired: true, firedAt: scheduled.Add(3 * time.Hour)})
+	h.HandleCallback(reminderCallback(5, 1, reminderActionTomorrow))
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 9, 0, 0, 0, time.Local)
+	if r := storage.reminders[1]; !r.t.Equal(tomorrow) {
+		t.Errorf("expected the reminder tomorrow at the scheduled time %s, got %s", tomorrow, r.t)
+	}
+}
+
+func TestReminderSnoozeRecurring(t *testing.T) {
+	h, storage, _ := newTestRemindHandler()
+	daily, _ := determineReminder("каждый день в 9:00 зарядка", time.Now())
+	daily.id, daily.user = 1, 5
+	storage.lastID = 10
+	storage.AddReminder(daily)
+
+	h.HandleCallback(reminderCallback(5, 1, reminderActionSnooze1h))
+	if r := storage.reminders[1]; r.repeat == nil || !r.t.Equal(daily.t) {
+		t.Errorf("recurring reminder should keep its schedule, got %+v", r)
+	}
+	if r, found := storage.reminders[11]; !found || r.repeat != nil || r.text != "зарядка" {
+		t.Errorf("expected one-time copy of the reminder, got %+v (found %v)", r, found)
+	}
+
+	h.HandleCallback(reminderCallback(5, 1, reminderActionDone))
+	if _, found := storage.reminders[1]; !found {
+		t.Errorf("recurring reminder should be kept when done")
+	}
+}
+
+func TestReminderDone(t *testing.T) {
+	h, storage, _ := newTestRemindHandler()
+	storage.AddReminder(Reminder{id: 1, t: time.Now(), user: 5, fired: true})
+	storage.AddReminder(Reminder{id: 2, t: time.Now().Add(time.Hour), user: 5})
+
+	if reply := h.HandleCallback(reminderCallback(6, 1, reminderActionDone)); reply != texts.Text("ru", "remind.button_not_owner") {
+		t.Errorf("expected not owner reply, got '%s'", reply)
+	}
+	if _, found := storage.reminders[1]; !found {
+		t.Fatalf("reminder should not be removed by another user")
+	}
+	if reply := h.HandleCallback(reminderCallback(5, 2, reminderActionDone)); reply != texts.Text("ru", "remind.button_outdated") {
+		t.Errorf("buttons of a pending reminder should be outdated, got '%s'", reply)
+	}
+
+	h.HandleCallback(reminderCallback(5, 1, reminderActionDone))
+	if _, found := storage.reminders[1]; found {
+		t.Errorf("done reminder should be removed")
+	}
+	if reply := h.HandleCallback(reminderCallback(5, 1, reminderActionDone)); reply != texts.Text("ru", "remind.button_outdated") {
+		t.Errorf("buttons of a removed reminder should be outdated, got '%s'", reply)
+	}
+}
@@ -3,16 +3,24 @@ package cmd
 import (
 	"time"
 
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 )
 
 type Reminder struct {
+	id      int64
 	t       time.Time
 	chat    tgbotbase.ChatID
-	replyTo int // message ID
+	user    tgbotbase.UserID // owner of the reminder
+	replyTo int              // message ID
+	text    string
+	repeat  *nltime.Recurrence // nil for one-time reminders
+	fired   bool               // notification has been sent; kept until its buttons are used
+	firedAt time.Time          // when the notification has been sent; t keeps the scheduled time
 }
 
 type ReminderStorage interface {
+	NextID() (int64, error)
 	AddReminder(Reminder)
 	RemoveReminder(Reminder)
 	LoadAll() []Reminder
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -10,79 +11,238 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 )
 
-var remindStart time.Time = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
-
+// RedisReminderStorage keeps reminders as versioned JSON records in hash 'reminders' keyed by reminder ID.
+// Records do not expire: a reminder is removed only when it has fired or has been cancelled.
 type RedisReminderStorage struct {
 	client *redis.Client
+	keys   tgbotbase.KeyPrefix
+}
+
+// NewRedisReminderStorage creates the storage and migrates reminders stored in the legacy key format;
+// keys are prefixed, so every bot has its own reminders
+func NewRedisReminderStorage(pool tgbotbase.RedisPool, keys tgbotbase.KeyPrefix) ReminderStorage {
+	s := &RedisReminderStorage{client: pool.GetConnByName("reminder"), keys: keys}
+	s.migrateLegacy()
+	return s
+}
+
+const remindersKey = "reminders"
+const reminderIDKey = "reminderid:last"
+
+// reminderRecordVersion is incremented on every incompatible change of reminderRecord
+const reminderRecordVersion = 1
+
+type reminderRecord struct {
+	Version int             `json:"v"`
+	ID      int64           `json:"id"`
+	Time    time.Time       `json:"time"`
+	Chat    int64           `json:"chat"`
+	User    int64           `json:"user"`
+	ReplyTo int             `json:"replyTo"`
+	Text    string          `json:"text"`
+	Repeat  *reminderRepeat `json:"repeat,omitempty"`
+	Fired   bool            `json:"fired,omitempty"`
+	FiredAt *time.Time      `json:"firedAt,omitempty"`
+}
+
+type reminderRepeat struct {
+	Every   int `json:"every"`
+	Unit    int `json:"unit"`
+	Weekday int `json:"weekday"` // -1 if not bound to a day of a week
 }
 
-func NewRedisReminderStorage(pool tgbotbase.RedisPool) ReminderStorage {
-	s := RedisReminderStorage{client: pool.GetConnByName("reminder")}
-	return &s
+func toReminderRepeat(rec *nltime.Recurrence) *reminderRepeat {
+	if rec == nil {
+		return nil
+	}
+	weekday := -1
+	if rec.HasWeekday {
+		weekday = int(rec.Weekday)
+	}
+	return &reminderRepeat{Every: rec.Every, Unit: int(rec.Unit), Weekday: weekday}
 }
 
-func reminderKey(r Reminder) string {
-	tdiff := r.t.Sub(remindStart)
-	return fmt.Sprintf("reminder:%d:%d:%d", int(tdiff.Seconds()), r.chat, r.replyTo)
+func (r *reminderRepeat) recurrence() *nltime.Recurrence {
+	if r == nil {
+		return nil
+	}
+	rec := &nltime.Recurrence{Every: r.Every, Unit: nltime.Unit(r.Unit)}
+	if r.Weekday >= 0 {
+		rec.Weekday = time.Weekday(r.Weekday)
+		rec.HasWeekday = true
+	}
+	return rec
+}
+
+func toReminderRecord(r Reminder) reminderRecord {
+	var firedAt *time.Time
+	if !r.firedAt.IsZero() {
+		firedAt = &r.firedAt
+	}
+	return reminderRecord{
+		Version: reminderRecordVersion,
+		ID:      r.id,
+		Time:    r.t,
+		Chat:    int64(r.chat),
+		User:    int64(r.user),
+		ReplyTo: r.replyTo,
+		Text:    r.text,
+		Repeat:  toReminderRepeat(r.repeat),
+		Fired:   r.fired,
+		FiredAt: firedAt}
+}
+
+func (rec reminderRecord) reminder() Reminder {
+	var firedAt time.Time
+	if rec.FiredAt != nil {
+		firedAt = *rec.FiredAt
+	}
+	return Reminder{
+		id:      rec.ID,
+		t:       rec.Time,
+		chat:    tgbotbase.ChatID(rec.Chat),
+		user:    tgbotbase.UserID(rec.User),
+		replyTo: rec.ReplyTo,
+		text:    rec.Text,
+		repeat:  rec.Repeat.recurrence(),
+		fired:   rec.Fired,
+		firedAt: firedAt}
+}
+
+func (s *RedisReminderStorage) NextID() (int64, error) {
+	return s.client.Incr(context.TODO(), s.keys.Key(reminderIDKey)).Result()
+}
+
+func (s *RedisReminderStorage) AddReminder(r Reminder) {
+	if err := s.put(context.TODO(), r); err != nil {
+		log.Printf("redisReminder: could not store reminder %d due to error: %s", r.id, err)
+	}
+}
+
+func (s *RedisReminderStorage) put(ctx context.Context, r Reminder) error {
+	data, err := json.Marshal(toReminderRecord(r))
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.keys.Key(remindersKey), strconv.FormatInt(r.id, 10), data).Err()
+}
+
+func (s *RedisReminderStorage) RemoveReminder(r Reminder) {
+	if err := s.client.HDel(context.TODO(), s.keys.Key(remindersKey), strconv.FormatInt(r.id, 10)).Err(); err != nil {
+		log.Printf("redisReminder: could not remove reminder %d due to error: %s", r.id, err)
+	}
 }
 
-func keyToReminder(key string) (*Reminder, error) {
+func (s *RedisReminderStorage) LoadAll() []Reminder {
+	values, err := s.client.HGetAll(context.TODO(), s.keys.Key(remindersKey)).Result()
+	if err != nil {
+		log.Printf("redisReminder: could not load stored reminders due to error: %s", err)
+		return nil
+	}
+	log.Printf("redisReminder: loaded %d records", len(values))
+	reminders := make([]Reminder, 0, len(values))
+	for id, data := range values {
+		var rec reminderRecord
+		if err := json.Unmarshal([]byte(data), &rec); err != nil {
+			log.Printf("redisReminder: could not parse reminder %s due to error: %s", id, err)
+			continue
+		}
+		if rec.Version > reminderRecordVersion {
+			log.Printf("redisReminder: reminder %s has unknown version %d, skipping", id, rec.Version)
+			continue
+		}
+		reminders = append(reminders, rec.reminder())
+	}
+	return reminders
+}
+
+// Legacy format: time, chat and message ID were encoded into key 'reminder:<secs>:<chat>:<msg>' with TTL,
+// value was '0' or JSON with ID, owner, text and recurrence.
+
+var legacyRemindStart time.Time = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type legacyReminderValue struct {
+	ID     int64           `json:"id"`
+	User   int64           `json:"user"`
+	Text   string          `json:"text"`
+	Repeat *reminderRepeat `json:"repeat,omitempty"`
+}
+
+func legacyKeyToReminder(key string) (*Reminder, error) {
 	splits := strings.Split(key, ":")
 	if len(splits) != 4 {
 		return nil, errors.New(fmt.Sprintf("Reminder key '%s' does not follow the expected format", key))
 	}
 
-	tdiffStr := splits[1]
-	tdiff, err := strconv.Atoi(tdiffStr)
+	tdiff, err := strconv.Atoi(splits[1])
 	if err != nil {
 		return nil, err
 	}
-	t := remindStart.Add(time.Duration(tdiff) * time.Second)
-
-	chatStr := splits[2]
-	chat, err := strconv.Atoi(chatStr)
+	chat, err := strconv.ParseInt(splits[2], 10, 64)
 	if err != nil {
 		return nil, err
 	}
-
-	replyToStr := splits[3]
-	replyTo, err := strconv.Atoi(replyToStr)
+	replyTo, err := strconv.Atoi(splits[3])
 	if err != nil {
 		return nil, err
 	}
 	return &Reminder{
-		t:       t,
+		t:       legacyRemindStart.Add(time.Duration(tdiff) * time.Second),
 		chat:    tgbotbase.ChatID(chat),
 		replyTo: replyTo}, nil
 }
 
-func (s *RedisReminderStorage) AddReminder(r Reminder) {
-	s.client.Set(context.TODO(), reminderKey(r), 0, r.t.Add(24*time.Hour).Sub(time.Now()))
-}
-
-func (s *RedisReminderStorage) RemoveReminder(r Reminder) {
-	s.client.Del(context.TODO(), reminderKey(r))
+// legacyReminder converts a reminder stored in the legacy format; ID is 0 if the value has none
+func legacyReminder(key string, data []byte) (*Reminder, error) {
+	r, err := legacyKeyToReminder(key)
+	if err != nil {
+		return nil, err
+	}
+	var value legacyReminderValue
+	if err := json.Unmarshal(data, &value); err == nil && value.ID != 0 {
+		r.id = value.ID
+		r.user = tgbotbase.UserID(value.User)
+		r.text = value.Text
+		r.repeat = value.Repeat.recurrence()
+	}
+	return r, nil
 }
 
-func (s *RedisReminderStorage) LoadAll() []Reminder {
-	keys, err := tgbotbase.GetAllKeys(context.TODO(), s.client, "reminder:*")
+// migrateLegacy moves reminders stored in the legacy key format into records
+func (s *RedisReminderStorage) migrateLegacy() {
+	ctx := context.TODO()
+	keys, err := tgbotbase.GetAllKeys(ctx, s.client, s.keys.Key("reminder:*"))
 	if err != nil {
-		log.Printf("redisReminder: could not load stored reminders due to error: %s", err)
-		return nil
+		log.Printf("redisReminder: could not look for legacy reminders due to error: %s", err)
+		return
 	}
-	log.Printf("redisReminder: loaded %d keys", len(keys))
-	reminders := make([]Reminder, 0, len(keys))
 	for _, k := range keys {
-		r, err := keyToReminder(k)
+		data, err := s.client.Get(ctx, k).Bytes()
+		if err != nil {
+			log.Printf("redisReminder: could not read legacy reminder '%s' due to error: %s", k, err)
+			continue
+		}
+		r, err := legacyReminder(s.keys.Trim(k), data)
 		if err != nil {
-			log.Printf("redisReminder: could not convert reminder key '%s' due to error: %s", k, err)
-		} else {
-			log.Printf("redisReminder: new reminder: %+v", *r)
-			reminders = append(reminders, *r)
+			log.Printf("redisReminder: could not convert legacy reminder key '%s' due to error: %s", k, err)
+			continue
 		}
+		if r.id == 0 {
+			if r.id, err = s.NextID(); err != nil {
+				log.Printf("redisReminder: could not assign ID to legacy reminder '%s' due to error: %s", k, err)
+				continue
+			}
+		}
+
+		if err := s.put(ctx, *r); err != nil {
+			log.Printf("redisReminder: could not store migrated reminder '%s' due to error: %s", k, err)
+			continue
+		}
+		s.client.Del(ctx, k)
+		log.Printf("redisReminder: legacy reminder '%s' migrated as %d", k, r.id)
 	}
-	return reminders
 }
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
+)
+
+func TestLegacyReminder(t *testing.T) {
+	monday := nltime.Recurrence{Every: 1, Unit: nltime.Week, Weekday: time.Monday, HasWeekday: true}
+	value, _ := json.Marshal(legacyReminderValue{ID: 7, User: 5, Text: "планёрка", Repeat: toReminderRepeat(&monday)})
+	at := time.Unix(1792396800, 0).UTC()
+
+	cases := []struct {
+		key, value string
+		expected   Reminder
+	}{
+		{"reminder:1792396800:-100123:42", "0", Reminder{t: at, chat: -100123, replyTo: 42}},
+		{"reminder:1792396800:1:2", string(value), Reminder{id: 7, t: at, chat: 1, replyTo: 2, user: 5, text: "планёрка", repeat: &monday}},
+		{"reminder:1792396800:1:2", `{"id": 0, "text": "ignored"}`, Reminder{t: at, chat: 1, replyTo: 2}},
+	}
+	for _, c := range cases {
+		r, err := legacyReminder(c.key, []byte(c.value))
+		if err != nil {
+			t.Errorf("'%s': unexpected error %s", c.key, err)
+			continue
+		}
+		if !sameReminder(*r, c.expected) {
+			t.Errorf("'%s' = '%s': got %+v, expected %+v", c.key, c.value, *r, c.expected)
+		}
+	}
+
+	for _, key := range []string{"reminder:1792396800:1", "reminder:abc:1:2", "reminder:1:chat:2", "reminder:1:2:msg"} {
+		if r, err := legacyReminder(key, []byte("0")); err == nil {
+			t.Errorf("'%s': expected error, got %+v", key, *r)
+		}
+	}
+}
+
+func TestReminderRecord(t *testing.T) {
+	every3h := nltime.Recurrence{Every: 3, Unit: nltime.Hour}
+	for _, r := range []Reminder{
+		{id: 1, t: testNow, chat: -1, user: 2, replyTo: 3, text: "один раз"},
+		{id: 2, t: testNow, chat: 1, user: 1, text: "пить воду", repeat: &every3h},
+		{id: 3, t: testNow, chat: 1, user: 1, fired: true, firedAt: testNow.Add(time.Minute)},
+	} {
+		data, err := json.Marshal(toReminderRecord(r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rec reminderRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Version != reminderRecordVersion || !sameReminder(rec.reminder(), r) {
+			t.Errorf("reminder %d: got %+v from %s", r.id, rec.reminder(), data)
+		}
+	}
+}
+
+func sameReminder(a, b Reminder) bool {
+	sameRepeat := (a.repeat == nil) == (b.repeat == nil) && (a.repeat == nil || *a.repeat == *b.repeat)
+	return a.id == b.id && a.t.Equal(b.t) && a.chat == b.chat && a.user == b.user && a.replyTo == b.replyTo &&
+		a.text == b.text && a.fired == b.fired && a.firedAt.Equal(b.firedAt) && sameRepeat
+}
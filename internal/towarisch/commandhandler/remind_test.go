@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+)
+
+// Monday, 19 Oct 2026 10:00 MSK
+var testNow = time.Date(2026, time.October, 19, 10, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+func testTime(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, testNow.Location())
+}
+
+func TestDetermineReminder(t *testing.T) {
+	cases := []struct {
+		msg  string
+		t    time.Time
+		text string
+	}{
+		{"завтра в 15:30 купить молоко", testTime(time.October, 20, 15, 30), "купить молоко"},
+		{"завтра позвонить маме", testTime(time.October, 20, defaultReminderHour, 0), "позвонить маме"},
+		{"через 5 минут выключить чайник", testNow.Add(5 * time.Minute), "выключить чайник"},
+		{"в 9:00 зарядка", testTime(time.October, 20, 9, 0), "зарядка"},
+		{"25.12 подарки", testTime(time.December, 25, defaultReminderHour, 0), "подарки"},
+	}
+	for _, c := range cases {
+		r, err := determineReminder(c.msg, testNow)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %s", c.msg, err)
+			continue
+		}
+		if !r.t.Equal(c.t) || r.text != c.text || r.repeat != nil {
+			t.Errorf("'%s': got %s '%s' (repeat %v), expected %s '%s'", c.msg, r.t, r.text, r.repeat, c.t, c.text)
+		}
+	}
+}
+
+func TestDetermineReminderErrors(t *testing.T) {
+	cases := map[string]error{
+		"купить молоко":          errNoReminderTime,
+		"25.12.2025 подарки":     errReminderInPast,
+		"31.02.2027 несуществую": errNoReminderTime,
+	}
+	for msg, expected := range cases {
+		if r, err := determineReminder(msg, testNow); !errors.Is(err, expected) {
+			t.Errorf("'%s': expected error %s, got %v (reminder at %s)", msg, expected, err, r.t)
+		}
+	}
+}
+
+func TestDetermineRecurringReminder(t *testing.T) {
+	cases := []struct {
+		msg  string
+		t    time.Time
+		text string
+	}{
+		{"каждый понедельник в 9:00 планёрка", testTime(time.October, 26, 9, 0), "планёрка"},
+		{"каждый день купить хлеб", testTime(time.October, 20, defaultReminderHour, 0), "купить хлеб"},
+		{"каждые три часа пить воду", testNow.Add(3 * time.Hour), "пить воду"},
+	}
+	for _, c := range cases {
+		r, err := determineReminder(c.msg, testNow)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %s", c.msg, err)
+			continue
+		}
+		if !r.t.Equal(c.t) || r.text != c.text || r.repeat == nil {
+			t.Errorf("'%s': got %s '%s' (repeat %v), expected recurring at %s '%s'", c.msg, r.t, r.text, r.repeat, c.t, c.text)
+		}
+	}
+}
+
+// memoryReminderStorage keeps reminders in a map by ID
+type memoryReminderStorage struct {
+	lastID    int64
+	reminders map[int64]Reminder
+}
+
+func newMemoryReminderStorage() *memoryReminderStorage {
+	return &memoryReminderStorage{reminders: make(map[int64]Reminder)}
+}
+
+func (s *memoryReminderStorage) NextID() (int64, error) {
+	s.lastID++
+	return s.lastID, nil
+}
+
+func (s *memoryReminderStorage) AddReminder(r Reminder) {
+	s.reminders[r.id] = r
+}
+
+func (s *memoryReminderStorage) RemoveReminder(r Reminder) {
+	delete(s.reminders, r.id)
+}
+
+func (s *memoryReminderStorage) LoadAll() []Reminder {
+	reminders := make([]Reminder, 0, len(s.reminders))
+	for _, r := range s.reminders {
+		reminders = append(reminders, r)
+	}
+	return reminders
+}
+
+// testCron records jobs instead of running them
+type testCron struct {
+	jobs map[time.Time][]tgbotbase.CronJob
+}
+
+func (c *testCron) AddJob(when time.Time, job tgbotbase.CronJob) {
+	if c.jobs == nil {
+		c.jobs = make(map[time.Time][]tgbotbase.CronJob)
+	}
+	c.jobs[when] = append(c.jobs[when], job)
+}
+
+func (c *testCron) RemoveJob(when time.Time, job tgbotbase.CronJob) {
+	left := make([]tgbotbase.CronJob, 0)
+	for _, j := range c.jobs[when] {
+		if j != job {
+			left = append(left, j)
+		}
+	}
+	c.jobs[when] = left
+}
+
+func TestFinishReschedulesRecurring(t *testing.T) {
+	storage := newMemoryReminderStorage()
+	cron := &testCron{}
+	h := &remindHandler{cron: cron, storage: storage}
+
+	daily, _ := nltime.ParseRecurrence("каждый день")
+	fired := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	r := Reminder{id: 1, t: fired, repeat: &daily, text: "купить хлеб"}
+	storage.AddReminder(r)
+	h.finish(r, true)
+
+	next := fired.AddDate(0, 0, 1)
+	stored, found := storage.reminders[1]
+	if !found || !stored.t.Equal(next) {
+		t.Fatalf("expected the reminder to be stored at %s, got %+v (found %v)", next, stored, found)
+	}
+	if len(cron.jobs[next]) != 1 {
+		t.Errorf("expected a job at %s, got %v", next, cron.jobs)
+	}
+
+	once := Reminder{id: 2, t: fired}
+	storage.AddReminder(once)
+	h.finish(once, true)
+	if _, found := storage.reminders[2]; found {
+		t.Errorf("one-time reminder should be removed")
+	}
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+)
+
+// userLocation returns location from 'timezone' property of the user in the chat; server location is used if it is not set
+func userLocation(props tgbotbase.PropertyStorage, user tgbotbase.UserID, chat tgbotbase.ChatID) *time.Location {
+	tz, err := props.GetProperty(context.TODO(), "timezone", user, chat)
+	if err != nil {
+		log.Printf("Could not get timezone property for user %d chat %d due to error: %s", user, chat, err)
+		return time.Local
+	}
+	if tz == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		log.Printf("Could not load timezone %s correctly; location loaded with error: %s", tz, err)
+		return time.Local
+	}
+	return loc
+}
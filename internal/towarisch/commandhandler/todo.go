@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// todo handles '/todo add <text> [due time]', '/todo done <N>' and '/todo list'; '/todo <text>' adds the text
+// as it did before the list existed
+func (h *remindHandler) todo(msg tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	sub := ""
+	if len(args) > 0 {
+		sub = strings.ToLower(args[0])
+		args = args[1:]
+	}
+	switch sub {
+	case "", "list":
+		h.todoList(msg)
+	case "add":
+		h.todoAdd(msg, strings.Join(args, " "))
+	case "done":
+		h.todoDone(msg, strings.Join(args, " "))
+	default:
+		h.todoAdd(msg, strings.TrimSpace(msg.CommandArguments()))
+	}
+}
+
+func (h *remindHandler) todoAdd(msg tgbotapi.Message, text string) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+	if text == "" {
+		h.reply(msg, tr.T("todo.usage"))
+		return
+	}
+
+	item := TodoItem{user: user, text: text, created: time.Now()}
+	now := time.Now().In(userLocation(h.properties, user, chat))
+	if res, found := nltime.Parse(text, now); found {
+		due := res.Time
+		if !res.HasTime {
+			due = due.Add(defaultReminderHour * time.Hour)
+		}
+		if due.After(now) && res.Strip(text) != "" {
+			item.text = res.Strip(text)
+			item.due = due
+		}
+	}
+
+	if !item.due.IsZero() {
+		id, err := h.storage.NextID()
+		if err != nil {
+			log.Printf("Could not get new reminder ID for todo item due to error: %s", err)
+			h.reply(msg, tr.T("remind.failed"))
+			return
+		}
+		item.reminder = id
+	}
+	item, err := h.todos.AddTodo(chat, item)
+	if err != nil {
+		log.Printf("Could not add todo item in chat %d due to error: %s", chat, err)
+		h.reply(msg, tr.T("todo.failed"))
+		return
+	}
+
+	if item.reminder == 0 {
+		h.reply(msg, tr.T("todo.added", item.id))
+		return
+	}
+	h.schedule(Reminder{
+		id:      item.reminder,
+		t:       item.due,
+		chat:    chat,
+		user:    user,
+		replyTo: msg.MessageID,
+		text:    tr.T("todo.due", item.id, item.text)})
+	h.reply(msg, tr.T("todo.added_due", item.id, item.due.Format(timeFormat_Out_Confirm)))
+}
+
+func (h *remindHandler) todoDone(msg tgbotapi.Message, arg string) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		h.reply(msg, tr.T("todo.usage"))
+		return
+	}
+	item, found, err := h.todos.RemoveTodo(chat, id)
+	if err != nil {
+		log.Printf("Could not remove todo item %d in chat %d due to error: %s", id, chat, err)
+		h.reply(msg, tr.T("todo.failed"))
+		return
+	}
+	if !found {
+		h.reply(msg, tr.T("todo.not_found", id))
+		return
+	}
+	if item.reminder != 0 {
+		for _, r := range h.storage.LoadAll() {
+			if r.id == item.reminder {
+				h.unschedule(r)
+				break
+			}
+		}
+	}
+	h.reply(msg, tr.T("todo.done", item.text))
+}
+
+func (h *remindHandler) todoList(msg tgbotapi.Message) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	items, err := h.todos.ListTodos(chat)
+	if err != nil {
+		log.Printf("Could not list todo items in chat %d due to error: %s", chat, err)
+		h.reply(msg, tr.T("todo.failed"))
+		return
+	}
+	if len(items) == 0 {
+		h.reply(msg, tr.T("todo.list_empty"))
+		return
+	}
+	loc := userLocation(h.properties, user, chat)
+	text := tr.T("todo.list_header")
+	for _, item := range items {
+		text = fmt.Sprintf("%s\n%d. %s", text, item.id, item.text)
+		if !item.due.IsZero() {
+			text = fmt.Sprintf("%s %s", text, tr.T("todo.until", item.due.In(loc).Format(timeFormat_Out_List)))
+		}
+	}
+	h.reply(msg, text)
+}
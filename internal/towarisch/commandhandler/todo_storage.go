@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+)
+
+// TodoItem is an entry of a chat todo list
+type TodoItem struct {
+	id       int64 // number of the item in the chat list
+	user     tgbotbase.UserID
+	text     string
+	created  time.Time
+	due      time.Time // zero if the item has no due date
+	reminder int64     // ID of the due date reminder; 0 if there is none
+}
+
+type TodoStorage interface {
+	// AddTodo stores the item assigning it a new number in the chat list
+	AddTodo(chat tgbotbase.ChatID, item TodoItem) (TodoItem, error)
+	// RemoveTodo removes the item by its number; false is returned if there is no such item
+	RemoveTodo(chat tgbotbase.ChatID, id int64) (TodoItem, bool, error)
+	ListTodos(chat tgbotbase.ChatID) ([]TodoItem, error)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+)
+
+// RedisTodoStorage keeps todo lists in the reminder database: hash todo:<chat> maps item number to its JSON
+type RedisTodoStorage struct {
+	client *redis.Client
+	keys   tgbotbase.KeyPrefix
+}
+
+func NewRedisTodoStorage(pool tgbotbase.RedisPool, keys tgbotbase.KeyPrefix) TodoStorage {
+	return &RedisTodoStorage{client: pool.GetConnByName("reminder"), keys: keys}
+}
+
+type todoValue struct {
+	User     int64     `json:"user"`
+	Text     string    `json:"text"`
+	Created  time.Time `json:"created"`
+	Due      time.Time `json:"due,omitempty"`
+	Reminder int64     `json:"reminder,omitempty"`
+}
+
+func (s *RedisTodoStorage) todoKey(chat tgbotbase.ChatID) string {
+	return s.keys.Key(fmt.Sprintf("todo:%d", chat))
+}
+
+func (s *RedisTodoStorage) todoIDKey(chat tgbotbase.ChatID) string {
+	return s.keys.Key(fmt.Sprintf("todoid:%d:last", chat))
+}
+
+func (s *RedisTodoStorage) AddTodo(chat tgbotbase.ChatID, item TodoItem) (TodoItem, error) {
+	ctx := context.TODO()
+	id, err := s.client.Incr(ctx, s.todoIDKey(chat)).Result()
+	if err != nil {
+		return item, err
+	}
+	item.id = id
+	data, err := json.Marshal(todoValue{
+		User:     int64(item.user),
+		Text:     item.text,
+		Created:  item.created,
+		Due:      item.due,
+		Reminder: item.reminder})
+	if err != nil {
+		return item, err
+	}
+	return item, s.client.HSet(ctx, s.todoKey(chat), strconv.FormatInt(id, 10), data).Err()
+}
+
+func (s *RedisTodoStorage) RemoveTodo(chat tgbotbase.ChatID, id int64) (TodoItem, bool, error) {
+	ctx := context.TODO()
+	field := strconv.FormatInt(id, 10)
+	data, err := s.client.HGet(ctx, s.todoKey(chat), field).Bytes()
+	if err == redis.Nil {
+		return TodoItem{}, false, nil
+	} else if err != nil {
+		return TodoItem{}, false, err
+	}
+	item, err := toTodoItem(id, data)
+	if err != nil {
+		return TodoItem{}, false, err
+	}
+	return item, true, s.client.HDel(ctx, s.todoKey(chat), field).Err()
+}
+
+func (s *RedisTodoStorage) ListTodos(chat tgbotbase.ChatID) ([]TodoItem, error) {
+	values, err := s.client.HGetAll(context.TODO(), s.todoKey(chat)).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TodoItem, 0, len(values))
+	for field, data := range values {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		item, err := toTodoItem(id, []byte(data))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].id < items[j].id })
+	return items, nil
+}
+
+func toTodoItem(id int64, data []byte) (TodoItem, error) {
+	var value todoValue
+	if err := json.Unmarshal(data, &value); err != nil {
+		return TodoItem{}, err
+	}
+	return TodoItem{
+		id:       id,
+		user:     tgbotbase.UserID(value.User),
+		text:     value.Text,
+		created:  value.Created,
+		due:      value.Due,
+		reminder: value.Reminder}, nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+type memoryTodoStorage struct {
+	items []TodoItem
+}
+
+func (s *memoryTodoStorage) AddTodo(chat tgbotbase.ChatID, item TodoItem) (TodoItem, error) {
+	item.id = int64(len(s.items) + 1)
+	s.items = append(s.items, item)
+	return item, nil
+}
+
+func (s *memoryTodoStorage) RemoveTodo(chat tgbotbase.ChatID, id int64) (TodoItem, bool, error) {
+	return TodoItem{}, false, nil
+}
+
+func (s *memoryTodoStorage) ListTodos(chat tgbotbase.ChatID) ([]TodoItem, error) {
+	return s.items, nil
+}
+
+func todoCommand(text string) tgbotapi.Message {
+	return tgbotapi.Message{
+		From:     &tgbotapi.User{ID: 5},
+		Chat:     &tgbotapi.Chat{ID: 7},
+		Text:     text,
+		Entities: &[]tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len("/todo")}},
+	}
+}
+
+func TestTodoWithoutSubcommandAdds(t *testing.T) {
+	todos := &memoryTodoStorage{}
+	h := NewRemindHandler(&testCron{}, newMemoryReminderStorage(), todos, emptyProperties{})
+	out := make(chan tgbotapi.Chattable, 10)
+	h.OutMsgCh = out
+
+	h.todo(todoCommand("/todo купить молоко"))
+	h.todo(todoCommand("/todo add вынести мусор"))
+	if len(todos.items) != 2 || todos.items[0].text != "купить молоко" || todos.items[1].text != "вынести мусор" {
+		t.Errorf("expected both tasks to be added, got %+v", todos.items)
+	}
+	if len(out) != 2 {
+		t.Errorf("expected a reply to every command, got %d", len(out))
+	}
+}
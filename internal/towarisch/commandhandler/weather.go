@@ -2,223 +2,249 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"errors"
 	"log"
-	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather/gazetteer"
+	"github.com/ilyalavrinov/tgbots/pkg/nltime"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 
-	"github.com/go-redis/redis/v8"
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
-var reToday *regexp.Regexp = regexp.MustCompile("сегодня")
-var reDayAfterTomorrow *regexp.Regexp = regexp.MustCompile("послезавтра")
-var reTomorrow *regexp.Regexp = regexp.MustCompile("завтра")
+var (
+	reInCity      = regexp.MustCompile(`(?i)(?:^|\s)(?:в|во|in)\s+(\p{L}[\p{L}\s'-]*)`)
+	reCityPrefix  = regexp.MustCompile(`(?i)^(?:в|во|in)\s+`)
+	reSaveWeather = regexp.MustCompile(`(?i)^(?:погода|weather)\s+(?:запомни|save)(?:\s+(.*))?$`)
+)
 
-func requestData(reqType string, cityId int64, apiKey string) ([]byte, error) {
-	weather_url := fmt.Sprintf("http://api.openweathermap.org/data/2.5/%s?id=%d&APPID=%s&lang=ru&units=metric", reqType,
-		cityId,
-		apiKey)
-	log.Printf("Sending weather request using url: %s", weather_url)
+// nearestCityKm is how far a point may be from a city to be named after it
+const nearestCityKm = 30
 
-	resp, err := http.Get(weather_url)
-	if err != nil {
-		log.Printf("Could not get data from '%s' due to error: %s", weather_url, err)
-		return []byte{}, err
+// maxCityCandidates limits the number of cities the user chooses from
+const maxCityCandidates = 5
+
+// determinePlace takes 'in <city>' part of the message, then a location the message replies to,
+// then 'location' and 'city' properties; candidates are returned if the city name is ambiguous
+func (h *weatherHandler) determinePlace(msg tgbotapi.Message, text string, lang string) (weather.Place, []gazetteer.Match, error) {
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	if matches := reInCity.FindStringSubmatch(text); matches != nil {
+		log.Printf("Message '%s' matches 'in city' regexp %s", text, reInCity)
+		candidates := lookupCities(h.cities, matches[1])
+		if gazetteer.Ambiguous(candidates) {
+			return weather.Place{}, candidates, nil
+		}
+		if len(candidates) > 0 {
+			return candidates[0].Place(lang), nil, nil
+		}
+		return weather.Place{Name: strings.Join(strings.Fields(matches[1]), " ")}, nil, nil
 	}
-	defer resp.Body.Close()
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Could not read response body from '%s' due to error: %s", weather_url, err)
-		return []byte{}, err
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.Location != nil {
+		loc := msg.ReplyToMessage.Location
+		return namedPlace(h.cities, loc.Latitude, loc.Longitude, lang), nil, nil
 	}
 
-	log.Printf("Weather response: %s", string(bodyBytes))
-
-	return bodyBytes, nil
+	place, err := placeFromProperty(h.properties, h.cities, user, chat, lang)
+	return place, nil, err
 }
 
-func (h *weatherHandler) determineCity(msg tgbotapi.Message) (int64, error) {
-	reInCity := regexp.MustCompile("(в|in) ([\\wA-Za-zА-Яа-я]+)")
-	text := msg.Text
-	if reInCity.MatchString(text) {
-		log.Printf("Message '%s' matches 'in city' regexp %s", text, reInCity)
-		matches := reInCity.FindStringSubmatch(text)
-		city := matches[2]
-		return getCityIDByName(h.redisconn, city)
+func placeFromProperty(props tgbotbase.PropertyStorage, cities *gazetteer.Index, userID tgbotbase.UserID, chatID tgbotbase.ChatID, lang string) (weather.Place, error) {
+	location, err := props.GetProperty(context.TODO(), "location", userID, chatID)
+	if err != nil {
+		log.Printf("Could not get weather location property due to error: %s", err)
+	} else if place, _, found := weather.ParseCoords(location); found {
+		return namedPlace(cities, place.Lat, place.Lon, lang), nil
 	}
 
-	return getCityIDFromProperty(h.properties, h.redisconn, tgbotbase.UserID(msg.From.ID), tgbotbase.ChatID(msg.Chat.ID))
-}
-
-func getCityIDFromProperty(props tgbotbase.PropertyStorage, conn *redis.Client, userID tgbotbase.UserID, chatID tgbotbase.ChatID) (int64, error) {
 	city, err := props.GetProperty(context.TODO(), "city", userID, chatID)
 	if err != nil {
 		log.Printf("Could not get weather city property due to error: %s", err)
-		return 0, err
+		return weather.Place{}, err
+	}
+	if city == "" {
+		return weather.Place{}, errors.New("city property is not set")
 	}
 
-	return getCityIDByName(conn, city)
+	// the most populated city is taken if the name is ambiguous
+	if candidates := lookupCities(cities, city); len(candidates) > 0 {
+		return candidates[0].Place(lang), nil
+	}
+	return weather.Place{Name: city}, nil
 }
 
-func getCityIDByName(conn *redis.Client, city string) (int64, error) {
-	city = strings.ToLower(city)
-
-	key := fmt.Sprintf("openweathermap:city:%s", city)
-	result := conn.HGet(context.TODO(), key, "id")
-	if result.Err() != nil {
-		log.Printf("Could not HGet for key '%s', error: %s", key, result.Err())
-		return 0, result.Err()
+// lookupCities looks for the longest known city formed by the first words of the name ('нижний новгород утром'
+// gives 'нижний новгород'); nothing is returned if providers should look for the whole name themselves
+func lookupCities(cities *gazetteer.Index, name string) []gazetteer.Match {
+	words := strings.Fields(name)
+	for n := len(words); n > 0; n-- {
+		if matches := cities.Search(strings.Join(words[:n], " "), maxCityCandidates); len(matches) > 0 {
+			return matches
+		}
 	}
+	return nil
+}
 
-	cityId, err := result.Int64()
-	if err != nil {
-		log.Printf("Could not convert ID for key '%s' into int, error: %s", key, err)
-		return 0, err
+// namedPlace names the point after the nearest known city
+func namedPlace(cities *gazetteer.Index, lat, lon float64, lang string) weather.Place {
+	place := weather.AtCoords(lat, lon)
+	if c, found := cities.Nearest(lat, lon, nearestCityKm); found {
+		place.Name = c.LocalName(lang)
 	}
-	log.Printf("City ID for %s is %d", city, cityId)
-
-	return cityId, nil
+	return place
 }
 
-func determineDate(text string) *time.Time {
-	now := time.Now()
-	target_day := now
-
-	if reDayAfterTomorrow.MatchString(text) { // DayAfterTomorrow should go first as simple Tomorrow is a substring
-		log.Printf("Forecast is requested for the day after tomorrow")
-		target_day = time.Date(now.Year(), now.Month(), now.Day()+2,
-			0, 0, 0, 0, time.Local)
-	} else if reTomorrow.MatchString(text) {
-		log.Printf("Forecast is requested for tomorrow")
-		target_day = time.Date(now.Year(), now.Month(), now.Day()+1,
-			0, 0, 0, 0, time.Local)
-	} else if reToday.MatchString(text) {
-		log.Printf("Forecast is requested for today")
-		target_day = time.Date(now.Year(), now.Month(), now.Day(),
-			0, 0, 0, 0, time.Local)
+// savePlace remembers coordinates, a location the message replies to or a city as the default place of the user in the chat
+func (h *weatherHandler) savePlace(msg tgbotapi.Message, text string, tr tgbotbase.Texts) string {
+	ctx := context.TODO()
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+
+	place, _, found := weather.ParseCoords(text)
+	if !found && msg.ReplyToMessage != nil && msg.ReplyToMessage.Location != nil {
+		place = namedPlace(h.cities, msg.ReplyToMessage.Location.Latitude, msg.ReplyToMessage.Location.Longitude, tr.Lang)
+		found = true
 	}
 
-	var result *time.Time = nil
-	if target_day != now { // probably 'is_regex_matched' flag is better
-		result = &target_day
+	var err error
+	if found {
+		err = h.properties.SetPropertyForUserInChat(ctx, "location", user, chat, place.Coords())
+	} else {
+		city := strings.Join(strings.Fields(reCityPrefix.ReplaceAllString(strings.TrimSpace(text), "")), " ")
+		if city == "" {
+			return tr.T("weather.save_usage")
+		}
+		place.Name = city
+		err = h.properties.SetPropertyForUserInChat(ctx, "city", user, chat, city)
+		if err == nil {
+			// the saved city must not be shadowed by a previously saved location
+			err = h.properties.SetPropertyForUserInChat(ctx, "location", user, chat, "")
+		}
+	}
+	if err != nil {
+		log.Printf("Could not save weather place %+v for user %d chat %d due to error: %s", place, user, chat, err)
+		return tr.T("weather.save_failed")
 	}
-	return result
+	return tr.T("weather.saved", place.Name)
 }
 
-type weatherData struct {
-	Cod  int
-	Main struct {
-		Temp float64
-	}
-	Name    string
-	Weather []struct {
-		Description string
+// weatherErrorText explains to the user why there is no weather
+func weatherErrorText(tr tgbotbase.Texts, err error) string {
+	switch {
+	case errors.Is(err, weather.ErrPlaceNotFound):
+		return tr.T("weather.err_place")
+	case errors.Is(err, weather.ErrUnauthorized):
+		return tr.T("weather.err_unauthorized")
+	case errors.Is(err, weather.ErrUnavailable), errors.Is(err, context.DeadlineExceeded):
+		return tr.T("weather.err_unavailable")
 	}
-	Wind struct {
-		Speed float32
+	return tr.T("weather.err_bad_response")
+}
+
+func getCurrentWeather(provider weather.Provider, place weather.Place, tr tgbotbase.Texts) (string, error) {
+	current, err := provider.Current(context.TODO(), place, tr.Lang)
+	if err != nil {
+		log.Printf("Could not get current weather for %+v from %s due to error: %s", place, provider.Name(), err)
+		return weatherErrorText(tr, err), err
 	}
+
+	return tr.T("weather.current", current.Place,
+		current.Description,
+		current.Temp,
+		current.FeelsLike,
+		current.Humidity) + formatWind(tr, current.WindSpeed, current.WindGust), nil
 }
 
-type forecastData struct {
-	City struct {
-		Name string
+// Forecast detail levels set by 'weatherDetail' property
+const (
+	weatherDetailHourly = "hourly"
+	weatherDetailDaily  = "daily"
+)
+
+func weatherDetail(props tgbotbase.PropertyStorage, user tgbotbase.UserID, chat tgbotbase.ChatID) string {
+	detail, err := props.GetProperty(context.TODO(), "weatherDetail", user, chat)
+	if err != nil {
+		log.Printf("Could not get weatherDetail property for user %d chat %d due to error: %s", user, chat, err)
 	}
-	List []struct {
-		DT_txt  string
-		Weather []struct {
-			Description string
-		}
-		Main struct {
-			Temp float32
-		}
+	if detail != weatherDetailDaily {
+		return weatherDetailHourly
 	}
+	return detail
 }
 
-func getCurrentWeather(token string, cityId int64) (string, error) {
-	bytes, err := requestData("weather", cityId, token)
-	if err != nil {
-		return "", nil
+func formatWind(tr tgbotbase.Texts, speed, gust float64) string {
+	if gust > speed {
+		return tr.T("weather.wind_gust", speed, gust)
 	}
+	return tr.T("weather.wind", speed)
+}
 
-	weather_data := weatherData{}
-	err = json.Unmarshal(bytes, &weather_data)
-	//err = json.NewDecoder(resp.Body).Decode(&weather_data)
-	if err != nil || weather_data.Cod != 200 {
-		return "Я не смог распарсить погоду :(", err
+func formatPrecip(tr tgbotbase.Texts, prob int, precip float64) string {
+	if precip >= 0.1 {
+		return tr.T("weather.precip_mm", prob, precip)
 	}
+	if prob > 0 {
+		return tr.T("weather.precip", prob)
+	}
+	return ""
+}
 
-	weather_msg := fmt.Sprintf("Сейчас в %s: %s, %.1f градусов, дует ветер %.0f м/с", weather_data.Name,
-		weather_data.Weather[0].Description,
-		weather_data.Main.Temp,
-		weather_data.Wind.Speed)
-	return weather_msg, nil
+func formatPoint(tr tgbotbase.Texts, p weather.Point, loc *time.Location) string {
+	return tr.T("weather.point", p.Time.In(loc).Format(timeFormat_Out_Time), p.Temp, p.FeelsLike, p.Description) +
+		formatPrecip(tr, p.PrecipProb, p.Precip) +
+		formatWind(tr, p.WindSpeed, p.WindGust)
 }
 
-func getForecast(token string, cityId int64, date time.Time) (string, error) {
-	log.Printf("Checking for upcoming weather in city %d", cityId)
-	bytes, err := requestData("forecast", cityId, token)
-	if err != nil {
-		return "", err
-	}
+func formatSummary(tr tgbotbase.Texts, s weather.Summary) string {
+	return tr.T("weather.summary", s.MinTemp, s.MaxTemp, s.Description) +
+		formatPrecip(tr, s.MaxPrecipProb, s.Precip) +
+		formatWind(tr, s.MaxWind, s.MaxGust)
+}
 
-	forecast_data := forecastData{}
-	err = json.Unmarshal(bytes, &forecast_data)
-	//err = json.NewDecoder(resp.Body).Decode(&weather_data)
+func getForecast(provider weather.Provider, place weather.Place, date time.Time, detail string, tr tgbotbase.Texts) (string, error) {
+	log.Printf("Checking for upcoming weather in %+v", place)
+	forecast, err := provider.Forecast(context.TODO(), place, tr.Lang)
 	if err != nil {
-		return "Я не смог распарсить прогноз :(", err
+		log.Printf("Could not get forecast for %+v from %s due to error: %s", place, provider.Name(), err)
+		return weatherErrorText(tr, err), err
 	}
 
 	now := time.Now()
 	if (date == now) && (date.Hour() > 18) {
-		return "Иди спи, нечего гулять по ночам", nil
+		return tr.T("weather.night"), nil
 	}
 
 	forecast_start := date
 	if forecast_start.Hour() < 6 {
 		forecast_start = time.Date(forecast_start.Year(), forecast_start.Month(), forecast_start.Day(),
-			5, 59, 0, 0, time.Local)
+			5, 59, 0, 0, date.Location())
 	}
 	forecast_end := time.Date(forecast_start.Year(), forecast_start.Month(), forecast_start.Day(),
-		18, 01, 00, 0, time.Local)
-
-	forecasts := make([]string, 0, 5)
-	for _, val := range forecast_data.List {
-		t, err := time.Parse(timeFormat_API, val.DT_txt)
-		if err != nil {
-			log.Printf("Error while parsing date: %s; error: %s", val.DT_txt, err)
-			continue
-		}
-		t = t.Local()
-		if t.Before(forecast_start) || t.After(forecast_end) {
-			log.Printf("Skipping date: %s", t)
-			continue
-		}
-		log.Printf("Forecast: %s,t = %.1f, %s", t, val.Main.Temp, val.Weather[0].Description)
-		forecasts = append(forecasts, fmt.Sprintf("%s: %.1f\u2103, %s", t.Format(timeFormat_Out_Time), val.Main.Temp, val.Weather[0].Description))
-	}
+		18, 01, 00, 0, date.Location())
 
-	if len(forecasts) == 0 {
+	points := forecast.Between(forecast_start, forecast_end)
+	summary, found := weather.Summarize(points)
+	if !found {
 		log.Printf("Something went wrong - no forecast")
-		return "Я не смог сделать прогноз :(", err
+		return tr.T("weather.no_forecast"), errors.New("no forecast points for the date")
 	}
 
-	forecast_msg := fmt.Sprintf("Прогнозирую на %s в %s:\n", date.Format(timeFormat_Out_Date), forecast_data.City.Name)
-	for _, forecast := range forecasts {
-		forecast_msg += forecast
-		forecast_msg += "\n"
+	forecast_msg := tr.T("weather.forecast", date.Format(timeFormat_Out_Date), forecast.Place)
+	if detail == weatherDetailHourly {
+		for _, p := range points {
+			forecast_msg += formatPoint(tr, p, date.Location())
+			forecast_msg += "\n"
+		}
 	}
+	forecast_msg += formatSummary(tr, summary)
 
 	return forecast_msg, nil
 }
 
-const timeFormat_API = "2006-01-02 15:04:05"
 const timeFormat_Out_Date = "Mon, 02 Jan"
 const timeFormat_Out_Time = "15:04"
 
@@ -226,25 +252,24 @@ var weatherWords = []string{"^погода", "^weather"}
 
 type weatherHandler struct {
 	tgbotbase.BaseHandler
-	token      string
-	redisconn  *redis.Client
+	provider   weather.Provider
+	cities     *gazetteer.Index
 	properties tgbotbase.PropertyStorage
+	localizer  *tgbotbase.Localizer
 }
 
-func NewWeatherHandler(token string, pool tgbotbase.RedisPool, properties tgbotbase.PropertyStorage) tgbotbase.IncomingMessageHandler {
+func NewWeatherHandler(provider weather.Provider, cities *gazetteer.Index, properties tgbotbase.PropertyStorage) tgbotbase.IncomingMessageHandler {
 	handler := weatherHandler{}
-	handler.token = token
-	handler.redisconn = pool.GetConnByName("openweathermap")
+	handler.provider = provider
+	handler.cities = cities
 	handler.properties = properties
-	if handler.redisconn == nil {
-		log.Panicf("Could not get connection to Redis")
-	}
+	handler.localizer = tgbotbase.NewLocalizer(texts, properties)
 	return &handler
 }
 
 func (h *weatherHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- tgbotbase.ServiceMsg) tgbotbase.HandlerTrigger {
 	h.OutMsgCh = outMsgCh
-	return tgbotbase.NewHandlerTrigger(regexp.MustCompile("^погода"), nil)
+	return tgbotbase.NewHandlerTrigger(regexp.MustCompile("^(погода|weather)"), nil).WithLocations()
 }
 
 func (h *weatherHandler) Name() string {
@@ -253,27 +278,68 @@ func (h *weatherHandler) Name() string {
 
 func (h *weatherHandler) HandleOne(msg tgbotapi.Message) {
 	text := msg.Text
+	user := tgbotbase.UserID(msg.From.ID)
+	chat := tgbotbase.ChatID(msg.Chat.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	if msg.Location != nil {
+		place := namedPlace(h.cities, msg.Location.Latitude, msg.Location.Longitude, tr.Lang)
+		replyMsg, _ := getCurrentWeather(h.provider, place, tr)
+		h.reply(msg, replyMsg)
+		return
+	}
+	if matches := reSaveWeather.FindStringSubmatch(text); matches != nil {
+		h.reply(msg, h.savePlace(msg, matches[1], tr))
+		return
+	}
 
-	date := determineDate(text)
-	cityID, err := h.determineCity(msg)
+	// coordinates are taken before time expressions since '56.32' may look like a date
+	coords, coordsSpan, hasCoords := weather.ParseCoords(text)
+	if hasCoords {
+		text = text[:coordsSpan[0]] + " " + text[coordsSpan[1]:]
+		coords = namedPlace(h.cities, coords.Lat, coords.Lon, tr.Lang)
+	}
+	var date *time.Time
+	if res, found := nltime.Parse(text, time.Now().In(userLocation(h.properties, user, chat))); found {
+		log.Printf("Forecast is requested for %s", res.Time)
+		date = &res.Time
+		text = res.Strip(text)
+	}
+	place := coords
+	var candidates []gazetteer.Match
+	var err error
+	if !hasCoords {
+		place, candidates, err = h.determinePlace(msg, text, tr.Lang)
+	}
 	if err != nil {
 		log.Printf("Could not determine city from message '%s' due to error: '%s'", text, err)
-
-		reply := tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Не смог распарсить город :("))
+		h.reply(msg, tr.T("weather.no_city"))
+		return
+	}
+	if len(candidates) > 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, tr.T("weather.choose_city"))
 		reply.BaseChat.ReplyToMessageID = msg.MessageID
+		reply.ReplyMarkup = cityButtons(candidates, date, tr.Lang)
 		h.OutMsgCh <- reply
 		return
 	}
 
-	var replyMsg string
+	h.reply(msg, h.weatherText(place, date, user, chat, tr))
+}
 
+// weatherText returns current weather if there is no date or forecast for the date
+func (h *weatherHandler) weatherText(place weather.Place, date *time.Time, user tgbotbase.UserID, chat tgbotbase.ChatID, tr tgbotbase.Texts) string {
+	var text string
 	if date == nil {
-		replyMsg, err = getCurrentWeather(h.token, cityID)
+		text, _ = getCurrentWeather(h.provider, place, tr)
 	} else {
-		replyMsg, err = getForecast(h.token, cityID, *date)
+		text, _ = getForecast(h.provider, place, *date, weatherDetail(h.properties, user, chat), tr)
 	}
+	return text
+}
 
-	reply := tgbotapi.NewMessage(msg.Chat.ID, replyMsg)
+func (h *weatherHandler) reply(msg tgbotapi.Message, text string) {
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 	reply.BaseChat.ReplyToMessageID = msg.MessageID
 	h.OutMsgCh <- reply
 }
@@ -0,0 +1,143 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+)
+
+// Cache keeps provider responses for a limited time
+type Cache interface {
+	// Get returns false if there is no fresh value for the key
+	Get(ctx context.Context, key string, value interface{}) bool
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration)
+}
+
+const (
+	currentTTL  = 10 * time.Minute
+	forecastTTL = time.Hour
+)
+
+type cachedProvider struct {
+	Provider
+	cache Cache
+}
+
+// NewCachedProvider caches responses of p keyed by provider, place, horizon and language
+func NewCachedProvider(p Provider, cache Cache) Provider {
+	return &cachedProvider{Provider: p, cache: cache}
+}
+
+func (p *cachedProvider) key(place Place, horizon, lang string) string {
+	return fmt.Sprintf("weather:%s:%s:%s:%s", p.Name(), place.Key(), horizon, lang)
+}
+
+func (p *cachedProvider) Current(ctx context.Context, place Place, lang string) (Current, error) {
+	key := p.key(place, "current", lang)
+	var c Current
+	if p.cache.Get(ctx, key, &c) {
+		return c, nil
+	}
+	c, err := p.Provider.Current(ctx, place, lang)
+	if err != nil {
+		return c, err
+	}
+	p.cache.Set(ctx, key, c, currentTTL)
+	return c, nil
+}
+
+func (p *cachedProvider) Forecast(ctx context.Context, place Place, lang string) (Forecast, error) {
+	key := p.key(place, "forecast", lang)
+	var f Forecast
+	if p.cache.Get(ctx, key, &f) {
+		return f, nil
+	}
+	f, err := p.Provider.Forecast(ctx, place, lang)
+	if err != nil {
+		return f, err
+	}
+	p.cache.Set(ctx, key, f, forecastTTL)
+	return f, nil
+}
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+type memoryCache struct {
+	mutex   sync.Mutex
+	entries map[string]memoryEntry
+}
+
+var _ Cache = &memoryCache{}
+
+// NewMemoryCache creates cache living in the process memory
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]memoryEntry)}
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, value interface{}) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, found := c.entries[key]
+	if !found {
+		return false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return false
+	}
+	return json.Unmarshal(e.data, value) == nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("weather cache: could not marshal value for '%s' due to error: %s", key, err)
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = memoryEntry{data: data, expires: time.Now().Add(ttl)}
+}
+
+type redisCache struct {
+	client *redis.Client
+}
+
+var _ Cache = &redisCache{}
+
+// NewRedisCache creates cache in Redis DB 'openweathermap'
+func NewRedisCache(pool tgbotbase.RedisPool) Cache {
+	return &redisCache{client: pool.GetConnByName("openweathermap")}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string, value interface{}) bool {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("weather cache: could not get '%s' due to error: %s", key, err)
+		}
+		return false
+	}
+	return json.Unmarshal(data, value) == nil
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("weather cache: could not marshal value for '%s' due to error: %s", key, err)
+		return
+	}
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		log.Printf("weather cache: could not set '%s' due to error: %s", key, err)
+	}
+}
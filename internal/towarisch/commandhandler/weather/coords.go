@@ -0,0 +1,36 @@
+package weather
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// reCoords matches 'lat, lon' written with decimal points, e.g. '56.3269, 44.0059' or '-33.86 151.2'
+var reCoords = regexp.MustCompile(`(-?\d{1,2}\.\d+)\s*[,;\s]\s*(-?\d{1,3}\.\d+)`)
+
+// AtCoords creates a place for the point; its name is the coordinates until a provider tells a better one
+func AtCoords(lat, lon float64) Place {
+	return Place{Name: fmt.Sprintf("%.4f, %.4f", lat, lon), Lat: lat, Lon: lon, HasCoords: true}
+}
+
+// Coords formats coordinates of the place the way ParseCoords understands
+func (p Place) Coords() string {
+	return fmt.Sprintf("%.5f,%.5f", p.Lat, p.Lon)
+}
+
+// ParseCoords looks for coordinates in the text; span is the position of the found coordinates
+func ParseCoords(text string) (place Place, span [2]int, found bool) {
+	for _, m := range reCoords.FindAllStringSubmatchIndex(text, -1) {
+		lat, err := strconv.ParseFloat(text[m[2]:m[3]], 64)
+		if err != nil || lat < -90 || lat > 90 {
+			continue
+		}
+		lon, err := strconv.ParseFloat(text[m[4]:m[5]], 64)
+		if err != nil || lon < -180 || lon > 180 {
+			continue
+		}
+		return AtCoords(lat, lon), [2]int{m[0], m[1]}, true
+	}
+	return Place{}, span, false
+}
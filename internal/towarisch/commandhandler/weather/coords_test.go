@@ -0,0 +1,40 @@
+package weather
+
+import "testing"
+
+func TestParseCoords(t *testing.T) {
+	tests := []struct {
+		text     string
+		found    bool
+		lat, lon float64
+		rest     string
+	}{
+		{"погода 56.3269, 44.0059 завтра", true, 56.3269, 44.0059, "погода  завтра"},
+		{"weather -33.86 151.2", true, -33.86, 151.2, "weather "},
+		{"56.32693,44.00592", true, 56.32693, 44.00592, ""},
+		{"погода 95.1, 44.0", false, 0, 0, ""},
+		{"погода 12.03 в Москве", false, 0, 0, ""},
+		{"погода в Москве", false, 0, 0, ""},
+	}
+	for _, test := range tests {
+		place, span, found := ParseCoords(test.text)
+		if found != test.found {
+			t.Errorf("'%s': expected found=%t, got %t", test.text, test.found, found)
+			continue
+		}
+		if !found {
+			continue
+		}
+		if !place.HasCoords || place.Lat != test.lat || place.Lon != test.lon {
+			t.Errorf("'%s': unexpected place %+v", test.text, place)
+		}
+		if rest := test.text[:span[0]] + test.text[span[1]:]; rest != test.rest {
+			t.Errorf("'%s': expected rest '%s', got '%s'", test.text, test.rest, rest)
+		}
+	}
+
+	place, _, found := ParseCoords(AtCoords(56.3269, -44.0059).Coords())
+	if !found || place.Lat != 56.3269 || place.Lon != -44.0059 {
+		t.Errorf("could not parse formatted coordinates: %+v", place)
+	}
+}
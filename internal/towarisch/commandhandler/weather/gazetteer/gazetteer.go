@@ -0,0 +1,372 @@
+// Package gazetteer finds cities by names written with typos, in Cyrillic or Latin letters
+package gazetteer
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotutil"
+)
+
+// City is a record of the index
+type City struct {
+	ID         int64 // OpenWeatherMap city ID, 0 if unknown
+	Name       string
+	Country    string // ISO 3166 code
+	State      string
+	Lat, Lon   float64
+	Population int
+	AltNames   []string
+}
+
+// LocalName returns the name in Cyrillic for Russian if the index has one
+func (c City) LocalName(lang string) string {
+	if lang == "ru" {
+		for _, n := range c.AltNames {
+			if isCyrillic(n) {
+				return n
+			}
+		}
+	}
+	return c.Name
+}
+
+// Title distinguishes the city from others having the same name
+func (c City) Title(lang string) string {
+	parts := []string{c.LocalName(lang)}
+	if c.State != "" {
+		parts = append(parts, c.State)
+	}
+	return strings.Join(append(parts, c.Country), ", ")
+}
+
+// Place converts the city into a place to get weather for
+func (c City) Place(lang string) weather.Place {
+	return weather.Place{Name: c.LocalName(lang), CityID: c.ID, Lat: c.Lat, Lon: c.Lon, HasCoords: true}
+}
+
+func isCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Index keeps cities in memory; names are compared in Latin transliteration
+type Index struct {
+	cities    []City
+	keys      [][]string
+	exact     map[string][]int
+	byID      map[int64]int
+	fuzzyKeys *tgbotutil.FuzzyIndex
+	keyCity   []int // city of every key in fuzzyKeys
+}
+
+//go:embed cities.tsv
+var bundledData []byte
+
+var bundled struct {
+	once  sync.Once
+	index *Index
+}
+
+// Bundled returns the index of major cities compiled into the binary
+func Bundled() *Index {
+	bundled.once.Do(func() {
+		index, err := Parse(bytes.NewReader(bundledData))
+		if err != nil {
+			log.Panicf("Bundled city index is broken: %s", err)
+		}
+		bundled.index = index
+	})
+	return bundled.index
+}
+
+// Load reads the index from the file made by tools/openweathermap_city_parser; empty path means the bundled index.
+// Bundled cities are added to the loaded ones since they have Cyrillic names and population;
+// a city found in both is taken from the file keeping bundled names and population.
+func Load(path string) (*Index, error) {
+	if path == "" {
+		return Bundled(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	index := newIndex()
+	for _, c := range Bundled().cities {
+		index.add(c)
+	}
+	if err := index.read(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	log.Printf("City index %s contains %d cities", path, index.Len())
+	return index, nil
+}
+
+// Parse reads tab separated lines: ID, name, country, state, latitude, longitude, population and comma separated
+// alternate names; lines starting with '#' are comments
+func Parse(r io.Reader) (*Index, error) {
+	index := newIndex()
+	if err := index.read(r); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func newIndex() *Index {
+	return &Index{exact: make(map[string][]int), byID: make(map[int64]int), fuzzyKeys: tgbotutil.NewFuzzyIndex()}
+}
+
+func (ix *Index) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		c, err := parseCity(strings.Split(text, "\t"))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		ix.add(c)
+	}
+	return scanner.Err()
+}
+
+func parseCity(fields []string) (City, error) {
+	if len(fields) < 7 {
+		return City{}, fmt.Errorf("expected at least 7 fields, got %d", len(fields))
+	}
+	var c City
+	var err error
+	if c.ID, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+		return c, err
+	}
+	c.Name, c.Country, c.State = fields[1], fields[2], fields[3]
+	if c.Lat, err = strconv.ParseFloat(fields[4], 64); err != nil {
+		return c, err
+	}
+	if c.Lon, err = strconv.ParseFloat(fields[5], 64); err != nil {
+		return c, err
+	}
+	if c.Population, err = strconv.Atoi(fields[6]); err != nil {
+		return c, err
+	}
+	if len(fields) > 7 && fields[7] != "" {
+		c.AltNames = strings.Split(fields[7], ",")
+	}
+	return c, nil
+}
+
+func nameKey(name string) string {
+	return tgbotutil.ToLatin(tgbotutil.NormalizeName(name))
+}
+
+// add puts the city into the index; a city with known ID which is already there is merged
+func (ix *Index) add(c City) {
+	if i, found := ix.byID[c.ID]; found && c.ID != 0 {
+		ix.merge(i, c)
+		return
+	}
+	i := len(ix.cities)
+	ix.cities = append(ix.cities, c)
+	ix.keys = append(ix.keys, nil)
+	if c.ID != 0 {
+		ix.byID[c.ID] = i
+	}
+	ix.indexNames(i)
+}
+
+// merge replaces the city by the newer record, keeping its names and population if the record has none
+func (ix *Index) merge(i int, c City) {
+	old := ix.cities[i]
+	if c.Population == 0 {
+		c.Population = old.Population
+	}
+	names := map[string]bool{c.Name: true}
+	for _, n := range c.AltNames {
+		names[n] = true
+	}
+	for _, n := range append([]string{old.Name}, old.AltNames...) {
+		if !names[n] {
+			names[n] = true
+			c.AltNames = append(c.AltNames, n)
+		}
+	}
+	ix.cities[i] = c
+	ix.indexNames(i)
+}
+
+// indexNames adds keys of the city names which are not indexed yet
+func (ix *Index) indexNames(i int) {
+	c := ix.cities[i]
+	seen := make(map[string]bool)
+	for _, key := range ix.keys[i] {
+		seen[key] = true
+	}
+	for _, n := range append([]string{c.Name}, c.AltNames...) {
+		key := nameKey(n)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		ix.keys[i] = append(ix.keys[i], key)
+		ix.exact[key] = append(ix.exact[key], i)
+		ix.fuzzyKeys.Add(key)
+		ix.keyCity = append(ix.keyCity, i)
+	}
+}
+
+// Len returns number of cities in the index
+func (ix *Index) Len() int {
+	return len(ix.cities)
+}
+
+// Match is a found city; Distance is the number of typos in the query
+type Match struct {
+	City
+	Distance int
+}
+
+// Search returns the closest matches of the query sorted by population; exact matches of a name or
+// of a transliteration win over fuzzy ones. 'Paris, US' limits the search to the country.
+func (ix *Index) Search(query string, limit int) []Match {
+	country := ""
+	if i := strings.LastIndex(query, ","); i >= 0 {
+		if code := strings.TrimSpace(query[i+1:]); len(code) == 2 {
+			country = strings.ToUpper(code)
+			query = query[:i]
+		}
+	}
+	key := nameKey(query)
+	if key == "" {
+		return nil
+	}
+
+	matches := make([]Match, 0)
+	for _, i := range ix.exact[key] {
+		matches = append(matches, Match{City: ix.cities[i]})
+	}
+	matches = filterCountry(matches, country)
+	if len(matches) == 0 {
+		matches = filterCountry(ix.fuzzy(key), country)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Population > matches[j].Population
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// fuzzy finds cities having the least distance to the key; a typo is allowed per 4 letters
+func (ix *Index) fuzzy(key string) []Match {
+	keyLen := len([]rune(key))
+	maxDist := keyLen / 4
+	if maxDist == 0 {
+		return nil
+	}
+	// the closest key of every city near enough
+	dists := make(map[int]int)
+	best := maxDist
+	ix.fuzzyKeys.Search(key, maxDist, func(k, dist int) {
+		i := ix.keyCity[k]
+		if d, found := dists[i]; !found || dist < d {
+			dists[i] = dist
+		}
+		if dist < best {
+			best = dist
+		}
+	})
+	closest := make([]int, 0)
+	for i, d := range dists {
+		if d == best {
+			closest = append(closest, i)
+		}
+	}
+	sort.Ints(closest)
+	matches := make([]Match, 0, len(closest))
+	for _, i := range closest {
+		matches = append(matches, Match{City: ix.cities[i], Distance: best})
+	}
+	return matches
+}
+
+func filterCountry(matches []Match, country string) []Match {
+	if country == "" {
+		return matches
+	}
+	filtered := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		if m.Country == country {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// dominance is how many times the most populated of equally good matches should exceed the next one to be chosen
+const dominance = 10
+
+// Ambiguous tells if the user should choose one of the matches returned by Search. Population of cities
+// loaded from a file is often unknown (zero), so the first match is chosen only if it is known to dominate
+func Ambiguous(matches []Match) bool {
+	if len(matches) < 2 || matches[1].Distance > matches[0].Distance {
+		return false
+	}
+	if matches[1].Population == 0 {
+		return true
+	}
+	return matches[0].Population < dominance*matches[1].Population
+}
+
+// Nearest returns the city closest to the point within maxKm
+func (ix *Index) Nearest(lat, lon, maxKm float64) (City, bool) {
+	found := -1
+	best := maxKm
+	for i, c := range ix.cities {
+		if d := distanceKm(lat, lon, c.Lat, c.Lon); d <= best {
+			best = d
+			found = i
+		}
+	}
+	if found < 0 {
+		return City{}, false
+	}
+	return ix.cities[found], true
+}
+
+const earthRadiusKm = 6371
+
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,113 @@
+package gazetteer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	ix := Bundled()
+	tests := []struct {
+		query     string
+		name      string
+		country   string
+		ambiguous bool
+	}{
+		{"Москва", "Moscow", "RU", false},
+		{"moskva", "Moscow", "RU", false},
+		{"москве", "Moscow", "RU", false},
+		{"Нижний Новгород", "Nizhniy Novgorod", "RU", false},
+		{"nizhny novgorod", "Nizhniy Novgorod", "RU", false},
+		{"Ростов-на-Дону", "Rostov-na-Donu", "RU", false},
+		{"ростов на дону", "Rostov-na-Donu", "RU", false},
+		{"Екатеринбургг", "Yekaterinburg", "RU", false},
+		{"Paris, US", "Paris", "US", false},
+		{"Springfield", "Springfield", "US", true},
+	}
+	for _, test := range tests {
+		matches := ix.Search(test.query, 5)
+		if len(matches) == 0 {
+			t.Errorf("'%s': nothing found", test.query)
+			continue
+		}
+		if matches[0].Name != test.name || matches[0].Country != test.country {
+			t.Errorf("'%s': expected %s, %s; got %+v", test.query, test.name, test.country, matches[0].City)
+		}
+		if Ambiguous(matches) != test.ambiguous {
+			t.Errorf("'%s': expected ambiguous=%t, got matches %+v", test.query, test.ambiguous, matches)
+		}
+	}
+
+	for _, query := range []string{"абырвалг", "xy", ""} {
+		if matches := ix.Search(query, 5); len(matches) != 0 {
+			t.Errorf("'%s': expected nothing, got %+v", query, matches)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := "# comment\n1\tTestville\tXX\t\t10.5\t-20.25\t100\tТествиль\n"
+	ix, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if ix.Len() != 1 {
+		t.Fatalf("expected 1 city, got %d", ix.Len())
+	}
+	c := ix.Search("тествиль", 1)[0].City
+	if c.ID != 1 || c.Lat != 10.5 || c.Lon != -20.25 || c.LocalName("ru") != "Тествиль" || c.Title("en") != "Testville, XX" {
+		t.Errorf("unexpected city %+v", c)
+	}
+
+	if _, err := Parse(strings.NewReader("1\tBroken\n")); err == nil {
+		t.Error("expected error for broken line")
+	}
+}
+
+func TestNearest(t *testing.T) {
+	c, found := Bundled().Nearest(56.32, 44.01, 30)
+	if !found || c.Name != "Nizhniy Novgorod" {
+		t.Errorf("unexpected nearest city %+v", c)
+	}
+	if _, found := Bundled().Nearest(0, -150, 30); found {
+		t.Error("expected no city in the ocean")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cities.tsv")
+	data := "524901\tMoscow\tRU\t\t55.7522\t37.6156\t0\t\n5601538\tMoscow\tUS\tIdaho\t46.7324\t-117.0002\t0\t\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Moscow of Russia is bundled as well, so only the one of Idaho is new
+	if ix.Len() != Bundled().Len()+1 {
+		t.Errorf("expected bundled and loaded cities, got %d", ix.Len())
+	}
+	matches := ix.Search("Москва", 5)
+	if len(matches) == 0 || matches[0].Country != "RU" || Ambiguous(matches) {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+	if m := matches[0]; m.Lat != 55.7522 || m.Population == 0 {
+		t.Errorf("expected the loaded record with bundled population, got %+v", m)
+	}
+}
+
+func TestAmbiguousUnknownPopulation(t *testing.T) {
+	data := "1\tTestville\tXX\tNorth\t10\t10\t0\t\n2\tTestville\tXX\tSouth\t-10\t10\t0\t\n3\tBigtown\tXX\t\t0\t0\t100000\t\n4\tBigtown\tYY\t\t0\t20\t0\t\n"
+	ix, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, query := range []string{"Testville", "Bigtown"} {
+		if matches := ix.Search(query, 5); len(matches) != 2 || !Ambiguous(matches) {
+			t.Errorf("'%s': expected ambiguous matches, got %+v", query, matches)
+		}
+	}
+}
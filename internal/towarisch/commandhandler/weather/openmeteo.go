@@ -0,0 +1,236 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// OpenMeteo uses keyless Open-Meteo API; places without coordinates are resolved with its geocoding API
+type OpenMeteo struct {
+	baseURL      string
+	geocodingURL string
+}
+
+func NewOpenMeteo() *OpenMeteo {
+	return &OpenMeteo{
+		baseURL:      "https://api.open-meteo.com/v1",
+		geocodingURL: "https://geocoding-api.open-meteo.com/v1"}
+}
+
+func (p *OpenMeteo) Name() string {
+	return "openmeteo"
+}
+
+type omGeocoding struct {
+	Results []struct {
+		Name      string
+		Latitude  float64
+		Longitude float64
+	}
+}
+
+// locate fills coordinates of the place if they are not known yet
+func (p *OpenMeteo) locate(ctx context.Context, place Place, lang string) (Place, error) {
+	if place.HasCoords {
+		return place, nil
+	}
+	if place.Name == "" {
+		return place, ErrPlaceNotFound
+	}
+	v := url.Values{}
+	v.Set("name", place.Name)
+	v.Set("count", "1")
+	v.Set("language", lang)
+	body, err := get(ctx, fmt.Sprintf("%s/search?%s", p.geocodingURL, v.Encode()))
+	if err != nil {
+		return place, err
+	}
+	var data omGeocoding
+	if err := json.Unmarshal(body, &data); err != nil {
+		return place, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	if len(data.Results) == 0 {
+		return place, ErrPlaceNotFound
+	}
+	place.Name = data.Results[0].Name
+	place.Lat = data.Results[0].Latitude
+	place.Lon = data.Results[0].Longitude
+	place.HasCoords = true
+	return place, nil
+}
+
+func (p *OpenMeteo) request(ctx context.Context, place Place, params url.Values) ([]byte, error) {
+	params.Set("latitude", fmt.Sprintf("%f", place.Lat))
+	params.Set("longitude", fmt.Sprintf("%f", place.Lon))
+	params.Set("timeformat", "unixtime")
+	params.Set("wind_speed_unit", "ms")
+	return get(ctx, fmt.Sprintf("%s/forecast?%s", p.baseURL, params.Encode()))
+}
+
+type omCurrent struct {
+	Current *struct {
+		Time        int64   `json:"time"`
+		Temperature float64 `json:"temperature_2m"`
+		FeelsLike   float64 `json:"apparent_temperature"`
+		Humidity    int     `json:"relative_humidity_2m"`
+		WeatherCode int     `json:"weather_code"`
+		WindSpeed   float64 `json:"wind_speed_10m"`
+		WindGust    float64 `json:"wind_gusts_10m"`
+	} `json:"current"`
+}
+
+type omHourly struct {
+	Hourly struct {
+		Time        []int64   `json:"time"`
+		Temperature []float64 `json:"temperature_2m"`
+		FeelsLike   []float64 `json:"apparent_temperature"`
+		Humidity    []int     `json:"relative_humidity_2m"`
+		PrecipProb  []int     `json:"precipitation_probability"`
+		Precip      []float64 `json:"precipitation"`
+		WeatherCode []int     `json:"weather_code"`
+		WindSpeed   []float64 `json:"wind_speed_10m"`
+		WindGust    []float64 `json:"wind_gusts_10m"`
+	} `json:"hourly"`
+}
+
+// consistent checks that every requested series has a value for every hour
+func (h omHourly) consistent() bool {
+	n := len(h.Hourly.Time)
+	for _, l := range []int{len(h.Hourly.Temperature), len(h.Hourly.FeelsLike), len(h.Hourly.Humidity),
+		len(h.Hourly.PrecipProb), len(h.Hourly.Precip), len(h.Hourly.WeatherCode),
+		len(h.Hourly.WindSpeed), len(h.Hourly.WindGust)} {
+		if l != n {
+			return false
+		}
+	}
+	return n > 0
+}
+
+func (p *OpenMeteo) Current(ctx context.Context, place Place, lang string) (Current, error) {
+	place, err := p.locate(ctx, place, lang)
+	if err != nil {
+		return Current{}, err
+	}
+	params := url.Values{}
+	params.Set("current", "temperature_2m,apparent_temperature,relative_humidity_2m,weather_code,wind_speed_10m,wind_gusts_10m")
+	body, err := p.request(ctx, place, params)
+	if err != nil {
+		return Current{}, err
+	}
+	var data omCurrent
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Current{}, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	if data.Current == nil {
+		return Current{}, fmt.Errorf("%w: no current weather", ErrBadResponse)
+	}
+	return Current{
+		Place:       place.Name,
+		Time:        time.Unix(data.Current.Time, 0),
+		Description: describeWMO(data.Current.WeatherCode, lang),
+		Temp:        data.Current.Temperature,
+		FeelsLike:   data.Current.FeelsLike,
+		Humidity:    data.Current.Humidity,
+		WindSpeed:   data.Current.WindSpeed,
+		WindGust:    data.Current.WindGust}, nil
+}
+
+// omForecastStep keeps forecast as dense as OpenWeatherMap one
+const omForecastStep = 3
+
+func (p *OpenMeteo) Forecast(ctx context.Context, place Place, lang string) (Forecast, error) {
+	place, err := p.locate(ctx, place, lang)
+	if err != nil {
+		return Forecast{}, err
+	}
+	params := url.Values{}
+	params.Set("hourly", "temperature_2m,apparent_temperature,relative_humidity_2m,precipitation_probability,"+
+		"precipitation,weather_code,wind_speed_10m,wind_gusts_10m")
+	params.Set("forecast_days", "6")
+	body, err := p.request(ctx, place, params)
+	if err != nil {
+		return Forecast{}, err
+	}
+	var data omHourly
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Forecast{}, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	if !data.consistent() {
+		return Forecast{}, fmt.Errorf("%w: inconsistent hourly forecast", ErrBadResponse)
+	}
+	h := data.Hourly
+	f := Forecast{Place: place.Name}
+	for i, ts := range h.Time {
+		t := time.Unix(ts, 0)
+		if t.UTC().Hour()%omForecastStep != 0 {
+			continue
+		}
+		// a point covers the following hours up to the next point like OpenWeatherMap 3-hour periods do
+		point := Point{
+			Time:        t,
+			Description: describeWMO(h.WeatherCode[i], lang),
+			Temp:        h.Temperature[i],
+			FeelsLike:   h.FeelsLike[i],
+			Humidity:    h.Humidity[i]}
+		for j := i; j < i+omForecastStep && j < len(h.Time); j++ {
+			point.Precip += h.Precip[j]
+			if h.PrecipProb[j] > point.PrecipProb {
+				point.PrecipProb = h.PrecipProb[j]
+			}
+			if h.WindSpeed[j] > point.WindSpeed {
+				point.WindSpeed = h.WindSpeed[j]
+			}
+			if h.WindGust[j] > point.WindGust {
+				point.WindGust = h.WindGust[j]
+			}
+		}
+		f.Points = append(f.Points, point)
+	}
+	return f, nil
+}
+
+// wmoDescriptions maps WMO weather interpretation codes into human-readable texts
+var wmoDescriptions = map[int][2]string{
+	0:  {"ясно", "clear sky"},
+	1:  {"преимущественно ясно", "mainly clear"},
+	2:  {"переменная облачность", "partly cloudy"},
+	3:  {"пасмурно", "overcast"},
+	45: {"туман", "fog"},
+	48: {"изморозь", "depositing rime fog"},
+	51: {"слабая морось", "light drizzle"},
+	53: {"морось", "drizzle"},
+	55: {"сильная морось", "dense drizzle"},
+	56: {"ледяная морось", "freezing drizzle"},
+	57: {"сильная ледяная морось", "dense freezing drizzle"},
+	61: {"небольшой дождь", "light rain"},
+	63: {"дождь", "rain"},
+	65: {"сильный дождь", "heavy rain"},
+	66: {"ледяной дождь", "freezing rain"},
+	67: {"сильный ледяной дождь", "heavy freezing rain"},
+	71: {"небольшой снег", "light snow"},
+	73: {"снег", "snow"},
+	75: {"сильный снег", "heavy snow"},
+	77: {"снежные зёрна", "snow grains"},
+	80: {"небольшой ливень", "light showers"},
+	81: {"ливень", "showers"},
+	82: {"сильный ливень", "violent showers"},
+	85: {"снегопад", "snow showers"},
+	86: {"сильный снегопад", "heavy snow showers"},
+	95: {"гроза", "thunderstorm"},
+	96: {"гроза с градом", "thunderstorm with hail"},
+	99: {"гроза с сильным градом", "thunderstorm with heavy hail"},
+}
+
+func describeWMO(code int, lang string) string {
+	d, found := wmoDescriptions[code]
+	if !found {
+		return fmt.Sprintf("WMO %d", code)
+	}
+	if lang == "ru" {
+		return d[0]
+	}
+	return d[1]
+}
@@ -0,0 +1,141 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+)
+
+// OpenWeatherMap uses 2.5 API: current weather and 5 days forecast with 3 hours step
+type OpenWeatherMap struct {
+	token   string
+	baseURL string
+}
+
+func NewOpenWeatherMap(token string) *OpenWeatherMap {
+	return &OpenWeatherMap{token: token, baseURL: "https://api.openweathermap.org/data/2.5"}
+}
+
+func (p *OpenWeatherMap) Name() string {
+	return "openweathermap"
+}
+
+func (p *OpenWeatherMap) url(reqType string, place Place, lang string) string {
+	v := url.Values{}
+	switch {
+	case place.CityID != 0:
+		v.Set("id", fmt.Sprint(place.CityID))
+	case place.HasCoords:
+		v.Set("lat", fmt.Sprintf("%f", place.Lat))
+		v.Set("lon", fmt.Sprintf("%f", place.Lon))
+	default:
+		v.Set("q", place.Name)
+	}
+	v.Set("appid", p.token)
+	v.Set("lang", lang)
+	v.Set("units", "metric")
+	return fmt.Sprintf("%s/%s?%s", p.baseURL, reqType, v.Encode())
+}
+
+type owmDescription []struct {
+	Description string
+}
+
+func (d owmDescription) text() string {
+	if len(d) == 0 {
+		return ""
+	}
+	return d[0].Description
+}
+
+type owmMain struct {
+	Temp      float64
+	FeelsLike float64 `json:"feels_like"`
+	Humidity  int
+}
+
+type owmWind struct {
+	Speed float64
+	Gust  float64
+}
+
+type owmCurrent struct {
+	Dt      int64
+	Name    string
+	Weather owmDescription
+	Main    *owmMain
+	Wind    owmWind
+}
+
+type owmPrecip struct {
+	ThreeHours float64 `json:"3h"`
+}
+
+type owmForecast struct {
+	City struct {
+		Name string
+	}
+	List []struct {
+		Dt      int64
+		Weather owmDescription
+		Main    owmMain
+		Wind    owmWind
+		Pop     float64
+		Rain    owmPrecip
+		Snow    owmPrecip
+	}
+}
+
+func (p *OpenWeatherMap) Current(ctx context.Context, place Place, lang string) (Current, error) {
+	body, err := get(ctx, p.url("weather", place, lang))
+	if err != nil {
+		return Current{}, err
+	}
+	var data owmCurrent
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Current{}, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	if data.Main == nil {
+		return Current{}, fmt.Errorf("%w: no temperature", ErrBadResponse)
+	}
+	return Current{
+		Place:       data.Name,
+		Time:        time.Unix(data.Dt, 0),
+		Description: data.Weather.text(),
+		Temp:        data.Main.Temp,
+		FeelsLike:   data.Main.FeelsLike,
+		Humidity:    data.Main.Humidity,
+		WindSpeed:   data.Wind.Speed,
+		WindGust:    data.Wind.Gust}, nil
+}
+
+func (p *OpenWeatherMap) Forecast(ctx context.Context, place Place, lang string) (Forecast, error) {
+	body, err := get(ctx, p.url("forecast", place, lang))
+	if err != nil {
+		return Forecast{}, err
+	}
+	var data owmForecast
+	if err := json.Unmarshal(body, &data); err != nil {
+		return Forecast{}, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	if len(data.List) == 0 {
+		return Forecast{}, fmt.Errorf("%w: empty forecast", ErrBadResponse)
+	}
+	f := Forecast{Place: data.City.Name, Points: make([]Point, 0, len(data.List))}
+	for _, val := range data.List {
+		f.Points = append(f.Points, Point{
+			Time:        time.Unix(val.Dt, 0),
+			Description: val.Weather.text(),
+			Temp:        val.Main.Temp,
+			FeelsLike:   val.Main.FeelsLike,
+			Humidity:    val.Main.Humidity,
+			PrecipProb:  int(math.Round(val.Pop * 100)),
+			Precip:      val.Rain.ThreeHours + val.Snow.ThreeHours,
+			WindSpeed:   val.Wind.Speed,
+			WindGust:    val.Wind.Gust})
+	}
+	return f, nil
+}
@@ -0,0 +1,76 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testOpenWeatherMap(t *testing.T, status int, body string) *OpenWeatherMap {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	p := NewOpenWeatherMap("token")
+	p.baseURL = srv.URL
+	return p
+}
+
+func TestOpenWeatherMapCurrent(t *testing.T) {
+	p := testOpenWeatherMap(t, http.StatusOK, `{"dt":1760000000,"name":"Moscow","weather":[],"main":{"temp":3.5},"wind":{"speed":4}}`)
+	c, err := p.Current(context.Background(), Place{CityID: 524901}, "ru")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if c.Place != "Moscow" || c.Temp != 3.5 || c.WindSpeed != 4 || c.Description != "" {
+		t.Errorf("unexpected current weather: %+v", c)
+	}
+}
+
+func TestOpenWeatherMapErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusUnauthorized, `{"cod":401}`, ErrUnauthorized},
+		{http.StatusNotFound, `{"cod":"404"}`, ErrPlaceNotFound},
+		{http.StatusServiceUnavailable, ``, ErrUnavailable},
+		{http.StatusOK, `{"cod":200}`, ErrBadResponse},
+		{http.StatusOK, `not json`, ErrBadResponse},
+	}
+	for _, test := range tests {
+		p := testOpenWeatherMap(t, test.status, test.body)
+		if _, err := p.Current(context.Background(), Place{Name: "Moscow"}, "en"); !errors.Is(err, test.want) {
+			t.Errorf("status %d body '%s': expected %v, got %v", test.status, test.body, test.want, err)
+		}
+	}
+}
+
+func TestCachedProvider(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"dt":1760000000,"name":"Moscow","weather":[{"description":"snow"}],"main":{"temp":-1}}`))
+	}))
+	defer srv.Close()
+	owm := NewOpenWeatherMap("token")
+	owm.baseURL = srv.URL
+	p := NewCachedProvider(owm, NewMemoryCache())
+
+	for i := 0; i < 2; i++ {
+		c, err := p.Current(context.Background(), Place{CityID: 1}, "en")
+		if err != nil || c.Description != "snow" {
+			t.Fatalf("unexpected result %+v, error %v", c, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 request, got %d", calls)
+	}
+	if _, err := p.Current(context.Background(), Place{CityID: 1}, "ru"); err != nil || calls != 2 {
+		t.Errorf("expected separate request for another language, got %d requests, error %v", calls, err)
+	}
+}
@@ -0,0 +1,61 @@
+package weather
+
+import "time"
+
+// Summary aggregates forecast points of a period
+type Summary struct {
+	From, To      time.Time
+	Description   string // the most frequent one
+	MinTemp       float64
+	MaxTemp       float64
+	MaxPrecipProb int
+	Precip        float64
+	MaxWind       float64
+	MaxGust       float64
+}
+
+// Between returns forecast points in [from, to)
+func (f Forecast) Between(from, to time.Time) []Point {
+	points := make([]Point, 0)
+	for _, p := range f.Points {
+		if !p.Time.Before(from) && p.Time.Before(to) {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
+// Summarize aggregates points; ok is false if there are no points
+func Summarize(points []Point) (s Summary, ok bool) {
+	if len(points) == 0 {
+		return s, false
+	}
+	s.From = points[0].Time
+	s.To = points[len(points)-1].Time
+	s.MinTemp = points[0].Temp
+	s.MaxTemp = points[0].Temp
+	descriptions := make(map[string]int)
+	for _, p := range points {
+		if p.Temp < s.MinTemp {
+			s.MinTemp = p.Temp
+		}
+		if p.Temp > s.MaxTemp {
+			s.MaxTemp = p.Temp
+		}
+		if p.PrecipProb > s.MaxPrecipProb {
+			s.MaxPrecipProb = p.PrecipProb
+		}
+		if p.WindSpeed > s.MaxWind {
+			s.MaxWind = p.WindSpeed
+		}
+		if p.WindGust > s.MaxGust {
+			s.MaxGust = p.WindGust
+		}
+		s.Precip += p.Precip
+		descriptions[p.Description]++
+		if descriptions[p.Description] > descriptions[s.Description] {
+			s.Description = p.Description
+		}
+	}
+	return s, true
+}
@@ -0,0 +1,29 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC)
+	f := Forecast{Points: []Point{
+		{Time: start.Add(-3 * time.Hour), Temp: -30, Description: "snow"},
+		{Time: start, Temp: -2, Description: "clear", WindSpeed: 3},
+		{Time: start.Add(3 * time.Hour), Temp: 1, Description: "rain", Precip: 2.5, PrecipProb: 80, WindGust: 12},
+		{Time: start.Add(6 * time.Hour), Temp: 4, Description: "rain", Precip: 1, PrecipProb: 60},
+		{Time: start.Add(24 * time.Hour), Temp: 20, Description: "clear"},
+	}}
+	s, ok := Summarize(f.Between(start, start.Add(24*time.Hour)))
+	if !ok {
+		t.Fatal("expected a summary")
+	}
+	if s.MinTemp != -2 || s.MaxTemp != 4 || s.Precip != 3.5 || s.MaxPrecipProb != 80 ||
+		s.MaxWind != 3 || s.MaxGust != 12 || s.Description != "rain" {
+		t.Errorf("unexpected summary %+v", s)
+	}
+
+	if _, ok := Summarize(f.Between(start.Add(48*time.Hour), start.Add(72*time.Hour))); ok {
+		t.Error("expected no summary without points")
+	}
+}
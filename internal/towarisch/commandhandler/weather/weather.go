@@ -0,0 +1,134 @@
+// Package weather provides current weather and forecasts from several providers
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrPlaceNotFound is returned if the provider does not know the place
+	ErrPlaceNotFound = errors.New("place not found")
+	// ErrUnavailable is returned if the provider could not be reached or has failed
+	ErrUnavailable = errors.New("weather provider unavailable")
+	// ErrUnauthorized is returned if the provider rejects the API key
+	ErrUnauthorized = errors.New("weather provider rejected the key")
+	// ErrBadResponse is returned if the provider response could not be understood
+	ErrBadResponse = errors.New("unexpected weather provider response")
+)
+
+// Place is a point to get weather for; providers use city ID, coordinates or name, whatever is set and supported
+type Place struct {
+	Name      string
+	CityID    int64 // OpenWeatherMap city ID
+	Lat, Lon  float64
+	HasCoords bool
+}
+
+// Key identifies the place in caches
+func (p Place) Key() string {
+	switch {
+	case p.CityID != 0:
+		return fmt.Sprintf("id%d", p.CityID)
+	case p.HasCoords:
+		return fmt.Sprintf("%.3f,%.3f", p.Lat, p.Lon)
+	default:
+		return p.Name
+	}
+}
+
+// Current is the weather at the moment
+type Current struct {
+	Place       string
+	Time        time.Time
+	Description string
+	Temp        float64 // °C
+	FeelsLike   float64 // °C
+	Humidity    int     // %
+	WindSpeed   float64 // m/s
+	WindGust    float64 // m/s, 0 if unknown
+}
+
+// Point is a forecast for a period starting at Time and lasting until the next point
+type Point struct {
+	Time        time.Time
+	Description string
+	Temp        float64 // °C
+	FeelsLike   float64 // °C
+	Humidity    int     // %
+	PrecipProb  int     // %
+	Precip      float64 // mm of rain and snow during the period
+	WindSpeed   float64 // m/s
+	WindGust    float64 // m/s, 0 if unknown
+}
+
+// Forecast is a list of forecast points sorted by time
+type Forecast struct {
+	Place  string
+	Points []Point
+}
+
+type Provider interface {
+	Name() string
+	Current(ctx context.Context, place Place, lang string) (Current, error)
+	Forecast(ctx context.Context, place Place, lang string) (Forecast, error)
+}
+
+// New creates provider by its name: "openweathermap" requires a token, "openmeteo" is keyless;
+// empty name selects OpenWeatherMap if there is a token and Open-Meteo otherwise
+func New(name, token string) (Provider, error) {
+	if name == "" {
+		name = "openmeteo"
+		if token != "" {
+			name = "openweathermap"
+		}
+	}
+	switch name {
+	case "openweathermap":
+		if token == "" {
+			return nil, errors.New("openweathermap provider requires a token")
+		}
+		return NewOpenWeatherMap(token), nil
+	case "openmeteo":
+		return NewOpenMeteo(), nil
+	}
+	return nil, fmt.Errorf("unknown weather provider '%s'", name)
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// get requests the url and classifies failures into the package errors
+func get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Printf("Could not get weather data due to error: %s", err)
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return body, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrUnauthorized
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrPlaceNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+	log.Printf("Weather provider responded with status %d: %s", resp.StatusCode, body)
+	return nil, fmt.Errorf("%w: status %d", ErrBadResponse, resp.StatusCode)
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather/gazetteer"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// Default thresholds of weather alerts; chats can override them with properties
+const (
+	defaultAlertFrost = -15.0 // °C, 'weatherAlertFrost'
+	defaultAlertRain  = 15.0  // mm per day, 'weatherAlertRain'
+	defaultAlertWind  = 15.0  // m/s of gusts, 'weatherAlertWind'
+)
+
+// weatherAlertsHandler checks tomorrow's forecast for chats having 'weatherAlerts' property
+// (time of the check from midnight, e.g. 20h) and warns them about frost, heavy precipitation and strong wind
+type weatherAlertsHandler struct {
+	tgbotbase.BaseHandler
+	props    tgbotbase.PropertyStorage
+	cities   *gazetteer.Index
+	cron     tgbotbase.Cron
+	provider weather.Provider
+
+	localizer *tgbotbase.Localizer
+}
+
+var _ tgbotbase.BackgroundMessageHandler = &weatherAlertsHandler{}
+
+func NewWeatherAlertsHandler(cron tgbotbase.Cron,
+	props tgbotbase.PropertyStorage,
+	cities *gazetteer.Index,
+	provider weather.Provider) tgbotbase.BackgroundMessageHandler {
+	h := &weatherAlertsHandler{
+		props:    props,
+		cities:   cities,
+		cron:     cron,
+		provider: provider,
+
+		localizer: tgbotbase.NewLocalizer(texts, props)}
+	return h
+}
+
+func (h *weatherAlertsHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- tgbotbase.ServiceMsg) {
+	h.OutMsgCh = outMsgCh
+}
+
+func (h *weatherAlertsHandler) Run() {
+	now := time.Now()
+	props, _ := h.props.GetEveryHavingProperty(context.TODO(), "weatherAlerts")
+	for _, prop := range props {
+		if (prop.User != 0) && (tgbotbase.ChatID(prop.User) != prop.Chat) {
+			log.Printf("Weather alerts: Skipping special setting for user %d in chat %d", prop.User, prop.Chat)
+			continue
+		}
+		dur, err := time.ParseDuration(prop.Value)
+		if err != nil {
+			log.Printf("Could not parse duration %s for chat %d due to error: %s", prop.Value, prop.Chat, err)
+			continue
+		}
+
+		place, err := placeFromProperty(h.props, h.cities, prop.User, prop.Chat, h.localizer.Lang(context.TODO(), prop.User, prop.Chat))
+		if err != nil {
+			log.Printf("Could not get city from property for user '%d' chat '%d' due to error: %s", prop.User, prop.Chat, err)
+			continue
+		}
+
+		when := tgbotbase.CalcNextTimeFromMidnight(now, dur)
+		job := weatherAlertJob{
+			place:     place,
+			chatID:    prop.Chat,
+			provider:  h.provider,
+			props:     h.props,
+			localizer: h.localizer}
+		job.OutMsgCh = h.OutMsgCh
+		h.cron.AddJob(when, &job)
+	}
+}
+
+func (h *weatherAlertsHandler) Name() string {
+	return "weather alerts"
+}
+
+type weatherAlertJob struct {
+	tgbotbase.BaseHandler
+	place     weather.Place
+	chatID    tgbotbase.ChatID
+	provider  weather.Provider
+	props     tgbotbase.PropertyStorage
+	localizer *tgbotbase.Localizer
+}
+
+var _ tgbotbase.CronJob = &weatherAlertJob{}
+
+func (job *weatherAlertJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
+	defer cron.AddJob(scheduledWhen.Add(24*time.Hour), job)
+
+	tr := job.localizer.For(context.TODO(), 0, job.chatID)
+	forecast, err := job.provider.Forecast(context.TODO(), job.place, tr.Lang)
+	if err != nil {
+		log.Printf("Weather alerts: could not get forecast for %+v due to error: %s", job.place, err)
+		return
+	}
+	loc := userLocation(job.props, 0, job.chatID)
+	now := time.Now().In(loc)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	summary, found := weather.Summarize(forecast.Between(tomorrow, tomorrow.AddDate(0, 0, 1)))
+	if !found {
+		log.Printf("Weather alerts: no forecast for %s in %+v", tomorrow, job.place)
+		return
+	}
+
+	msg := ""
+	if summary.MinTemp <= job.threshold("weatherAlertFrost", defaultAlertFrost) {
+		msg += tr.T("weather.alert_frost", summary.MinTemp)
+	}
+	if summary.Precip >= job.threshold("weatherAlertRain", defaultAlertRain) {
+		msg += tr.T("weather.alert_rain", summary.Precip)
+	}
+	if gust := max(summary.MaxGust, summary.MaxWind); gust >= job.threshold("weatherAlertWind", defaultAlertWind) {
+		msg += tr.T("weather.alert_wind", gust)
+	}
+	if msg == "" {
+		return
+	}
+	job.OutMsgCh <- tgbotapi.NewMessage(int64(job.chatID), tr.T("weather.alert_header", forecast.Place)+msg)
+}
+
+func (job *weatherAlertJob) threshold(name string, def float64) float64 {
+	value, err := job.props.GetProperty(context.TODO(), name, 0, job.chatID)
+	if err != nil || value == "" {
+		return def
+	}
+	t, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Weather alerts: could not parse %s '%s' for chat %d due to error: %s", name, value, job.chatID, err)
+		return def
+	}
+	return t
+}
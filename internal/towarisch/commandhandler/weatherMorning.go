@@ -5,30 +5,35 @@ import (
 	"log"
 	"time"
 
-	"github.com/go-redis/redis/v8"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather/gazetteer"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
 type weatherMorningHandler struct {
 	tgbotbase.BaseHandler
-	props tgbotbase.PropertyStorage
-	conn  *redis.Client
-	cron  tgbotbase.Cron
-	token string
+	props    tgbotbase.PropertyStorage
+	cities   *gazetteer.Index
+	cron     tgbotbase.Cron
+	provider weather.Provider
+
+	localizer *tgbotbase.Localizer
 }
 
 var _ tgbotbase.BackgroundMessageHandler = &weatherMorningHandler{}
 
 func NewWeatherMorningHandler(cron tgbotbase.Cron,
 	props tgbotbase.PropertyStorage,
-	pool tgbotbase.RedisPool,
-	token string) tgbotbase.BackgroundMessageHandler {
+	cities *gazetteer.Index,
+	provider weather.Provider) tgbotbase.BackgroundMessageHandler {
 	h := &weatherMorningHandler{
-		props: props,
-		conn:  pool.GetConnByName("openweathermap"),
-		cron:  cron,
-		token: token}
+		props:    props,
+		cities:   cities,
+		cron:     cron,
+		provider: provider,
+
+		localizer: tgbotbase.NewLocalizer(texts, props)}
 	return h
 }
 
@@ -51,17 +56,19 @@ func (h *weatherMorningHandler) Run() {
 			continue
 		}
 
-		cityID, err := getCityIDFromProperty(h.props, h.conn, prop.User, prop.Chat)
+		place, err := placeFromProperty(h.props, h.cities, prop.User, prop.Chat, h.localizer.Lang(context.TODO(), prop.User, prop.Chat))
 		if err != nil {
-			log.Printf("Could not get city ID from property for user '%d' city '%d' due to error: %s", prop.User, prop.Chat, err)
+			log.Printf("Could not get city from property for user '%d' chat '%d' due to error: %s", prop.User, prop.Chat, err)
 			continue
 		}
 
 		when := tgbotbase.CalcNextTimeFromMidnight(now, dur)
 		job := weatherJob{
-			cityID: cityID,
-			chatID: prop.Chat,
-			token:  h.token}
+			place:     place,
+			chatID:    prop.Chat,
+			provider:  h.provider,
+			detail:    weatherDetail(h.props, prop.User, prop.Chat),
+			localizer: h.localizer}
 		job.OutMsgCh = h.OutMsgCh
 		h.cron.AddJob(when, &job)
 	}
@@ -73,9 +80,11 @@ func (h *weatherMorningHandler) Name() string {
 
 type weatherJob struct {
 	tgbotbase.BaseHandler
-	cityID int64
-	chatID tgbotbase.ChatID
-	token  string
+	place     weather.Place
+	chatID    tgbotbase.ChatID
+	provider  weather.Provider
+	detail    string
+	localizer *tgbotbase.Localizer
 }
 
 var _ tgbotbase.CronJob = &weatherJob{}
@@ -83,7 +92,7 @@ var _ tgbotbase.CronJob = &weatherJob{}
 func (job *weatherJob) Do(scheduledWhen time.Time, cron tgbotbase.Cron) {
 	defer cron.AddJob(scheduledWhen.Add(24*time.Hour), job)
 
-	if msg, err := getForecast(job.token, job.cityID, time.Now()); err == nil {
+	if msg, err := getForecast(job.provider, job.place, time.Now(), job.detail, job.localizer.For(context.TODO(), 0, job.chatID)); err == nil {
 		job.OutMsgCh <- tgbotapi.NewMessage(int64(job.chatID), msg)
 	}
 }
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather/gazetteer"
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const weatherCallbackPrefix = "weather:"
+
+// weatherCallbackData keeps coordinates of the chosen city and the requested date (0 for current weather)
+func weatherCallbackData(c gazetteer.City, date *time.Time) string {
+	var unix int64
+	if date != nil {
+		unix = date.Unix()
+	}
+	return fmt.Sprintf("%s%.4f:%.4f:%d", weatherCallbackPrefix, c.Lat, c.Lon, unix)
+}
+
+func cityButtons(candidates []gazetteer.Match, date *time.Time, lang string) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(candidates))
+	for _, c := range candidates {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(c.Title(lang), weatherCallbackData(c.City, date))))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (h *weatherHandler) CallbackPrefix() string {
+	return weatherCallbackPrefix
+}
+
+func (h *weatherHandler) HandleCallback(q tgbotapi.CallbackQuery) string {
+	var chat tgbotbase.ChatID
+	if q.Message != nil && q.Message.Chat != nil {
+		chat = tgbotbase.ChatID(q.Message.Chat.ID)
+	}
+	user := tgbotbase.UserID(q.From.ID)
+	tr := h.localizer.For(context.TODO(), user, chat)
+
+	splits := strings.Split(strings.TrimPrefix(q.Data, weatherCallbackPrefix), ":")
+	if len(splits) != 3 {
+		log.Printf("Unexpected weather callback data '%s'", q.Data)
+		return ""
+	}
+	lat, errLat := strconv.ParseFloat(splits[0], 64)
+	lon, errLon := strconv.ParseFloat(splits[1], 64)
+	unix, errDate := strconv.ParseInt(splits[2], 10, 64)
+	if errLat != nil || errLon != nil || errDate != nil {
+		log.Printf("Unexpected weather callback data '%s'", q.Data)
+		return ""
+	}
+
+	var date *time.Time
+	if unix != 0 {
+		t := time.Unix(unix, 0).In(userLocation(h.properties, user, chat))
+		date = &t
+	}
+	text := h.weatherText(namedPlace(h.cities, lat, lon, tr.Lang), date, user, chat, tr)
+	if q.Message != nil {
+		h.Replier().EditText(chat, q.Message.MessageID, text)
+	}
+	return ""
+}
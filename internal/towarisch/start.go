@@ -2,11 +2,14 @@ package towarisch
 
 import (
 	"context"
+	"errors"
 
 	log "github.com/sirupsen/logrus"
 
 	cmd "github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler"
 	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/covid"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather"
+	"github.com/ilyalavrinov/tgbots/internal/towarisch/commandhandler/weather/gazetteer"
 	"github.com/ilyalavrinov/tgbots/pkg/tgbotbase"
 )
 
@@ -22,26 +25,55 @@ func Start(cfg_filename string) error {
 
 	log.Printf("Starting bot with full config: %+v", fullcfg)
 
+	host := tgbotbase.NewStandaloneHost(context.TODO(), tgbotbase.HostConfig{Redis: fullcfg.Redis})
+	if err := Setup(host, "towarisch", fullcfg); err != nil {
+		log.Printf("My bot cannot be set up due to error: %s", err)
+		return err
+	}
+	err = host.Start()
+
+	log.Print("Stopping my bot")
+	return err
+}
+
+// Setup creates towarisch bot at the host and registers all its handlers
+func Setup(host *tgbotbase.Host, name string, fullcfg Config) error {
+	if host.Redis == nil {
+		return errors.New("towarisch requires Redis to be configured")
+	}
+
 	tgcfg := tgbotbase.Config{TGBot: fullcfg.TGBot,
 		Proxy_SOCKS5: fullcfg.Proxy_SOCKS5}
-	bot := tgbotbase.NewBot(tgcfg)
+	bot, err := host.NewBot(name, tgcfg)
+	if err != nil {
+		return err
+	}
+
+	redispool := host.Redis
+	propstorage := bot.Properties()
+	remindstorage := cmd.NewRedisReminderStorage(redispool, bot.KeyPrefix())
+	todostorage := cmd.NewRedisTodoStorage(redispool, bot.KeyPrefix())
 
-	rediscfg := fullcfg.Redis
-	redispool := tgbotbase.NewRedisPool(context.TODO(), rediscfg)
-	propstorage := tgbotbase.NewRedisPropertyStorage(redispool)
-	remindstorage := cmd.NewRedisReminderStorage(redispool)
+	cron := host.Cron
 
-	cron := tgbotbase.NewCron()
+	weatherProvider, err := weather.New(fullcfg.Weather.Provider, fullcfg.Weather.Token)
+	if err != nil {
+		return err
+	}
+	weatherProvider = weather.NewCachedProvider(weatherProvider, weather.NewRedisCache(redispool))
+	cities, err := gazetteer.Load(fullcfg.Weather.Cities)
+	if err != nil {
+		return err
+	}
 
 	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(cmd.NewPropertyHandler(propstorage)))
-	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(cmd.NewWeatherHandler(fullcfg.Weather.Token, redispool, propstorage)))
-	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(cmd.NewRemindHandler(cron, remindstorage, propstorage)))
+	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(cmd.NewWeatherHandler(weatherProvider, cities, propstorage)))
+	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(cmd.NewRemindHandler(cron, remindstorage, todostorage, propstorage)))
 	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(cmd.NewKittiesHandler(cron, propstorage)))
-	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(cmd.NewWeatherMorningHandler(cron, propstorage, redispool, fullcfg.Weather.Token)))
+	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(cmd.NewWeatherMorningHandler(cron, propstorage, cities, weatherProvider)))
+	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(cmd.NewWeatherAlertsHandler(cron, propstorage, cities, weatherProvider)))
 	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(covid.NewCovid19Handler(cron, propstorage, covid.NewRedisHistory(redispool))))
 	bot.AddHandler(tgbotbase.NewBackgroundMessageDealer(cmd.NewNewsNNHandler(cron, propstorage)))
-	bot.Start()
-
-	log.Print("Stopping my bot")
+	bot.AddHandler(tgbotbase.NewIncomingMessageDealer(tgbotbase.NewChatsHandler(bot.ChatRegistry(), fullcfg.Owners.ID)))
 	return nil
 }
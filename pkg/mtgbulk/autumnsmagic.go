@@ -1,21 +1,40 @@
 package mtgbulk
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gocolly/colly"
 )
 
-func searchAutumnsMagic(searchName string, names map[string]bool) CardResult {
+const autumnsMagicName = "AutumnsMagic"
+
+type autumnsMagicSeller struct{}
+
+func init() {
+	RegisterSeller(autumnsMagicSeller{})
+}
+
+func (autumnsMagicSeller) Name() string {
+	return autumnsMagicName
+}
+
+func (autumnsMagicSeller) Kind() SellerKind {
+	return Shop
+}
+
+func (autumnsMagicSeller) Search(ctx context.Context, q CardQuery) (CardResult, error) {
+	return searchAutumnsMagic(ctx, q.EnglishName, q.Aliases)
+}
+
+func searchAutumnsMagic(ctx context.Context, searchName string, names map[string]bool) (CardResult, error) {
 	searchName = strings.ToLower(searchName)
 	result := newCardResult()
 	addr := autumnsMagickSearchURL(searchName)
 
-	c := colly.NewCollector()
-	c.SetRequestTimeout(20 * time.Second)
+	c := newCollector(ctx)
 	c.OnHTML(".product-wrapper", func(e *colly.HTMLElement) {
 		name := e.ChildText(".card-name a")
 		if !names[strings.ToLower(name)] {
@@ -41,21 +60,26 @@ func searchAutumnsMagic(searchName string, names map[string]bool) CardResult {
 				"err", err)
 			return
 		}
+		foil := e.ChildText(".product-properties .foil") != ""
 		logger.Debugw("card",
 			"searchName", searchName,
 			"name", name,
 			"price", price,
-			"count", qty)
+			"count", qty,
+			"foil", foil)
 
 		result.Available = true
 		result.Prices = append(result.Prices, CardPrice{
-			Price:    float32(price),
-			Foil:     false, // TODO: get this info
-			Currency: RUR,
-			Quantity: qty,
-			Platform: MtgTrade,
-			Trader:   "AutumnsMagic",
-			URL:      addr, // TODO: correct it! - it's just a search result, but we can get a direct link to a card at a seller
+			Price:     float32(price),
+			Foil:      foil,
+			Currency:  RUR,
+			Quantity:  qty,
+			Condition: ParseCondition(e.ChildText(".product-properties .condition")),
+			Language:  languageCode(e.ChildText(".product-properties .lang")),
+			Set:       setFromIcon(e.ChildAttr(".product-properties i.ss", "class")),
+			Platform:  autumnsMagicName,
+			Trader:    "AutumnsMagic",
+			URL:       addr, // TODO: correct it! - it's just a search result, but we can get a direct link to a card at a seller
 		})
 	})
 
@@ -65,7 +89,7 @@ func searchAutumnsMagic(searchName string, names map[string]bool) CardResult {
 			"url", addr,
 			"err", err)
 	}
-	return result
+	return result, err
 }
 
 func autumnsMagickSearchURL(searchName string) string {
@@ -0,0 +1,127 @@
+package mtgbulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultPriceTTL is used by price caches created with zero TTL
+const DefaultPriceTTL = time.Hour
+
+// PriceCache keeps search results of a seller for a limited time
+type PriceCache interface {
+	// Get returns false if there is no fresh result of the card at the platform
+	Get(ctx context.Context, platform, card string) (CardResult, bool)
+	Set(ctx context.Context, platform, card string, res CardResult)
+}
+
+// priceCacheKey is the same for names differing only in case and spacing
+func priceCacheKey(platform, card string) string {
+	card = strings.Join(strings.Fields(strings.ToLower(card)), " ")
+	return fmt.Sprintf("mtgbulk:price:%s:%s", strings.ToLower(platform), card)
+}
+
+type memoryPriceEntry struct {
+	res     CardResult
+	expires time.Time
+}
+
+type memoryPriceCache struct {
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]memoryPriceEntry
+}
+
+var _ PriceCache = &memoryPriceCache{}
+
+// NewMemoryPriceCache creates price cache living in the process memory
+func NewMemoryPriceCache(ttl time.Duration) PriceCache {
+	if ttl <= 0 {
+		ttl = DefaultPriceTTL
+	}
+	return &memoryPriceCache{ttl: ttl, entries: make(map[string]memoryPriceEntry)}
+}
+
+func (c *memoryPriceCache) Get(ctx context.Context, platform, card string) (CardResult, bool) {
+	key := priceCacheKey(platform, card)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, found := c.entries[key]
+	if !found {
+		return CardResult{}, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return CardResult{}, false
+	}
+	res := e.res
+	res.Prices = append([]CardPrice(nil), e.res.Prices...)
+	return res, true
+}
+
+func (c *memoryPriceCache) Set(ctx context.Context, platform, card string, res CardResult) {
+	res.Prices = append([]CardPrice(nil), res.Prices...)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[priceCacheKey(platform, card)] = memoryPriceEntry{res: res, expires: time.Now().Add(c.ttl)}
+}
+
+type redisPriceCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+var _ PriceCache = &redisPriceCache{}
+
+// NewRedisPriceCache creates price cache in the Redis DB of the client
+func NewRedisPriceCache(client *redis.Client, ttl time.Duration) PriceCache {
+	if ttl <= 0 {
+		ttl = DefaultPriceTTL
+	}
+	return &redisPriceCache{client: client, ttl: ttl}
+}
+
+func (c *redisPriceCache) Get(ctx context.Context, platform, card string) (CardResult, bool) {
+	key := priceCacheKey(platform, card)
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Errorw("could not get cached prices",
+				"key", key,
+				"err", err)
+		}
+		return CardResult{}, false
+	}
+	var res CardResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		logger.Errorw("could not unmarshal cached prices",
+			"key", key,
+			"err", err)
+		return CardResult{}, false
+	}
+	return res, true
+}
+
+func (c *redisPriceCache) Set(ctx context.Context, platform, card string, res CardResult) {
+	key := priceCacheKey(platform, card)
+	data, err := json.Marshal(res)
+	if err != nil {
+		logger.Errorw("could not marshal prices for cache",
+			"key", key,
+			"err", err)
+		return
+	}
+	if err := c.client.Set(ctx, key, data, c.ttl).Err(); err != nil {
+		logger.Errorw("could not cache prices",
+			"key", key,
+			"err", err)
+	}
+}
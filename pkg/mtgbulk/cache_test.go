@@ -0,0 +1,52 @@
+package mtgbulk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSearchAllCache(t *testing.T) {
+	seller := &fakeSeller{name: "FakeCached", price: 5}
+	req := NewNamesRequest()
+	req.Cache = NewMemoryPriceCache(time.Hour)
+
+	first, _, cached := searchAll(context.Background(), []CardQuery{{Name: "Sol Ring"}}, []Seller{seller}, req)
+	if cached != 0 || seller.calls != 1 {
+		t.Fatalf("expected a real search, got %d cached and %d calls", cached, seller.calls)
+	}
+	fetchedAt := first["Sol Ring"].Prices[0].FetchedAt
+	if fetchedAt.IsZero() {
+		t.Error("expected fetch time to be set")
+	}
+
+	second, _, cached := searchAll(context.Background(), []CardQuery{{Name: "sol  ring"}}, []Seller{seller}, req)
+	if cached != 1 || seller.calls != 1 {
+		t.Errorf("expected result from cache, got %d cached and %d calls", cached, seller.calls)
+	}
+	if p := second["sol  ring"].Prices; len(p) != 1 || !p[0].FetchedAt.Equal(fetchedAt) {
+		t.Errorf("expected cached offer with the original fetch time, got %+v", p)
+	}
+
+	req.ForceRefresh = true
+	searchAll(context.Background(), []CardQuery{{Name: "Sol Ring"}}, []Seller{seller}, req)
+	if seller.calls != 2 {
+		t.Errorf("expected forced search, got %d calls", seller.calls)
+	}
+}
+
+func TestCardResultJSON(t *testing.T) {
+	res := CardResult{Available: true, Prices: []CardPrice{{Price: 1.5, Currency: USD, Quantity: 2, Platform: "MtgSale", FetchedAt: time.Unix(1600000000, 0)}}}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded CardResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Available || len(decoded.Prices) != 1 || decoded.Prices[0].Currency != USD || !decoded.Prices[0].FetchedAt.Equal(res.Prices[0].FetchedAt) {
+		t.Errorf("expected %+v after round trip, got %+v", res, decoded)
+	}
+}
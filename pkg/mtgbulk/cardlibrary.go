@@ -1,21 +1,29 @@
 package mtgbulk
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
 type Library interface {
 	CardAliases(string) (map[string]bool, error)
 	EnglishName(string) (string, error)
+	// Resolve returns the known name of the card closest to the given one or UnknownCardError with suggestions
+	Resolve(string) (string, error)
 }
 
 type InMemoryLibrary struct {
 	cardIDtoNames       map[string]map[string]bool
 	cardNameToID        map[string]string
 	cardIDtoEnglishName map[string]string
+	// printedNames maps lowercase names to names as printed on cards
+	printedNames map[string]string
+	resolver     *nameResolver
 }
 
 type Card struct {
@@ -27,67 +35,200 @@ type Card struct {
 	URI       string `json:"uri"`
 }
 
+// libraryCard is a card of the library with all its names as printed; the English one goes first
+type libraryCard struct {
+	OracleID string
+	Names    []string
+}
+
+func newInMemoryLibrary() *InMemoryLibrary {
+	return &InMemoryLibrary{
+		cardIDtoNames:       make(map[string]map[string]bool),
+		cardNameToID:        make(map[string]string),
+		cardIDtoEnglishName: make(map[string]string),
+		printedNames:        make(map[string]string),
+		resolver:            newNameResolver(),
+	}
+}
+
+func (lib *InMemoryLibrary) add(c libraryCard) {
+	names, found := lib.cardIDtoNames[c.OracleID]
+	if !found {
+		names = make(map[string]bool)
+		lib.cardIDtoNames[c.OracleID] = names
+		lib.cardIDtoEnglishName[c.OracleID] = c.Names[0]
+	}
+	for _, printed := range c.Names {
+		parts := []string{printed}
+		if strings.Contains(printed, "//") {
+			parts = append(parts, strings.Split(printed, " // ")...)
+		}
+		for _, p := range parts {
+			names[strings.ToLower(p)] = true
+			lib.cardNameToID[strings.ToLower(p)] = c.OracleID
+			if _, found := lib.printedNames[strings.ToLower(p)]; !found {
+				lib.printedNames[strings.ToLower(p)] = p
+			}
+			lib.resolver.add(p, c.OracleID)
+		}
+	}
+}
+
+// NewInMemoryLibrary reads Scryfall dump of all cards; English and Russian names are kept.
+// Loading an index made by BuildLibraryIndex is much faster, see OpenLibrary
 func NewInMemoryLibrary(dumpPath string) (Library, error) {
+	lib := newInMemoryLibrary()
+	err := readDump(dumpPath, func(c libraryCard) {
+		lib.add(c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	lib.resolver.index()
+	return lib, nil
+}
+
+// readDump decodes Scryfall dump card by card, so the dump is never kept in memory as a whole
+func readDump(dumpPath string, add func(libraryCard)) error {
 	f, err := os.Open(dumpPath)
 	if err != nil {
-		return nil, fmt.Errorf("Cannot open file with dump: %w", err)
+		return fmt.Errorf("Cannot open file with dump: %w", err)
 	}
+	defer f.Close()
 	logger.Debugw("decoding dump",
 		"path", dumpPath)
-	dec := json.NewDecoder(f)
+	dec := json.NewDecoder(bufio.NewReader(f))
 	_, err = dec.Token()
 	if err != nil {
-		return nil, fmt.Errorf("Cannot tokenize file with dump: %w", err)
-	}
-
-	lib := &InMemoryLibrary{
-		cardIDtoNames:       make(map[string]map[string]bool),
-		cardNameToID:        make(map[string]string),
-		cardIDtoEnglishName: make(map[string]string),
+		return fmt.Errorf("Cannot tokenize file with dump: %w", err)
 	}
 
 	for dec.More() {
 		var c Card
 		err := dec.Decode(&c)
 		if err != nil {
-			return nil, fmt.Errorf("Cannot decode file with dump: %w", err)
+			return fmt.Errorf("Cannot decode file with dump: %w", err)
 		}
 		if c.Lang != "en" && c.Lang != "ru" {
 			continue
 		}
-
-		if c.LocalName == "" {
-			c.LocalName = c.Name
-			if c.Lang == "en" {
-				lib.cardIDtoEnglishName[c.OracleID] = c.Name
-			}
+		names := []string{c.Name}
+		if c.LocalName != "" && c.LocalName != c.Name {
+			names = append(names, c.LocalName)
 		}
-		c.LocalName = strings.ToLower(c.LocalName)
-		names, found := lib.cardIDtoNames[c.OracleID]
+		add(libraryCard{OracleID: c.OracleID, Names: names})
+	}
+	_, err = dec.Token()
+	if err != nil {
+		return fmt.Errorf("Cannot advance to next token at dump: %w", err)
+	}
+
+	logger.Debugw("decoding done")
+	return nil
+}
+
+// BuildLibraryIndex reads Scryfall dump and writes the card names into a compact index: tab separated lines
+// of Oracle ID, English name and other names. The index is replaced only when it is completely written
+func BuildLibraryIndex(dumpPath, indexPath string) error {
+	byID := make(map[string]*libraryCard)
+	ids := make([]string, 0)
+	err := readDump(dumpPath, func(c libraryCard) {
+		card, found := byID[c.OracleID]
 		if !found {
-			lib.cardIDtoNames[c.OracleID] = make(map[string]bool)
-			names = lib.cardIDtoNames[c.OracleID]
+			card = &libraryCard{OracleID: c.OracleID}
+			byID[c.OracleID] = card
+			ids = append(ids, c.OracleID)
 		}
-		names[c.LocalName] = true
-		lib.cardNameToID[c.LocalName] = c.OracleID
-
-		if strings.Contains(c.LocalName, "//") {
-			parts := strings.Split(c.LocalName, " // ")
-			for _, p := range parts {
-				names[p] = true
-				lib.cardNameToID[p] = c.OracleID
+		for _, n := range c.Names {
+			if !contains(card.Names, n) {
+				card.Names = append(card.Names, n)
 			}
 		}
+	})
+	if err != nil {
+		return err
 	}
-	_, err = dec.Token()
+	sort.Strings(ids)
+
+	tmpPath := indexPath + ".tmp"
+	f, err := os.Create(tmpPath)
 	if err != nil {
-		return nil, fmt.Errorf("Cannot advance to next token at dump: %w", err)
+		return fmt.Errorf("Cannot create card index: %w", err)
+	}
+	defer os.Remove(tmpPath)
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# Card index made of %s: Oracle ID, English name, other names\n", dumpPath)
+	for _, id := range ids {
+		fmt.Fprintf(w, "%s\t%s\n", id, strings.Join(byID[id].Names, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("Cannot write card index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Cannot write card index: %w", err)
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		return fmt.Errorf("Cannot replace card index: %w", err)
 	}
+	logger.Debugw("card index built",
+		"path", indexPath,
+		"cards", len(ids))
+	return nil
+}
 
-	logger.Debugw("decoding done")
+// LoadLibraryIndex reads the index made by BuildLibraryIndex
+func LoadLibraryIndex(indexPath string) (Library, error) {
+	f, err := os.Open(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot open card index: %w", err)
+	}
+	defer f.Close()
+	lib, err := readLibraryIndex(f)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read card index %s: %w", indexPath, err)
+	}
 	return lib, nil
 }
 
+func readLibraryIndex(r io.Reader) (*InMemoryLibrary, error) {
+	lib := newInMemoryLibrary()
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected Oracle ID and name", line)
+		}
+		lib.add(libraryCard{OracleID: fields[0], Names: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	lib.resolver.index()
+	return lib, nil
+}
+
+// OpenLibrary loads the card index; it is built from the dump at first if it is missing or older than the dump
+func OpenLibrary(dumpPath, indexPath string) (Library, error) {
+	index, indexErr := os.Stat(indexPath)
+	dump, dumpErr := os.Stat(dumpPath)
+	if dumpErr != nil && indexErr != nil {
+		return nil, fmt.Errorf("Neither card index %s nor Scryfall dump %s is found, download the dump at first", indexPath, dumpPath)
+	}
+	if dumpErr == nil && (indexErr != nil || index.ModTime().Before(dump.ModTime())) {
+		if err := BuildLibraryIndex(dumpPath, indexPath); err != nil {
+			return nil, err
+		}
+	}
+	return LoadLibraryIndex(indexPath)
+}
+
 func (lib *InMemoryLibrary) CardAliases(cardname string) (map[string]bool, error) {
 	cardname = strings.ToLower(cardname)
 	id, found := lib.cardNameToID[cardname]
@@ -105,3 +246,19 @@ func (lib *InMemoryLibrary) EnglishName(cardname string) (string, error) {
 	}
 	return lib.cardIDtoEnglishName[id], nil
 }
+
+// Resolve tries exact name at first, then names differing in case, punctuation, transliteration,
+// prefixes and typos; the name is returned as printed on the card
+func (lib *InMemoryLibrary) Resolve(cardname string) (string, error) {
+	if printed, found := lib.printedNames[strings.ToLower(cardname)]; found {
+		return printed, nil
+	}
+	name, err := lib.resolver.resolve(cardname)
+	if err != nil {
+		return "", err
+	}
+	logger.Debugw("card name resolved",
+		"name", cardname,
+		"resolved", name)
+	return name, nil
+}
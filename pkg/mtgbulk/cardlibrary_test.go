@@ -0,0 +1,117 @@
+package mtgbulk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLibraryIndex(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "dump.json")
+	index := filepath.Join(dir, "index.tsv")
+	if err := os.WriteFile(dump, []byte(libraryDump), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := OpenLibrary(dump, index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(index); err != nil {
+		t.Fatalf("index has not been built: %s", err)
+	}
+
+	for _, name := range []string{"молния", "lightning bolt", "Ice"} {
+		if _, err := lib.CardAliases(name); err != nil {
+			t.Errorf("no aliases of %q: %s", name, err)
+		}
+	}
+	aliases, _ := lib.CardAliases("молния")
+	if !aliases["lightning bolt"] || !aliases["молния"] {
+		t.Errorf("expected English and Russian aliases, got %v", aliases)
+	}
+	if en, _ := lib.EnglishName("Молния"); en != "Lightning Bolt" {
+		t.Errorf("expected English name Lightning Bolt, got %q", en)
+	}
+	if en, _ := lib.EnglishName("fire"); en != "Fire // Ice" {
+		t.Errorf("expected English name Fire // Ice, got %q", en)
+	}
+	if name, err := lib.Resolve("Lightnig Bolt"); err != nil || name != "Lightning Bolt" {
+		t.Errorf("expected Lightning Bolt to be resolved, got %q, %v", name, err)
+	}
+
+	// the index is used without the dump
+	if err := os.Remove(dump); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenLibrary(dump, index); err != nil {
+		t.Errorf("could not open index without dump: %s", err)
+	}
+	if _, err := OpenLibrary(dump, filepath.Join(dir, "missing.tsv")); err == nil {
+		t.Errorf("expected error without dump and index")
+	}
+}
+
+func TestRefreshScryfallDump(t *testing.T) {
+	updated := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	downloads := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bulk-data":
+			fmt.Fprintf(w, `{"data": [{"type": "oracle_cards", "download_uri": "%[1]s/oracle.json"},
+				{"type": "all_cards", "download_uri": "%[1]s/all.json", "updated_at": %q}]}`,
+				srv.URL, updated.Format(time.RFC3339))
+		case "/all.json":
+			downloads++
+			fmt.Fprint(w, libraryDump)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { scryfallBulkURL = url }(scryfallBulkURL)
+	scryfallBulkURL = srv.URL + "/bulk-data"
+
+	dump := filepath.Join(t.TempDir(), "dump.json")
+	for i, expected := range []bool{true, false} {
+		downloaded, err := RefreshScryfallDump(context.Background(), dump, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if downloaded != expected {
+			t.Errorf("refresh %d: expected downloaded %v, got %v", i, expected, downloaded)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("expected the dump to be downloaded once, got %d", downloads)
+	}
+
+	// the index built after the previous download is rebuilt from the new dump
+	index := filepath.Join(t.TempDir(), "index.tsv")
+	if _, err := OpenLibrary(dump, index); err != nil {
+		t.Fatal(err)
+	}
+	built := time.Now().Add(-time.Second)
+	if err := os.Chtimes(index, built, built); err != nil {
+		t.Fatal(err)
+	}
+	if downloaded, _ := RefreshScryfallDump(context.Background(), dump, true); !downloaded {
+		t.Errorf("expected forced download")
+	}
+	if _, err := OpenLibrary(dump, index); err != nil {
+		t.Fatal(err)
+	}
+	if st, err := os.Stat(index); err != nil || !st.ModTime().After(built) {
+		t.Errorf("expected the index to be rebuilt from the new dump")
+	}
+	data, err := os.ReadFile(dump)
+	if err != nil || string(data) != libraryDump {
+		t.Errorf("unexpected dump contents: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,273 @@
+package mtgbulk
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Condition of a card; better conditions have lower values, zero means that the seller has not told it
+type Condition int
+
+const (
+	UnknownCondition Condition = iota
+	NearMint
+	SlightlyPlayed
+	ModeratelyPlayed
+	HeavilyPlayed
+	Damaged
+)
+
+var conditionNames = map[Condition]string{
+	NearMint:         "NM",
+	SlightlyPlayed:   "SP",
+	ModeratelyPlayed: "MP",
+	HeavilyPlayed:    "HP",
+	Damaged:          "DMG",
+}
+
+// conditionAliases maps grades used by shops to conditions
+var conditionAliases = map[string]Condition{
+	"M":    NearMint,
+	"NM":   NearMint,
+	"NM/M": NearMint,
+	"SP":   SlightlyPlayed,
+	"LP":   SlightlyPlayed,
+	"EX":   SlightlyPlayed,
+	"MP":   ModeratelyPlayed,
+	"PL":   ModeratelyPlayed,
+	"HP":   HeavilyPlayed,
+	"DMG":  Damaged,
+	"D":    Damaged,
+	// names used by deck builders
+	"MINT":              NearMint,
+	"NEAR MINT":         NearMint,
+	"LIGHTLY PLAYED":    SlightlyPlayed,
+	"SLIGHTLY PLAYED":   SlightlyPlayed,
+	"EXCELLENT":         SlightlyPlayed,
+	"MODERATELY PLAYED": ModeratelyPlayed,
+	"PLAYED":            ModeratelyPlayed,
+	"HEAVILY PLAYED":    HeavilyPlayed,
+	"DAMAGED":           Damaged,
+}
+
+func (c Condition) String() string {
+	return conditionNames[c]
+}
+
+func (c Condition) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Condition) UnmarshalText(text []byte) error {
+	*c = ParseCondition(string(text))
+	return nil
+}
+
+// ParseCondition recognizes grades like 'NM' or 'LP'; UnknownCondition is returned for anything else
+func ParseCondition(s string) Condition {
+	return conditionAliases[strings.ToUpper(strings.TrimSpace(s))]
+}
+
+// languages lists codes of card languages
+var languages = map[string]bool{
+	"EN": true, "RU": true, "DE": true, "FR": true, "IT": true, "ES": true,
+	"PT": true, "JP": true, "KO": true, "ZH": true, "CN": true, "TW": true,
+}
+
+// FoilFilter selects foil or non-foil offers
+type FoilFilter int
+
+const (
+	AnyFoil FoilFilter = iota
+	OnlyFoil
+	NoFoil
+)
+
+// CardFilter restricts offers of a card; empty values allow anything.
+// Offers with unknown language, set or condition pass the filter since many shops do not tell them
+type CardFilter struct {
+	Languages    []string
+	Sets         []string
+	MinCondition Condition // the worst acceptable condition
+	Foil         FoilFilter
+}
+
+func (f CardFilter) empty() bool {
+	return len(f.Languages) == 0 && len(f.Sets) == 0 && f.MinCondition == UnknownCondition && f.Foil == AnyFoil
+}
+
+// matches tells if the offer passes the filter; unknown attributes pass only if lenient
+func (f CardFilter) matches(p CardPrice, lenient bool) bool {
+	if len(f.Languages) > 0 && !matchesAny(p.Language, f.Languages, lenient) {
+		return false
+	}
+	if len(f.Sets) > 0 && !matchesAny(p.Set, f.Sets, lenient) {
+		return false
+	}
+	if f.MinCondition != UnknownCondition {
+		if p.Condition == UnknownCondition {
+			if !lenient {
+				return false
+			}
+		} else if p.Condition > f.MinCondition {
+			return false
+		}
+	}
+	switch f.Foil {
+	case OnlyFoil:
+		return p.Foil
+	case NoFoil:
+		return !p.Foil
+	}
+	return true
+}
+
+func matchesAny(value string, allowed []string, lenient bool) bool {
+	if value == "" {
+		return lenient
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(a, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// CardRequirements are filters of a card from a card list like '4x Lightning Bolt [EN, NM+, foil?]':
+// Required ones exclude offers, Preferred ones (marked with '?') are used if they give enough cards
+type CardRequirements struct {
+	Required  CardFilter
+	Preferred CardFilter
+}
+
+// merge combines requirements of duplicated entries of a card. Required filters must be the same since
+// the merged quantity cannot follow both; preferred ones are joined so that either preference is fine
+func (r CardRequirements) merge(other CardRequirements) (CardRequirements, bool) {
+	if !r.Required.equal(other.Required) {
+		return r, false
+	}
+	return CardRequirements{Required: r.Required, Preferred: r.Preferred.union(other.Preferred)}, true
+}
+
+func (f CardFilter) equal(other CardFilter) bool {
+	return sameItems(f.Languages, other.Languages) && sameItems(f.Sets, other.Sets) &&
+		f.MinCondition == other.MinCondition && f.Foil == other.Foil
+}
+
+// union passes offers passing any of the filters; an empty value of either filter allows anything
+func (f CardFilter) union(other CardFilter) CardFilter {
+	var u CardFilter
+	if len(f.Languages) > 0 && len(other.Languages) > 0 {
+		u.Languages = joinItems(f.Languages, other.Languages)
+	}
+	if len(f.Sets) > 0 && len(other.Sets) > 0 {
+		u.Sets = joinItems(f.Sets, other.Sets)
+	}
+	if f.MinCondition != UnknownCondition && other.MinCondition != UnknownCondition {
+		u.MinCondition = max(f.MinCondition, other.MinCondition)
+	}
+	if f.Foil == other.Foil {
+		u.Foil = f.Foil
+	}
+	return u
+}
+
+func sameItems(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		if !contains(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func joinItems(a, b []string) []string {
+	joined := append([]string{}, a...)
+	for _, s := range b {
+		if !contains(joined, s) {
+			joined = append(joined, s)
+		}
+	}
+	return joined
+}
+
+var setCodeRe = regexp.MustCompile(`^[A-Z0-9]{2,5}$`)
+
+// requiredCondition reads condition of a card list written like 'SP' or 'SP+'. Only canonical names are
+// accepted: shop grades like 'EX' or 'M' are also set codes (Exodus, Magic 2010 and so on)
+func requiredCondition(token string) Condition {
+	token = strings.TrimSuffix(token, "+")
+	for c, name := range conditionNames {
+		if name == token {
+			return c
+		}
+	}
+	return UnknownCondition
+}
+
+// parseRequirements reads comma or space separated tokens: language codes, conditions meaning
+// 'this or better' and optionally written like 'SP+', 'foil' or 'nonfoil', and set codes
+func parseRequirements(s string) (CardRequirements, error) {
+	var req CardRequirements
+	for _, token := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		f := &req.Required
+		if strings.HasSuffix(token, "?") {
+			f = &req.Preferred
+			token = strings.TrimSuffix(token, "?")
+		}
+		token = strings.ToUpper(token)
+
+		if c := requiredCondition(token); c != UnknownCondition {
+			f.MinCondition = c
+			continue
+		}
+
+		switch {
+		case token == "FOIL":
+			f.Foil = OnlyFoil
+		case token == "NONFOIL":
+			f.Foil = NoFoil
+		case languages[token]:
+			f.Languages = append(f.Languages, token)
+		case setCodeRe.MatchString(token):
+			f.Sets = append(f.Sets, token)
+		default:
+			return req, fmt.Errorf("Unknown card requirement %q", token)
+		}
+	}
+	return req, nil
+}
+
+// apply leaves offers passing required filters; offers passing preferred ones as well are used alone
+// if they give enough cards
+func (r CardRequirements) apply(res CardResult, quantity int) CardResult {
+	if r.Required.empty() && r.Preferred.empty() {
+		return res
+	}
+
+	required := make([]CardPrice, 0, len(res.Prices))
+	preferred := make([]CardPrice, 0, len(res.Prices))
+	preferredQty := 0
+	for _, p := range res.Prices {
+		if !r.Required.matches(p, true) {
+			continue
+		}
+		required = append(required, p)
+		if !r.Preferred.empty() && r.Preferred.matches(p, false) {
+			preferred = append(preferred, p)
+			preferredQty += p.Quantity
+		}
+	}
+
+	filtered := CardResult{Prices: required}
+	if !r.Preferred.empty() && preferredQty >= quantity {
+		filtered.Prices = preferred
+	}
+	filtered.Available = len(filtered.Prices) > 0
+	return filtered
+}
@@ -0,0 +1,75 @@
+package mtgbulk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLineRequirements(t *testing.T) {
+	name, qty, req, err := parseLine("4x Lightning Bolt [EN, SP+, m10 nm? foil?]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Lightning Bolt" || qty != 4 {
+		t.Errorf("expected 4 Lightning Bolt, got %d %q", qty, name)
+	}
+	expected := CardRequirements{
+		Required:  CardFilter{Languages: []string{"EN"}, Sets: []string{"M10"}, MinCondition: SlightlyPlayed},
+		Preferred: CardFilter{MinCondition: NearMint, Foil: OnlyFoil},
+	}
+	if !reflect.DeepEqual(req, expected) {
+		t.Errorf("expected %+v, got %+v", expected, req)
+	}
+
+	if _, _, _, err := parseLine("Opt [mint-ish]"); err == nil {
+		t.Error("expected error for unknown requirement")
+	}
+}
+
+func TestParseRequirementsSetLikeGrade(t *testing.T) {
+	// EX is Exodus rather than the 'excellent' grade of shops
+	req, err := parseRequirements("EX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := CardRequirements{Required: CardFilter{Sets: []string{"EX"}}}
+	if !reflect.DeepEqual(req, expected) {
+		t.Errorf("expected %+v, got %+v", expected, req)
+	}
+}
+
+func TestRequirementsApply(t *testing.T) {
+	res := CardResult{Available: true, Prices: []CardPrice{
+		{Price: 10, Quantity: 2, Condition: HeavilyPlayed, Language: "EN"},
+		{Price: 20, Quantity: 1, Condition: NearMint, Language: "RU"},
+		{Price: 30, Quantity: 1, Condition: SlightlyPlayed},
+		{Price: 40, Quantity: 3, Condition: NearMint, Language: "EN"},
+	}}
+	prices := func(r CardResult) []float32 {
+		p := make([]float32, 0, len(r.Prices))
+		for _, cp := range r.Prices {
+			p = append(p, cp.Price)
+		}
+		return p
+	}
+
+	// unknown language passes required filters
+	req := CardRequirements{Required: CardFilter{Languages: []string{"EN"}, MinCondition: SlightlyPlayed}}
+	if got := prices(req.apply(res, 1)); !reflect.DeepEqual(got, []float32{30, 40}) {
+		t.Errorf("unexpected required filtering: %v", got)
+	}
+
+	// preferred offers are used alone only if there are enough of them
+	req = CardRequirements{Preferred: CardFilter{Languages: []string{"EN"}, MinCondition: NearMint}}
+	if got := prices(req.apply(res, 3)); !reflect.DeepEqual(got, []float32{40}) {
+		t.Errorf("expected only preferred offers, got %v", got)
+	}
+	if got := prices(req.apply(res, 4)); len(got) != 4 {
+		t.Errorf("expected every offer when preferred are not enough, got %v", got)
+	}
+
+	req = CardRequirements{Required: CardFilter{Languages: []string{"DE"}, Foil: OnlyFoil}}
+	if filtered := req.apply(res, 1); filtered.Available {
+		t.Errorf("expected no offers, got %v", prices(filtered))
+	}
+}
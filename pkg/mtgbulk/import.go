@@ -0,0 +1,304 @@
+package mtgbulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotutil"
+)
+
+// utf8BOM starts files saved by some editors on Windows
+const utf8BOM = "\uFEFF"
+
+// DeckFormat is a format of card lists understood by ParseText
+type DeckFormat int
+
+const (
+	// TextList has a card per line like '4x Opt [EN]', exports of MTG Arena and MTGO text files as well
+	TextList DeckFormat = iota
+	// MTGODeck is XML .dek file of MTGO
+	MTGODeck
+	// CSVList is a CSV export of Moxfield, Archidekt and alike with a header line
+	CSVList
+)
+
+func (f DeckFormat) String() string {
+	switch f {
+	case MTGODeck:
+		return "MTGO .dek"
+	case CSVList:
+		return "CSV"
+	}
+	return "text"
+}
+
+// DetectFormat guesses the format of the card list by its contents
+func DetectFormat(data []byte) DeckFormat {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte(utf8BOM)))
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return MTGODeck
+	}
+	header, _, _ := strings.Cut(string(trimmed), "\n")
+	if _, err := csvColumns(header); err == nil {
+		return CSVList
+	}
+	return TextList
+}
+
+// deckSection recognizes section headers like 'Sideboard:'; cards of maybeboard are not bought
+func deckSection(line string) (known, bought bool) {
+	switch strings.ToLower(strings.TrimSuffix(strings.TrimSpace(line), ":")) {
+	case "deck", "main", "mainboard", "commander", "companion", "sideboard":
+		return true, true
+	case "maybeboard", "considering", "about":
+		return true, false
+	}
+	return false, false
+}
+
+// cardList collects cards merging duplicates; duplicates must have the same required filters
+type cardList struct {
+	req NamesRequest
+	// names maps normalized names to the first spelling of the card, so that names differing in case
+	// or punctuation are merged
+	names map[string]string
+}
+
+func newCardList() cardList {
+	return cardList{req: NewNamesRequest(), names: make(map[string]string)}
+}
+
+func (l *cardList) add(name string, quantity int, requirements CardRequirements) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("Empty card name")
+	}
+	if quantity <= 0 {
+		logger.Warnw("Illegal requested quantity",
+			"name", name,
+			"quantity", quantity)
+		return fmt.Errorf("Illegal quantity for card %q has been requested: %d", name, quantity)
+	}
+	key := tgbotutil.NormalizeName(name)
+	if first, found := l.names[key]; found {
+		name = first
+		logger.Debugw("Merging duplicated card",
+			"name", name,
+			"quantity", quantity)
+		merged, ok := l.req.Requirements[name].merge(requirements)
+		if !ok {
+			return fmt.Errorf("Card %q is listed several times with different requirements, list it once", name)
+		}
+		requirements = merged
+	}
+	l.names[key] = name
+	l.req.Requirements[name] = requirements
+	l.req.Cards[name] += quantity
+	return nil
+}
+
+// arenaSuffixRe matches printing of MTG Arena exports like '(M10) 146' and foil mark '*F*' of Moxfield
+var arenaSuffixRe = regexp.MustCompile(`\s+\(([A-Za-z0-9]{2,6})\)(?:\s+\S+)?(\s+\*F\*)?$`)
+var foilMarkRe = regexp.MustCompile(`\s+\*F\*$`)
+
+// parseTextList reads lines like '4x Opt [EN]', 'SB: 2 Duress' or '1 Opt (XLN) 65' with section headers
+func parseTextList(data []byte) (NamesRequest, error) {
+	list := newCardList()
+	bought := true
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, utf8BOM))
+		if len(line) == 0 || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if known, b := deckSection(line); known {
+			bought = b
+			continue
+		}
+		if !bought {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "SB:"))
+
+		var printing CardFilter
+		if m := arenaSuffixRe.FindStringSubmatch(line); m != nil {
+			printing.Sets = []string{strings.ToUpper(m[1])}
+			if m[2] != "" {
+				printing.Foil = OnlyFoil
+			}
+			line = line[:len(line)-len(m[0])]
+		} else if foilMarkRe.MatchString(line) {
+			printing.Foil = OnlyFoil
+			line = foilMarkRe.ReplaceAllString(line, "")
+		}
+
+		name, quantity, requirements, err := parseLine(line)
+		if err != nil {
+			logger.Warnw("could not parse line",
+				"err", err,
+				"line", line)
+			return list.req, err
+		}
+		if requirements.Preferred.empty() {
+			requirements.Preferred = printing
+		}
+		if err := list.add(name, quantity, requirements); err != nil {
+			return list.req, err
+		}
+	}
+	return list.req, nil
+}
+
+type mtgoDeck struct {
+	Cards []struct {
+		Quantity  int    `xml:"Quantity,attr"`
+		Sideboard bool   `xml:"Sideboard,attr"`
+		Name      string `xml:"Name,attr"`
+	} `xml:"Cards"`
+}
+
+// parseMTGODeck reads MTGO .dek file; sideboard cards are bought as well
+func parseMTGODeck(data []byte) (NamesRequest, error) {
+	list := newCardList()
+	var deck mtgoDeck
+	if err := xml.Unmarshal(data, &deck); err != nil {
+		return list.req, fmt.Errorf("Could not read MTGO deck: %s", err)
+	}
+	for _, c := range deck.Cards {
+		if err := list.add(c.Name, c.Quantity, CardRequirements{}); err != nil {
+			return list.req, err
+		}
+	}
+	return list.req, nil
+}
+
+// csvHeaders lists accepted header names of the columns
+var csvHeaders = map[string][]string{
+	"quantity":  {"count", "quantity", "qty", "amount"},
+	"name":      {"name", "card name", "card"},
+	"set":       {"edition code", "set code", "edition", "set"},
+	"condition": {"condition"},
+	"language":  {"language", "lang"},
+	"foil":      {"foil", "finish", "printing"},
+	"section":   {"board", "section", "category", "categories"},
+}
+
+// csvColumns maps columns found in the header line to their indexes; quantity and name are mandatory
+func csvColumns(header string) (map[string]int, error) {
+	r := csv.NewReader(strings.NewReader(header))
+	fields, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("No CSV header")
+	}
+	return csvFieldColumns(fields)
+}
+
+// csvFieldColumns is csvColumns for the header already split into fields
+func csvFieldColumns(fields []string) (map[string]int, error) {
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("No CSV header")
+	}
+	columns := make(map[string]int)
+	for i, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		for column, names := range csvHeaders {
+			if _, found := columns[column]; found {
+				continue
+			}
+			for _, n := range names {
+				if f == n {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	if _, found := columns["quantity"]; !found {
+		return nil, fmt.Errorf("No quantity column in CSV header")
+	}
+	if _, found := columns["name"]; !found {
+		return nil, fmt.Errorf("No name column in CSV header")
+	}
+	return columns, nil
+}
+
+// languageCodes maps language names used by deck builders and shops to codes
+var languageCodes = map[string]string{
+	"english": "EN", "russian": "RU", "german": "DE", "french": "FR", "italian": "IT",
+	"spanish": "ES", "portuguese": "PT", "japanese": "JP", "korean": "KO",
+	"chinese simplified": "ZH", "chinese traditional": "TW",
+	"английский": "EN", "русский": "RU", "немецкий": "DE", "французский": "FR", "итальянский": "IT",
+	"испанский": "ES", "португальский": "PT", "японский": "JP", "корейский": "KO", "китайский": "ZH",
+}
+
+func languageCode(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if code, found := languageCodes[s]; found {
+		return code
+	}
+	if code := strings.ToUpper(s); languages[code] {
+		return code
+	}
+	return ""
+}
+
+// parseCSVList reads CSV with a header; printing attributes of the rows are preferred, not required
+func parseCSVList(data []byte) (NamesRequest, error) {
+	list := newCardList()
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte(utf8BOM))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return list.req, fmt.Errorf("Could not read CSV: %s", err)
+	}
+	if len(rows) == 0 {
+		return list.req, nil
+	}
+	columns, err := csvFieldColumns(rows[0])
+	if err != nil {
+		return list.req, err
+	}
+	field := func(row []string, column string) string {
+		i, found := columns[column]
+		if !found || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	for n, row := range rows[1:] {
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		// custom categories of Archidekt like 'Ramp' are parts of the deck, maybeboard is not
+		if s := strings.ToLower(field(row, "section")); strings.Contains(s, "maybe") || strings.Contains(s, "considering") {
+			continue
+		}
+
+		quantity, err := strconv.Atoi(field(row, "quantity"))
+		if err != nil {
+			return list.req, fmt.Errorf("Illegal quantity in CSV line %d: %q", n+2, field(row, "quantity"))
+		}
+
+		var printing CardFilter
+		if set := strings.ToUpper(field(row, "set")); setCodeRe.MatchString(set) {
+			printing.Sets = []string{set}
+		}
+		printing.MinCondition = ParseCondition(field(row, "condition"))
+		if lang := languageCode(field(row, "language")); lang != "" {
+			printing.Languages = []string{lang}
+		}
+		switch strings.ToLower(field(row, "foil")) {
+		case "foil", "etched", "true", "yes":
+			printing.Foil = OnlyFoil
+		}
+
+		if err := list.add(field(row, "name"), quantity, CardRequirements{Preferred: printing}); err != nil {
+			return list.req, err
+		}
+	}
+	return list.req, nil
+}
@@ -0,0 +1,134 @@
+package mtgbulk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTextFormats(t *testing.T) {
+	cases := []struct {
+		name   string
+		text   string
+		format DeckFormat
+		cards  map[string]int
+	}{
+		{
+			name:   "plain with duplicates",
+			text:   "4x Opt\n2 Shock [EN]\nOpt\n",
+			format: TextList,
+			cards:  map[string]int{"Opt": 5, "Shock": 2},
+		},
+		{
+			name: "arena",
+			text: "About\nName Izzet Tempo\n\nCommander\n1 Niv-Mizzet, Parun (GRN) 192\n\nDeck\n4 Opt (XLN) 65\n" +
+				"2 Shock (M19) 156 *F*\n\nSideboard\n2 Negate (RIX) 44\n\nMaybeboard\n1 Counterspell (MH2) 267\n",
+			format: TextList,
+			cards:  map[string]int{"Niv-Mizzet, Parun": 1, "Opt": 4, "Shock": 2, "Negate": 2},
+		},
+		{
+			name:   "duplicates differing in case",
+			text:   "1 Sol Ring\n1 sol ring\n2 SOL RING\n",
+			format: TextList,
+			cards:  map[string]int{"Sol Ring": 4},
+		},
+		{
+			name:   "mtgo text",
+			text:   "4 Opt\n2 Shock\n\nSB: 2 Negate\n2 Opt\n",
+			format: TextList,
+			cards:  map[string]int{"Opt": 6, "Shock": 2, "Negate": 2},
+		},
+		{
+			name: "mtgo dek",
+			text: `<?xml version="1.0" encoding="utf-8"?>
+<Deck xmlns:xsd="http://www.w3.org/2001/XMLSchema">
+  <NetDeckID>0</NetDeckID>
+  <Cards CatID="69982" Quantity="4" Sideboard="false" Name="Opt" Annotation="0" />
+  <Cards CatID="68069" Quantity="2" Sideboard="false" Name="Shock" Annotation="0" />
+  <Cards CatID="67118" Quantity="2" Sideboard="true" Name="Negate" Annotation="0" />
+</Deck>`,
+			format: MTGODeck,
+			cards:  map[string]int{"Opt": 4, "Shock": 2, "Negate": 2},
+		},
+		{
+			name: "moxfield csv",
+			text: "\uFEFF\"Count\",\"Tradelist Count\",\"Name\",\"Edition\",\"Condition\",\"Language\",\"Foil\",\"Tags\"\n" +
+				"\"4\",\"0\",\"Opt\",\"xln\",\"Near Mint\",\"English\",\"\",\"\"\n" +
+				"\"1\",\"0\",\"Fire // Ice\",\"mh2\",\"Lightly Played\",\"Russian\",\"foil\",\"\"\n",
+			format: CSVList,
+			cards:  map[string]int{"Opt": 4, "Fire // Ice": 1},
+		},
+		{
+			name: "archidekt csv",
+			text: "Quantity,Name,Finish,Condition,Language,Edition Name,Edition Code,Categories\n" +
+				"1,\"Niv-Mizzet, Parun\",Normal,NM,EN,Guilds of Ravnica,grn,Commander\n" +
+				"4,Opt,Normal,NM,EN,Ixalan,xln,\"Draw,Cantrip\"\n" +
+				"1,Counterspell,Normal,NM,EN,Modern Horizons 2,mh2,Maybeboard\n",
+			format: CSVList,
+			cards:  map[string]int{"Niv-Mizzet, Parun": 1, "Opt": 4},
+		},
+		{
+			name: "csv with quoted header",
+			text: "\"Notes, \"\"private\"\"\",Quantity,Name\n" +
+				"\"to trade, maybe\",3,Opt\n",
+			format: CSVList,
+			cards:  map[string]int{"Opt": 3},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if f := DetectFormat([]byte(c.text)); f != c.format {
+				t.Errorf("expected format %s, got %s", c.format, f)
+			}
+			req, err := ParseText(strings.NewReader(c.text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(req.Cards, c.cards) {
+				t.Errorf("expected %v, got %v", c.cards, req.Cards)
+			}
+		})
+	}
+}
+
+func TestParseTextPrinting(t *testing.T) {
+	req, err := ParseText(strings.NewReader("2 Shock (M19) 156 *F*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := CardFilter{Sets: []string{"M19"}, Foil: OnlyFoil}
+	if got := req.Requirements["Shock"]; !reflect.DeepEqual(got.Preferred, expected) || !got.Required.empty() {
+		t.Errorf("expected preferred %+v, got %+v", expected, got)
+	}
+
+	req, err = ParseText(strings.NewReader("Count,Name,Edition,Condition,Language,Foil\n1,Fire // Ice,mh2,Lightly Played,Russian,foil\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = CardFilter{Sets: []string{"MH2"}, Languages: []string{"RU"}, MinCondition: SlightlyPlayed, Foil: OnlyFoil}
+	if got := req.Requirements["Fire // Ice"]; !reflect.DeepEqual(got.Preferred, expected) {
+		t.Errorf("expected preferred %+v, got %+v", expected, got.Preferred)
+	}
+}
+
+func TestParseTextDuplicateRequirements(t *testing.T) {
+	if _, err := ParseText(strings.NewReader("4 Opt [EN]\n2 Opt [RU]\n")); err == nil {
+		t.Error("expected error for different required filters of a card")
+	}
+	if _, err := ParseText(strings.NewReader("4 Opt\n2 Opt [RU]\n")); err == nil {
+		t.Error("expected error for required filters of a part of a card")
+	}
+
+	req, err := ParseText(strings.NewReader("2 Opt [EN, NM+]\n1 Opt [NM en]\n1 Shock (XLN) 65\n1 Shock (M19) 156 *F*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]CardRequirements{
+		"Opt":   {Required: CardFilter{Languages: []string{"EN"}, MinCondition: NearMint}},
+		"Shock": {Preferred: CardFilter{Sets: []string{"XLN", "M19"}}},
+	}
+	if req.Cards["Opt"] != 3 || req.Cards["Shock"] != 2 || !reflect.DeepEqual(req.Requirements, expected) {
+		t.Errorf("expected 3 Opt and 2 Shock with %+v, got %v with %+v", expected, req.Cards, req.Requirements)
+	}
+}
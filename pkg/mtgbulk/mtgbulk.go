@@ -1,83 +1,48 @@
 package mtgbulk
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
+	"time"
 )
 
-// TODO: remove this ugly hack
-var cardLib Library
-var libOnce sync.Once
-
 type NamesRequest struct {
 	Cards map[string]int
+	// Requirements restrict offers of the cards, see CardRequirements
+	Requirements map[string]CardRequirements
 
+	// DeliveryFee is paid to every seller which has no Terms
 	DeliveryFee int
-	onlySingles *bool
-}
-
-func (req *NamesRequest) hasOnlySingles() bool {
-	if req.onlySingles != nil {
-		return *req.onlySingles
-	}
-
-	onlySingles := true
-	for _, n := range req.Cards {
-		if n > 1 {
-			onlySingles = false
-			break
-		}
-	}
-	req.onlySingles = &onlySingles
-	return *req.onlySingles
+	// Terms of sellers keyed by platform name or by full name of a trader like 'trader@MtgTrade'
+	Terms map[string]SellerTerms
+	// Pickup allows picking orders up at sellers which offer it instead of paying for delivery
+	Pickup bool
+	// Sellers lists names of sellers to search at; all registered sellers are used if empty
+	Sellers []string
+	// DisabledSellers are not searched even if listed in Sellers
+	DisabledSellers []string
+	// Progress is called after every card search at a seller; calls are never concurrent
+	Progress func(Progress)
+	// Cache keeps search results between requests; nothing is cached if nil
+	Cache PriceCache
+	// ForceRefresh searches at sellers even if there are cached results
+	ForceRefresh bool
 }
 
 func NewNamesRequest() NamesRequest {
 	return NamesRequest{
-		Cards: make(map[string]int),
+		Cards:        make(map[string]int),
+		Requirements: make(map[string]CardRequirements),
 	}
 }
 
-type PlatformType int
-
-const (
-	MtgSale      PlatformType = iota
-	MtgTrade     PlatformType = iota
-	SpellMarket  PlatformType = iota
-	AutumnsMagic PlatformType = iota
-	TopDeck      PlatformType = iota
-)
-
-var shops = map[PlatformType]bool{
-	MtgSale:      true,
-	SpellMarket:  true,
-	AutumnsMagic: true,
-}
-
-func (pt PlatformType) String() string {
-	switch pt {
-	case MtgSale:
-		return "MtgSale"
-	case MtgTrade:
-		return "MtgTrade"
-	case SpellMarket:
-		return "SpellMarket"
-	case AutumnsMagic:
-		return "AutumsMagic"
-	case TopDeck:
-		return "TopDeck"
-	}
-	return ""
-}
-
 type CurrencyType int
 
 const (
@@ -100,22 +65,57 @@ func (c CurrencyType) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.String())
 }
 
+func (c *CurrencyType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, known := range []CurrencyType{RUR, USD} {
+		if known.String() == s {
+			*c = known
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown currency %q", s)
+}
+
 type CardPrice struct {
 	Price    float32
 	Foil     bool
 	Currency CurrencyType
 	Quantity int
 
-	Platform PlatformType
+	// attributes are empty if the seller does not tell them
+	Condition Condition
+	Language  string // code like 'EN'
+	Set       string // set code like 'M10'
+
+	Platform string // name of the seller
 	Trader   string
 	URL      string
+
+	FetchedAt time.Time // when the offer has been scraped; older than now if taken from cache
 }
 
 func (cp *CardPrice) SellerFullName() string {
-	if shops[cp.Platform] {
+	if s, found := SellerByName(cp.Platform); found && s.Kind() == Shop {
 		return cp.Trader
 	}
-	return cp.Trader + "@" + cp.Platform.String()
+	return cp.Trader + "@" + cp.Platform
+}
+
+// Details describes condition, language, set and foil of the offer if known, like 'NM EN M10 foil'
+func (cp *CardPrice) Details() string {
+	details := make([]string, 0, 4)
+	for _, d := range []string{cp.Condition.String(), cp.Language, cp.Set} {
+		if d != "" {
+			details = append(details, d)
+		}
+	}
+	if cp.Foil {
+		details = append(details, "foil")
+	}
+	return strings.Join(details, " ")
 }
 
 type CardResult struct {
@@ -146,51 +146,81 @@ func (c *CardResult) sortByPrice() {
 type NamesResult struct {
 	AllSortedCards    map[string]CardResult
 	NotAvailableCards []string
+	// Failures lists searches which have failed or timed out; offers of other sellers are still in the result
+	Failures []SearchFailure
+	// CachedSearches counts card searches at sellers answered from cache
+	CachedSearches int
+	// Resolved maps names from the request to known names they have been corrected to
+	Resolved map[string]string
+	// UnknownCards could not be resolved and have not been searched
+	UnknownCards []UnknownCardError
+
+	MinPricesNoDelivery map[string][]CardPrice
+	// WithDelivery is the cheapest purchase taking delivery fee of every seller into account
+	WithDelivery    *PurchasePlan
+	MinPricesMatrix *PossessionMatrix
+}
 
-	MinPricesNoDelivery          map[string][]CardPrice
-	WithDeliveryByEliminateFewer map[string]CardPrice
-	MinPricesMatrix              *PossessionMatrix
+// OldestFetch returns time when the oldest offer in the result has been scraped; zero if there are no offers
+func (r *NamesResult) OldestFetch() time.Time {
+	var oldest time.Time
+	for _, res := range r.AllSortedCards {
+		for _, p := range res.Prices {
+			if oldest.IsZero() || p.FetchedAt.Before(oldest) {
+				oldest = p.FetchedAt
+			}
+		}
+	}
+	return oldest
 }
 
-func ProcessByNames(req NamesRequest) (*NamesResult, error) {
+// ProcessByNames resolves the card names in the library and searches the cards at the sellers concurrently;
+// cancelling the context stops the search
+func ProcessByNames(ctx context.Context, lib Library, req NamesRequest) (*NamesResult, error) {
 	logger.Debugw("Incoming ProcessByNames request",
 		"count", len(req.Cards))
 
 	result := &NamesResult{
 		AllSortedCards: make(map[string]CardResult, len(req.Cards)),
+		Resolved:       make(map[string]string),
 	}
 
-	// TODO: remove this ugly hack
-	libOnce.Do(func() {
-		dumpPath := "./scryfall.all.dump"
-		var err error
-		cardLib, err = NewInMemoryLibrary(dumpPath)
-		if err != nil {
-			panic(err)
-		}
-	})
+	sellers, err := selectSellers(req.Sellers, req.DisabledSellers)
+	if err != nil {
+		logger.Errorw("could not select sellers",
+			"err", err)
+		return result, err
+	}
 
+	req, err = resolveNames(lib, req, result)
+	if err != nil {
+		return result, err
+	}
+
+	queries := make([]CardQuery, 0, len(req.Cards))
 	for name := range req.Cards {
-		allNames, err := cardLib.CardAliases(name)
+		allNames, err := lib.CardAliases(name)
 		if err != nil {
 			logger.Errorw("could not get all names for card, is it missing?",
 				"err", err)
 			return result, err
 		}
 
-		englishName, err := cardLib.EnglishName(name)
+		englishName, err := lib.EnglishName(name)
 		if err != nil {
 			logger.Errorw("could not get english name for card, is it missing?",
 				"err", err)
 			return result, err
 		}
 
-		cardRes := newCardResult()
-		cardRes.merge(searchMtgSale(name))
-		cardRes.merge(searchMtgTrade(name))
-		cardRes.merge(searchSpellMarket(name, allNames))
-		cardRes.merge(searchAutumnsMagic(englishName, allNames))
-		cardRes.merge(searchTopDeck(name))
+		queries = append(queries, CardQuery{Name: name, EnglishName: englishName, Aliases: allNames})
+	}
+
+	found, failures, cached := searchAll(ctx, queries, sellers, req)
+	result.Failures = failures
+	result.CachedSearches = cached
+	for name, cardRes := range found {
+		cardRes = req.Requirements[name].apply(cardRes, req.Cards[name])
 		cardRes.sortByPrice()
 		if cardRes.Available {
 			result.AllSortedCards[name] = cardRes
@@ -198,6 +228,7 @@ func ProcessByNames(req NamesRequest) (*NamesResult, error) {
 			result.NotAvailableCards = append(result.NotAvailableCards, name)
 		}
 	}
+	sort.Strings(result.NotAvailableCards)
 
 	greedyMinPrices, err := calcGreedyMinPrices(req, result.AllSortedCards)
 	if err != nil {
@@ -207,101 +238,144 @@ func ProcessByNames(req NamesRequest) (*NamesResult, error) {
 	}
 	result.MinPricesNoDelivery = greedyMinPrices
 
-	result.MinPricesMatrix = fillMinPricesMatrix(result.AllSortedCards)
+	result.MinPricesMatrix = fillMinPricesMatrix(req, result.AllSortedCards)
 
-	if req.DeliveryFee > 0 && req.hasOnlySingles() {
-		eliminateFewer, err := evaluateConsideringDelivery(req, result.AllSortedCards, greedyMinPrices)
+	result.WithDelivery = optimizePurchase(req, result.AllSortedCards)
+
+	if err := ctx.Err(); err != nil {
+		// offers found before the interruption are still analyzed and returned
+		logger.Errorw("search has been interrupted",
+			"err", err,
+			"failures", len(failures))
+		return result, err
+	}
+	return result, nil
+}
+
+// resolveNames replaces names of the request with the known ones; unknown cards are left out of the returned request
+func resolveNames(lib Library, req NamesRequest, result *NamesResult) (NamesRequest, error) {
+	resolved := req
+	resolved.Cards = make(map[string]int, len(req.Cards))
+	resolved.Requirements = make(map[string]CardRequirements, len(req.Requirements))
+	for name, quantity := range req.Cards {
+		known, err := lib.Resolve(name)
+		var unknown *UnknownCardError
+		if errors.As(err, &unknown) {
+			logger.Warnw("unknown card",
+				"name", name,
+				"suggestions", unknown.Suggestions)
+			result.UnknownCards = append(result.UnknownCards, *unknown)
+			continue
+		}
 		if err != nil {
-			logger.Errorw("could not calculate min prices with delivery",
+			logger.Errorw("could not resolve card name",
+				"name", name,
 				"err", err)
-			return result, err
+			return resolved, err
+		}
+		if !strings.EqualFold(known, name) {
+			result.Resolved[name] = known
 		}
-		result.WithDeliveryByEliminateFewer = eliminateFewer
+		requirements := req.Requirements[name]
+		if _, found := resolved.Cards[known]; found {
+			merged, ok := resolved.Requirements[known].merge(requirements)
+			if !ok {
+				return resolved, fmt.Errorf("Card %q is listed under several names with different requirements, list it once", known)
+			}
+			requirements = merged
+		}
+		resolved.Requirements[known] = requirements
+		resolved.Cards[known] += quantity
 	}
+	sort.Slice(result.UnknownCards, func(i, j int) bool {
+		return result.UnknownCards[i].Name < result.UnknownCards[j].Name
+	})
 
-	return result, nil
+	if len(resolved.Cards) == 0 {
+		return resolved, fmt.Errorf("None of the cards is known")
+	}
+	return resolved, nil
 }
 
 var quantityRe *regexp.Regexp = regexp.MustCompile("^(\\d+)x?\\s*(.*)$")
+var requirementsRe *regexp.Regexp = regexp.MustCompile(`^(.*?)\s*\[([^\]]*)\]$`)
 
-func parseLine(line string) (string, int, error) {
+func parseLine(line string) (string, int, CardRequirements, error) {
 	quantity := 1
 	cardname := line
+	var requirements CardRequirements
 	var err error
+	if m := requirementsRe.FindStringSubmatch(line); m != nil {
+		line = m[1]
+		cardname = line
+		requirements, err = parseRequirements(m[2])
+		if err != nil {
+			return "", 0, requirements, err
+		}
+	}
 	if quantityRe.MatchString(line) {
 		matches := quantityRe.FindAllStringSubmatch(line, -1)
 
 		quantity, err = strconv.Atoi(matches[0][1])
 		if err != nil {
-			return "", 0, err
+			return "", 0, requirements, err
 		}
 		cardname = matches[0][2]
 		if len(cardname) == 0 {
-			return "", 0, fmt.Errorf("empty cardname")
+			return "", 0, requirements, fmt.Errorf("empty cardname")
 		}
 	}
 
-	return cardname, quantity, nil
+	return cardname, quantity, requirements, nil
 }
 
-func ProcessText(r io.Reader) (*NamesResult, error) {
-	cards := NewNamesRequest()
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		line := scanner.Text()
-		logger.Debugw("New line read from body",
-			"line", line)
-		line = strings.Trim(line, " ")
-		if len(line) == 0 {
-			continue
-		}
-		name, quantity, err := parseLine(line)
-		if err != nil {
-			logger.Warnw("could not parse line",
-				"err", err,
-				"line", line)
-			return nil, err
-		}
-
-		logger.Debugw("Parsed line",
-			"line", line,
-			"cardname", name,
-			"quantity", quantity)
+func ProcessText(ctx context.Context, lib Library, r io.Reader) (*NamesResult, error) {
+	cards, err := ParseText(r)
+	if err != nil {
+		return nil, err
+	}
 
-		if _, found := cards.Cards[name]; found {
-			logger.Warnw("Duplicated card",
-				"name", name)
-			return nil, fmt.Errorf("Card with name %q is duplicated in the list", name)
-		}
+	result, err := ProcessByNames(ctx, lib, cards)
+	if err != nil {
+		logger.Warnw("Could not process request",
+			"err", err)
+		return nil, err
+	}
 
-		if quantity <= 0 {
-			logger.Warnw("Illegal requested quantity",
-				"name", name,
-				"quantity", quantity)
-			return nil, fmt.Errorf("Illegal quantity for card %q has been requested: %d", name, quantity)
-		}
+	return result, nil
+}
 
-		cards.Cards[name] = quantity
-	}
-	if err := scanner.Err(); err != nil {
+// ParseText reads a card list detecting its format, see DeckFormat; duplicated cards are merged
+func ParseText(r io.Reader) (NamesRequest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
 		logger.Warnw("Error reading body",
 			"err", err)
-		return nil, err
+		return NewNamesRequest(), err
 	}
 
-	if len(cards.Cards) == 0 {
-		logger.Warnw("Empty card list")
-		return nil, fmt.Errorf("Empty card list")
+	format := DetectFormat(data)
+	logger.Debugw("Card list format detected",
+		"format", format)
+	var cards NamesRequest
+	switch format {
+	case MTGODeck:
+		cards, err = parseMTGODeck(data)
+	case CSVList:
+		cards, err = parseCSVList(data)
+	default:
+		cards, err = parseTextList(data)
 	}
-
-	result, err := ProcessByNames(cards)
 	if err != nil {
-		logger.Warnw("Could not process request",
-			"err", err)
-		return nil, err
+		return cards, err
 	}
 
-	return result, nil
+	if len(cards.Cards) == 0 {
+		logger.Warnw("Empty card list")
+		return cards, fmt.Errorf("Empty card list")
+	}
+
+	return cards, nil
 }
 
 func calcGreedyMinPrices(req NamesRequest, cards map[string]CardResult) (map[string][]CardPrice, error) {
@@ -338,147 +412,14 @@ func calcGreedyMinPrices(req NamesRequest, cards map[string]CardResult) (map[str
 	return result, nil
 }
 
-func fillMinPricesMatrix(cards map[string]CardResult) *PossessionMatrix {
+func fillMinPricesMatrix(req NamesRequest, cards map[string]CardResult) *PossessionMatrix {
 	m := NewPossessionMatrix()
+	m.Pickup = req.Pickup
 	for c, res := range cards {
 		for _, p := range res.Prices {
 			m.AddCard(p.SellerFullName(), c, int(p.Price))
+			m.Terms[p.SellerFullName()] = req.termsFor(p)
 		}
 	}
 	return m
 }
-
-func evaluateConsideringDelivery(req NamesRequest, cards map[string]CardResult, minPrices map[string][]CardPrice) (map[string]CardPrice, error) {
-	sellerCards := make(map[string]map[string]bool) // trader -> cardnames -> true
-	cardSellers := make(map[string]map[string]bool) // cardname -> traders -> true
-	cardSellerMinPrice := make(map[sellerCardPair]float32)
-	sellersWithUniqueCards := make(map[string][]string) // trader -> cardnames
-	for cardname, res := range cards {
-		if !res.Available {
-			logger.Debugw("delivery calc card not available",
-				"card", cardname)
-		}
-
-		cardSellers[cardname] = make(map[string]bool)
-
-		sellers := make(map[string]struct{})
-		for _, cardprice := range res.Prices {
-			trader := cardprice.Trader
-			cardSellers[cardname][trader] = true
-			if _, found := sellerCards[trader]; !found {
-				sellerCards[trader] = make(map[string]bool, len(cards))
-			}
-			sellerCards[trader][cardname] = true
-			sellers[trader] = struct{}{}
-
-			pair := sellerCardPair{seller: trader, cardname: cardname}
-			price := cardSellerMinPrice[pair]
-			if price == 0 || price > cardprice.Price {
-				cardSellerMinPrice[pair] = cardprice.Price
-				logger.Debugw("card seller new min price",
-					"card", cardname,
-					"seller", trader,
-					"price", cardprice.Price)
-			}
-		}
-
-		if len(sellers) == 1 {
-			uniqueSeller := ""
-			for s := range sellers {
-				uniqueSeller = s
-			}
-			sellersWithUniqueCards[uniqueSeller] = append(sellersWithUniqueCards[uniqueSeller], cardname)
-			logger.Debugw("trader uniquely sells a card",
-				"trader", uniqueSeller,
-				"card", cardname)
-		}
-	}
-	logger.Debugw("stats collected",
-		"totalSellers", len(sellerCards),
-		"totalUniqueSellers", len(sellersWithUniqueCards))
-
-	evalData := deliveryEvalData{
-		deliveryFee:        req.DeliveryFee,
-		cards:              cards,
-		sellerCards:        sellerCards,
-		cardSellers:        cardSellers,
-		sellerCardMinPrice: cardSellerMinPrice,
-	}
-	evaluateDeliveryViaPermutation(evalData)
-
-	return nil, nil
-}
-
-type sellerCardPair struct {
-	seller, cardname string
-}
-
-type deliveryEvalData struct {
-	deliveryFee              int
-	cards                    map[string]CardResult
-	sellerCards, cardSellers map[string]map[string]bool
-	sellerCardMinPrice       map[sellerCardPair]float32
-}
-
-func evaluateDeliveryViaPermutation(data deliveryEvalData) (int, []sellerCardPair) {
-	cost, result := iteratePermutation(map[string]bool{}, []sellerCardPair{}, data)
-	logger.Debugw("permutation best result",
-		"cost", cost)
-	return cost, result
-}
-
-func iteratePermutation(cardsPicked map[string]bool, resultSet []sellerCardPair, data deliveryEvalData) (int, []sellerCardPair) {
-	if len(cardsPicked) == len(data.cards) {
-		cost := 0
-		sellersMet := make(map[string]bool)
-		for _, cs := range resultSet {
-			cost += int(data.sellerCardMinPrice[cs])
-			if !sellersMet[cs.seller] {
-				sellersMet[cs.seller] = true
-				cost += data.deliveryFee
-			}
-		}
-		logger.Debugw("permutation end",
-			"cost", cost)
-		return cost, resultSet
-	}
-
-	bestCost := math.MaxInt32
-	var bestResult []sellerCardPair
-	for card := range data.cards {
-		if cardsPicked[card] {
-			logger.Debugw("card already picked",
-				"card", card,
-				"picked", len(cardsPicked))
-			continue
-		}
-		cardsPicked[card] = true
-		logger.Debugw("card picked",
-			"card", card,
-			"picked", len(cardsPicked))
-		for seller := range data.cardSellers[card] {
-			logger.Debugw("seller picked",
-				"card", card,
-				"picked", len(cardsPicked),
-				"seller", seller)
-			resultSet = append(resultSet, sellerCardPair{seller: seller, cardname: card})
-			price, rs := iteratePermutation(cardsPicked, resultSet, data)
-			if price < bestCost {
-				logger.Debugw("permutation better result",
-					"cost", price,
-					"cards picked", len(cardsPicked),
-					"card", card,
-					"seller", seller)
-				bestCost = price
-				bestResult = rs
-			}
-			resultSet = resultSet[0 : len(resultSet)-1]
-		}
-		delete(cardsPicked, card)
-	}
-
-	logger.Debugw("permutation exhausted",
-		"cost", bestCost,
-		"picked", len(cardsPicked))
-	return bestCost, bestResult
-}
@@ -1,21 +1,40 @@
 package mtgbulk
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gocolly/colly"
 )
 
-func searchMtgSale(cardname string) CardResult {
+const mtgSaleName = "MtgSale"
+
+type mtgSaleSeller struct{}
+
+func init() {
+	RegisterSeller(mtgSaleSeller{})
+}
+
+func (mtgSaleSeller) Name() string {
+	return mtgSaleName
+}
+
+func (mtgSaleSeller) Kind() SellerKind {
+	return Shop
+}
+
+func (mtgSaleSeller) Search(ctx context.Context, q CardQuery) (CardResult, error) {
+	return searchMtgSale(ctx, q.Name)
+}
+
+func searchMtgSale(ctx context.Context, cardname string) (CardResult, error) {
 	result := newCardResult()
 	addr := mtgSaleSearchURL(cardname)
 
-	c := colly.NewCollector()
-	c.SetRequestTimeout(20 * time.Second)
+	c := newCollector(ctx)
 	c.OnHTML(".ctclass", func(e *colly.HTMLElement) {
 		name1 := strings.ToLower(e.ChildText(".tnamec"))
 		name2 := strings.ToLower(e.ChildText(".smallfont"))
@@ -54,13 +73,16 @@ func searchMtgSale(cardname string) CardResult {
 			if countVal > 0 {
 				result.Available = true
 				result.Prices = append(result.Prices, CardPrice{
-					Price:    float32(pVal),
-					Foil:     foil,
-					Currency: RUR,
-					Quantity: countVal,
-					Platform: MtgSale,
-					Trader:   "mtgsale",
-					URL:      addr, // TODO: correct it! - there's a direct link to a card instead of a search
+					Price:     float32(pVal),
+					Foil:      foil,
+					Currency:  RUR,
+					Quantity:  countVal,
+					Condition: ParseCondition(e.ChildText(".sost")),
+					Language:  languageCode(e.ChildText(".lang")),
+					Set:       setFromIcon(e.ChildAttr(".nabor i", "class")),
+					Platform:  mtgSaleName,
+					Trader:    "mtgsale",
+					URL:       addr, // TODO: correct it! - there's a direct link to a card instead of a search
 				})
 			}
 		}
@@ -72,7 +94,7 @@ func searchMtgSale(cardname string) CardResult {
 			"url", addr,
 			"err", err)
 	}
-	return result
+	return result, err
 }
 
 func mtgSaleSearchURL(cardname string) string {
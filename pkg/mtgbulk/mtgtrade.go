@@ -1,26 +1,41 @@
 package mtgbulk
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gocolly/colly"
 )
 
-func searchMtgTrade(cardname string) CardResult {
+const mtgTradeName = "MtgTrade"
+
+type mtgTradeSeller struct{}
+
+func init() {
+	RegisterSeller(mtgTradeSeller{})
+}
+
+func (mtgTradeSeller) Name() string {
+	return mtgTradeName
+}
+
+func (mtgTradeSeller) Kind() SellerKind {
+	return Marketplace
+}
+
+func (mtgTradeSeller) Search(ctx context.Context, q CardQuery) (CardResult, error) {
+	return searchMtgTrade(ctx, q.Name)
+}
+
+func searchMtgTrade(ctx context.Context, cardname string) (CardResult, error) {
 	cardname = strings.ToLower(cardname)
 	result := newCardResult()
 	addr := mtgTradeSearchURL(cardname)
 
 	visitedPages := make(map[string]bool)
-	c := colly.NewCollector()
-	defCopy := *(http.DefaultTransport.(*http.Transport))
-	var tr *http.Transport = &defCopy
-	tr.ResponseHeaderTimeout = 20 * time.Second
-	c.WithTransport(tr)
+	c := newCollector(ctx)
 	c.OnHTML(".search-item", func(e *colly.HTMLElement) {
 		nameEn := strings.ToLower(e.ChildText(".catalog-title"))
 		if nameEn != cardname {
@@ -66,23 +81,31 @@ func searchMtgTrade(cardname string) CardResult {
 					foil = true
 				}
 
+				quality := eTR.ChildText(".js-card-quality-tooltip")
+				lang := eTR.ChildText(".js-card-lang-tooltip")
+				set := setFromIcon(eTR.ChildAttr("i.ss", "class"))
 				logger.Debugw("card",
 					"row_index", i,
 					"trader", trader,
 					"price", price,
 					"count", quantity,
 					"foil", foil,
-					"quality", eTR.ChildText(".js-card-quality-tooltip")) // TODO: to CardPrice
+					"quality", quality,
+					"lang", lang,
+					"set", set)
 
 				result.Available = true
 				result.Prices = append(result.Prices, CardPrice{
-					Price:    float32(price),
-					Foil:     foil,
-					Currency: RUR,
-					Quantity: quantity,
-					Platform: MtgTrade,
-					Trader:   trader,
-					URL:      addr, // TODO: correct it! - it's just a search result, but we can get a direct link to a card at a seller
+					Price:     float32(price),
+					Foil:      foil,
+					Currency:  RUR,
+					Quantity:  quantity,
+					Condition: ParseCondition(quality),
+					Language:  languageCode(lang),
+					Set:       set,
+					Platform:  mtgTradeName,
+					Trader:    trader,
+					URL:       addr, // TODO: correct it! - it's just a search result, but we can get a direct link to a card at a seller
 				})
 			})
 		})
@@ -114,7 +137,7 @@ func searchMtgTrade(cardname string) CardResult {
 			"url", addr,
 			"err", err)
 	}
-	return result
+	return result, err
 }
 
 func mtgTradeSearchURL(cardname string) string {
@@ -0,0 +1,353 @@
+package mtgbulk
+
+import (
+	"sort"
+
+	"github.com/tealeg/xlsx"
+)
+
+// exactSellersLimit is the maximal number of sellers for which every combination is evaluated;
+// local search is used for more sellers
+const exactSellersLimit = 12
+
+// PurchasePlan tells what to buy at which seller to get the cards as cheap as possible including delivery
+type PurchasePlan struct {
+	// Items lists offers to buy for every card; Quantity of an offer is the number of cards to take from it
+	Items map[string][]CardPrice
+	// Sellers lists sellers to order from, sorted by name
+	Sellers []string
+	// Missing maps card to quantity which cannot be bought at any seller
+	Missing map[string]int
+	// Delivery maps seller to the price of delivering its part of the order
+	Delivery map[string]float32
+	// BelowMinOrder maps seller to the sum lacking up to its minimal order
+	BelowMinOrder map[string]float32
+
+	CardsCost    float32
+	DeliveryCost float32
+	Total        float32
+}
+
+// sellerOffer is an offer of a card from the optimizer point of view
+type sellerOffer struct {
+	seller int
+	price  CardPrice
+}
+
+type optimizer struct {
+	cards   []string
+	need    []int
+	offers  [][]sellerOffer // by card, sorted by price
+	sellers []string
+	terms   []SellerTerms
+	pickup  bool
+}
+
+// planCost is the quality of a set of sellers; missing cards are worse than orders below minimum,
+// which are worse than any price
+type planCost struct {
+	missing  int
+	belowMin float32
+	total    float32
+}
+
+func (c planCost) less(other planCost) bool {
+	if c.missing != other.missing {
+		return c.missing < other.missing
+	}
+	if c.belowMin != other.belowMin {
+		return c.belowMin < other.belowMin
+	}
+	return c.total < other.total
+}
+
+func newOptimizer(req NamesRequest, cards map[string]CardResult) *optimizer {
+	o := &optimizer{pickup: req.Pickup}
+	sellerIdx := make(map[string]int)
+	for name := range req.Cards {
+		o.cards = append(o.cards, name)
+	}
+	sort.Strings(o.cards)
+	o.need = make([]int, len(o.cards))
+	for i, name := range o.cards {
+		o.need[i] = req.Cards[name]
+	}
+
+	o.offers = make([][]sellerOffer, len(o.cards))
+	for i, name := range o.cards {
+		for _, p := range cards[name].Prices {
+			if p.Quantity <= 0 {
+				continue
+			}
+			seller := p.SellerFullName()
+			idx, found := sellerIdx[seller]
+			if !found {
+				idx = len(o.sellers)
+				sellerIdx[seller] = idx
+				o.sellers = append(o.sellers, seller)
+				o.terms = append(o.terms, req.termsFor(p))
+			}
+			o.offers[i] = append(o.offers[i], sellerOffer{seller: idx, price: p})
+		}
+		sort.SliceStable(o.offers[i], func(a, b int) bool {
+			return o.offers[i][a].price.Price < o.offers[i][b].price.Price
+		})
+	}
+	return o
+}
+
+// evaluate buys the cheapest offers at the opened sellers; delivery is paid only for sellers actually used
+func (o *optimizer) evaluate(open []bool) (planCost, []bool) {
+	var cost planCost
+	used := make([]bool, len(o.sellers))
+	sums := make([]float32, len(o.sellers))
+	for i, offers := range o.offers {
+		left := o.need[i]
+		for _, offer := range offers {
+			if left == 0 {
+				break
+			}
+			if !open[offer.seller] {
+				continue
+			}
+			take := min(left, offer.price.Quantity)
+			sums[offer.seller] += float32(take) * offer.price.Price
+			used[offer.seller] = true
+			left -= take
+		}
+		cost.missing += left
+	}
+	for s, u := range used {
+		if !u {
+			continue
+		}
+		cost.total += sums[s] + o.terms[s].Delivery(sums[s], o.pickup)
+		if !o.terms[s].Accepts(sums[s]) {
+			cost.belowMin += float32(o.terms[s].MinOrder) - sums[s]
+		}
+	}
+	return cost, used
+}
+
+// best finds the set of sellers with the lowest total cost
+func (o *optimizer) best() []bool {
+	if len(o.sellers) <= exactSellersLimit {
+		return o.exhaustive()
+	}
+	return o.localSearch()
+}
+
+func (o *optimizer) exhaustive() []bool {
+	n := len(o.sellers)
+	open := make([]bool, n)
+	bestOpen := make([]bool, n)
+	var bestCost planCost
+	for mask := 0; mask < 1<<n; mask++ {
+		for s := range open {
+			open[s] = mask&(1<<s) != 0
+		}
+		cost, _ := o.evaluate(open)
+		if mask == 0 || cost.less(bestCost) {
+			bestCost = cost
+			copy(bestOpen, open)
+		}
+	}
+	return bestOpen
+}
+
+// localSearch starts from every seller opened and from greedily added sellers,
+// then improves both by closing, opening and swapping sellers while it helps
+func (o *optimizer) localSearch() []bool {
+	all := make([]bool, len(o.sellers))
+	for s := range all {
+		all[s] = true
+	}
+	fromAll, allCost := o.improve(all)
+	fromGreedy, greedyCost := o.improve(o.greedy())
+	if greedyCost.less(allCost) {
+		return fromGreedy
+	}
+	return fromAll
+}
+
+// greedy opens sellers one by one, each time the one which lowers the cost most
+func (o *optimizer) greedy() []bool {
+	open := make([]bool, len(o.sellers))
+	cost, _ := o.evaluate(open)
+	for {
+		bestSeller := -1
+		bestCost := cost
+		for s := range open {
+			if open[s] {
+				continue
+			}
+			open[s] = true
+			if c, _ := o.evaluate(open); c.less(bestCost) {
+				bestSeller, bestCost = s, c
+			}
+			open[s] = false
+		}
+		if bestSeller < 0 {
+			return open
+		}
+		open[bestSeller] = true
+		cost = bestCost
+	}
+}
+
+func (o *optimizer) improve(start []bool) ([]bool, planCost) {
+	open := append([]bool(nil), start...)
+	cost, used := o.evaluate(open)
+	// sellers which are opened but not used only hide better moves
+	copy(open, used)
+
+	for {
+		bestCost := cost
+		var bestMove func()
+		try := func(move func(), undo func()) {
+			move()
+			if c, _ := o.evaluate(open); c.less(bestCost) {
+				bestCost = c
+				bestMove = move
+			}
+			undo()
+		}
+		for s := range open {
+			if open[s] {
+				try(func() { open[s] = false }, func() { open[s] = true })
+				for t := range open {
+					if open[t] {
+						continue
+					}
+					try(func() { open[s], open[t] = false, true }, func() { open[s], open[t] = true, false })
+				}
+			} else {
+				try(func() { open[s] = true }, func() { open[s] = false })
+			}
+		}
+		if bestMove == nil {
+			return open, cost
+		}
+		bestMove()
+		cost, used = o.evaluate(open)
+		copy(open, used)
+	}
+}
+
+// plan turns the set of sellers into the purchase plan
+func (o *optimizer) plan(open []bool) *PurchasePlan {
+	p := &PurchasePlan{
+		Items:         make(map[string][]CardPrice, len(o.cards)),
+		Missing:       make(map[string]int),
+		Delivery:      make(map[string]float32),
+		BelowMinOrder: make(map[string]float32),
+	}
+	used := make([]bool, len(o.sellers))
+	sums := make([]float32, len(o.sellers))
+	for i, name := range o.cards {
+		left := o.need[i]
+		for _, offer := range o.offers[i] {
+			if left == 0 {
+				break
+			}
+			if !open[offer.seller] {
+				continue
+			}
+			item := offer.price
+			item.Quantity = min(left, offer.price.Quantity)
+			p.Items[name] = append(p.Items[name], item)
+			p.CardsCost += float32(item.Quantity) * item.Price
+			sums[offer.seller] += float32(item.Quantity) * item.Price
+			used[offer.seller] = true
+			left -= item.Quantity
+		}
+		if left > 0 {
+			p.Missing[name] = left
+		}
+	}
+	for s, u := range used {
+		if !u {
+			continue
+		}
+		seller := o.sellers[s]
+		p.Sellers = append(p.Sellers, seller)
+		p.Delivery[seller] = o.terms[s].Delivery(sums[s], o.pickup)
+		p.DeliveryCost += p.Delivery[seller]
+		if !o.terms[s].Accepts(sums[s]) {
+			p.BelowMinOrder[seller] = float32(o.terms[s].MinOrder) - sums[s]
+		}
+	}
+	sort.Strings(p.Sellers)
+	p.Total = p.CardsCost + p.DeliveryCost
+	return p
+}
+
+// optimizePurchase chooses sellers minimizing cost of the cards plus delivery to every seller ordered from;
+// orders below minimum of a seller are avoided if possible
+func optimizePurchase(req NamesRequest, cards map[string]CardResult) *PurchasePlan {
+	o := newOptimizer(req, cards)
+	p := o.plan(o.best())
+	logger.Debugw("purchase plan",
+		"sellers", len(p.Sellers),
+		"candidates", len(o.sellers),
+		"cardsCost", p.CardsCost,
+		"deliveryCost", p.DeliveryCost,
+		"missing", len(p.Missing))
+	return p
+}
+
+// ToXlsxSheet writes the plan as a list of offers to buy followed by totals
+func (p *PurchasePlan) ToXlsxSheet(out *xlsx.Sheet) error {
+	for x, title := range []string{"CARD", "QTY", "PRICE", "SELLER", "DETAILS"} {
+		out.Cell(0, x).SetString(title)
+	}
+
+	cards := make([]string, 0, len(p.Items))
+	for card := range p.Items {
+		cards = append(cards, card)
+	}
+	sort.Strings(cards)
+
+	y := 1
+	for _, card := range cards {
+		for _, item := range p.Items[card] {
+			out.Cell(y, 0).SetString(card)
+			out.Cell(y, 1).SetInt(item.Quantity)
+			out.Cell(y, 2).SetFloat(float64(item.Price))
+			out.Cell(y, 3).SetString(item.SellerFullName())
+			out.Cell(y, 4).SetString(item.Details())
+			y++
+		}
+	}
+
+	missing := make([]string, 0, len(p.Missing))
+	for card := range p.Missing {
+		missing = append(missing, card)
+	}
+	sort.Strings(missing)
+	for _, card := range missing {
+		out.Cell(y, 0).SetString(card)
+		out.Cell(y, 1).SetInt(p.Missing[card])
+		out.Cell(y, 3).SetString("NOT AVAILABLE")
+		y++
+	}
+
+	y++
+	for _, total := range []struct {
+		title string
+		value float32
+	}{{"CARDS", p.CardsCost}, {"DELIVERY", p.DeliveryCost}, {"TOTAL", p.Total}} {
+		out.Cell(y, 0).SetString(total.title)
+		out.Cell(y, 2).SetFloat(float64(total.value))
+		y++
+	}
+	for _, seller := range p.Sellers {
+		if lack, found := p.BelowMinOrder[seller]; found {
+			out.Cell(y, 0).SetString("BELOW MIN ORDER")
+			out.Cell(y, 2).SetFloat(float64(lack))
+			out.Cell(y, 3).SetString(seller)
+			y++
+		}
+	}
+	return nil
+}
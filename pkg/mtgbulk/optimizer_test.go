@@ -0,0 +1,110 @@
+package mtgbulk
+
+import (
+	"fmt"
+	"testing"
+)
+
+func offer(seller string, price float32, qty int) CardPrice {
+	return CardPrice{Price: price, Quantity: qty, Platform: mtgTradeName, Trader: seller}
+}
+
+func cardResults(offers map[string][]CardPrice) map[string]CardResult {
+	res := make(map[string]CardResult, len(offers))
+	for card, prices := range offers {
+		res[card] = CardResult{Available: true, Prices: prices}
+	}
+	return res
+}
+
+func TestOptimizePurchase(t *testing.T) {
+	req := NewNamesRequest()
+	req.Cards["Opt"] = 4
+	req.Cards["Shock"] = 1
+	cards := cardResults(map[string][]CardPrice{
+		"Opt":   {offer("a", 10, 4), offer("c", 15, 2), offer("b", 20, 4)},
+		"Shock": {offer("b", 10, 1), offer("c", 12, 1)},
+	})
+
+	// without delivery the cheapest offers win even at different sellers
+	p := optimizePurchase(req, cards)
+	if p.Total != 50 || len(p.Sellers) != 2 {
+		t.Errorf("expected 50 at two sellers, got %+v", p)
+	}
+
+	// with delivery a single seller is cheaper even if one of the cards costs more
+	req.DeliveryFee = 100
+	p = optimizePurchase(req, cards)
+	if p.Total != 190 || p.DeliveryCost != 100 || len(p.Sellers) != 1 || p.Sellers[0] != "b@MtgTrade" {
+		t.Errorf("expected everything at b for 190, got %+v", p)
+	}
+
+	// no seller has six copies, so the cheapest pair of sellers is chosen
+	req.Cards["Opt"] = 6
+	p = optimizePurchase(req, cards)
+	if len(p.Missing) != 0 || p.Total != 282 {
+		t.Errorf("expected full purchase for 282, got %+v", p)
+	}
+
+	req.Cards["Opt"] = 11
+	p = optimizePurchase(req, cards)
+	if p.Missing["Opt"] != 1 {
+		t.Errorf("expected one missing Opt, got %+v", p.Missing)
+	}
+}
+
+func TestOptimizePurchaseManySellers(t *testing.T) {
+	req := NewNamesRequest()
+	offers := make(map[string][]CardPrice)
+	for c := 0; c < 10; c++ {
+		card := fmt.Sprintf("card%d", c)
+		req.Cards[card] = 1
+		for s := 0; s < 20; s++ {
+			// every small seller has the cheapest copy of one card, while the big one has all of them
+			price := float32(100)
+			if s == c {
+				price = 90
+			}
+			offers[card] = append(offers[card], offer(fmt.Sprintf("small%d", s), price, 1))
+		}
+		offers[card] = append(offers[card], offer("big", 95, 1))
+	}
+
+	req.DeliveryFee = 50
+	p := optimizePurchase(req, cardResults(offers))
+	if len(p.Sellers) != 1 || p.Sellers[0] != "big@MtgTrade" || p.Total != 1000 {
+		t.Errorf("expected everything at the big seller for 1000, got %v for %v", p.Sellers, p.Total)
+	}
+}
+
+func TestOptimizePurchaseTerms(t *testing.T) {
+	req := NewNamesRequest()
+	req.Cards["Opt"] = 1
+	req.Cards["Shock"] = 1
+	req.DeliveryFee = 100
+	cards := cardResults(map[string][]CardPrice{
+		"Opt":   {offer("a", 10, 1), offer("b", 50, 1)},
+		"Shock": {offer("b", 60, 1), offer("a", 65, 1)},
+	})
+
+	// b delivers for free from 100, which beats the cheaper cards at a
+	req.Terms = map[string]SellerTerms{"b@mtgtrade": {DeliveryFee: 100, FreeFrom: 100}}
+	p := optimizePurchase(req, cards)
+	if p.Total != 110 || p.DeliveryCost != 0 || len(p.Sellers) != 1 || p.Sellers[0] != "b@MtgTrade" {
+		t.Errorf("expected free delivery from b for 110, got %+v", p)
+	}
+
+	// terms of the platform apply to every trader, so neither accepts a single cheap card
+	req.Terms = map[string]SellerTerms{"MtgTrade": {DeliveryFee: 10, MinOrder: 70}}
+	p = optimizePurchase(req, cards)
+	if len(p.BelowMinOrder) != 0 || len(p.Sellers) != 1 || p.Total != 85 {
+		t.Errorf("expected a single order at a for 85, got %+v", p)
+	}
+
+	req.Terms = map[string]SellerTerms{"MtgTrade": {DeliveryFee: 100, Pickup: true}}
+	req.Pickup = true
+	p = optimizePurchase(req, cards)
+	if p.DeliveryCost != 0 || p.Total != 70 {
+		t.Errorf("expected pickup of the cheapest cards for 70, got %+v", p)
+	}
+}
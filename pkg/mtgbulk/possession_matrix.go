@@ -12,12 +12,16 @@ import (
 type PossessionMatrix struct {
 	SellerCards map[string]map[string]int
 	CardSellers map[string]map[string]int
+
+	Terms  map[string]SellerTerms // by seller as given by termsFor, so sellers without own terms pay NamesRequest.DeliveryFee
+	Pickup bool
 }
 
 func NewPossessionMatrix() *PossessionMatrix {
 	return &PossessionMatrix{
 		SellerCards: make(map[string]map[string]int),
 		CardSellers: make(map[string]map[string]int),
+		Terms:       make(map[string]SellerTerms),
 	}
 }
 
@@ -45,6 +49,8 @@ type PossessionTable struct {
 	Sellers, Cards                     []string
 	Prices                             [][]int
 	SellerCardsTotal, SellerPriceTotal []int
+	SellerDeliveryTotal                []int // delivery of all cards of the seller
+	SellerBelowMinOrder                []bool
 	CardSellersTotal                   []int
 	MinPrice, AvgPrice, MedianPrice    []int
 }
@@ -63,6 +69,8 @@ func NewPossessionTable(m *PossessionMatrix) *PossessionTable {
 	}
 	t.SellerCardsTotal = make([]int, sellersN)
 	t.SellerPriceTotal = make([]int, sellersN)
+	t.SellerDeliveryTotal = make([]int, sellersN)
+	t.SellerBelowMinOrder = make([]bool, sellersN)
 	t.CardSellersTotal = make([]int, cardsN)
 
 	for seller := range m.SellerCards {
@@ -93,6 +101,12 @@ func NewPossessionTable(m *PossessionMatrix) *PossessionTable {
 		}
 	}
 
+	for x, seller := range t.Sellers {
+		terms := m.Terms[seller]
+		t.SellerDeliveryTotal[x] = int(terms.Delivery(float32(t.SellerPriceTotal[x]), m.Pickup))
+		t.SellerBelowMinOrder[x] = !terms.Accepts(float32(t.SellerPriceTotal[x]))
+	}
+
 	for card, prices := range cardPrices {
 		sort.Ints(prices)
 		cardPrices[card] = prices
@@ -166,6 +180,24 @@ func (t *PossessionTable) ToTextTable(out io.Writer) error {
 		f2 = append(f2, c)
 	}
 	tOut.AppendFooter(f2)
+
+	f3 := make(table.Row, 0, len(t.Sellers)+2)
+	f3 = append(f3, "Delivery")
+	for _, d := range t.SellerDeliveryTotal {
+		f3 = append(f3, d)
+	}
+	tOut.AppendFooter(f3)
+
+	f4 := make(table.Row, 0, len(t.Sellers)+2)
+	f4 = append(f4, "Total with delivery")
+	for x, p := range t.SellerPriceTotal {
+		total := fmt.Sprint(p + t.SellerDeliveryTotal[x])
+		if t.SellerBelowMinOrder[x] {
+			total += " (below min order)"
+		}
+		f4 = append(f4, total)
+	}
+	tOut.AppendFooter(f4)
 	tOut.Render()
 
 	return nil
@@ -274,5 +306,25 @@ func (t *PossessionTable) ToXlsxSheet(out *xlsx.Sheet, minPrices map[string]int)
 		c.SetInt(totals)
 	}
 
+	yOffset += 1
+	c = out.Cell(yOffset+0, 0)
+	c.SetString("DELIVERY")
+	for x, delivery := range t.SellerDeliveryTotal {
+		c := out.Cell(0+yOffset, x+xOffset)
+		c.SetInt(delivery)
+	}
+
+	yOffset += 1
+	c = out.Cell(yOffset+0, 0)
+	c.SetString("TOTAL WITH DELIVERY")
+	for x := range t.SellerDeliveryTotal {
+		c := out.Cell(0+yOffset, x+xOffset)
+		col := xlsx.ColIndexToLetters(xOffset + x)
+		c.SetStringFormula(fmt.Sprintf("=%s%d+%s%d", col, yOffset-2, col, yOffset))
+		if t.SellerBelowMinOrder[x] {
+			c.SetStyle(noCardStyle)
+		}
+	}
+
 	return nil
 }
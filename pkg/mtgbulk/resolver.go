@@ -0,0 +1,178 @@
+package mtgbulk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ilyalavrinov/tgbots/pkg/tgbotutil"
+)
+
+// maxSuggestions limits 'did you mean' suggestions for an unknown card
+const maxSuggestions = 5
+
+// minPrefixLen is the shortest query which may be completed by prefix search
+const minPrefixLen = 4
+
+// UnknownCardError is returned for a card name which could not be resolved; Suggestions are the closest known names
+type UnknownCardError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownCardError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("Unknown card %q", e.Name)
+	}
+	return fmt.Sprintf("Unknown card %q, did you mean: %s?", e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+// nameResolver finds card names written with typos, in other letter case or punctuation,
+// transliterated or shortened to a prefix
+type nameResolver struct {
+	names     []string // as printed on cards
+	ids       []string // Oracle ID of every name
+	exact     map[string][]int
+	keys      []resolverKey         // sorted for prefix search
+	fuzzyKeys *tgbotutil.FuzzyIndex // key numbers are name numbers
+}
+
+type resolverKey struct {
+	key  string
+	len  int // in runes
+	name int
+}
+
+func newNameResolver() *nameResolver {
+	return &nameResolver{exact: make(map[string][]int), fuzzyKeys: tgbotutil.NewFuzzyIndex()}
+}
+
+// resolverNameKey makes Cyrillic names and their Latin transliterations equal
+func resolverNameKey(name string) string {
+	return tgbotutil.ToLatin(tgbotutil.NormalizeName(name))
+}
+
+func (r *nameResolver) add(name, oracleID string) {
+	key := resolverNameKey(name)
+	if key == "" {
+		return
+	}
+	for _, i := range r.exact[key] {
+		if r.ids[i] == oracleID {
+			return
+		}
+	}
+	i := len(r.names)
+	r.names = append(r.names, name)
+	r.ids = append(r.ids, oracleID)
+	r.exact[key] = append(r.exact[key], i)
+	r.keys = append(r.keys, resolverKey{key: key, len: len([]rune(key)), name: i})
+	r.fuzzyKeys.Add(key)
+}
+
+// index sorts keys; must be called after all names are added
+func (r *nameResolver) index() {
+	sort.Slice(r.keys, func(i, j int) bool {
+		return r.keys[i].key < r.keys[j].key
+	})
+}
+
+// candidate is a card close to the query
+type candidate struct {
+	name     int
+	distance int
+}
+
+// resolve returns the known name of the card if it is the only close one; a typo is allowed per 4 letters
+func (r *nameResolver) resolve(query string) (string, error) {
+	key := resolverNameKey(query)
+	if key == "" {
+		return "", &UnknownCardError{Name: query}
+	}
+	if found := r.exact[key]; len(found) > 0 {
+		return r.names[found[0]], nil
+	}
+
+	prefixed := r.byPrefix(key)
+	if len(prefixed) == 1 {
+		return r.names[prefixed[0].name], nil
+	}
+
+	keyLen := len([]rune(key))
+	fuzzy := r.fuzzy(key, keyLen/2)
+	if len(fuzzy) > 0 && fuzzy[0].distance <= keyLen/4 &&
+		(len(fuzzy) == 1 || fuzzy[1].distance > fuzzy[0].distance) {
+		return r.names[fuzzy[0].name], nil
+	}
+
+	suggestions := make([]string, 0, maxSuggestions)
+	for _, c := range append(prefixed, fuzzy...) {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		if name := r.names[c.name]; !contains(suggestions, name) {
+			suggestions = append(suggestions, name)
+		}
+	}
+	return "", &UnknownCardError{Name: query, Suggestions: suggestions}
+}
+
+// byPrefix returns a candidate per card having a name starting with the key, shortest names first
+func (r *nameResolver) byPrefix(key string) []candidate {
+	if len([]rune(key)) < minPrefixLen {
+		return nil
+	}
+	best := make(map[string]candidate)
+	from := sort.Search(len(r.keys), func(i int) bool {
+		return r.keys[i].key >= key
+	})
+	for _, k := range r.keys[from:] {
+		if !strings.HasPrefix(k.key, key) {
+			break
+		}
+		id := r.ids[k.name]
+		if c, found := best[id]; !found || k.len < c.distance {
+			best[id] = candidate{name: k.name, distance: k.len}
+		}
+	}
+	return sortedCandidates(best)
+}
+
+// fuzzy returns a candidate per card having a name not farther than maxDist from the key, closest first;
+// names sharing no trigram with the key are not suggested
+func (r *nameResolver) fuzzy(key string, maxDist int) []candidate {
+	if maxDist == 0 {
+		return nil
+	}
+	best := make(map[string]candidate)
+	r.fuzzyKeys.Search(key, maxDist, func(name, d int) {
+		id := r.ids[name]
+		if c, found := best[id]; !found || d < c.distance {
+			best[id] = candidate{name: name, distance: d}
+		}
+	})
+	return sortedCandidates(best)
+}
+
+func sortedCandidates(byID map[string]candidate) []candidate {
+	candidates := make([]candidate, 0, len(byID))
+	for _, c := range byID {
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	return candidates
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package mtgbulk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const libraryDump = `[
+{"oracle_id": "bolt", "name": "Lightning Bolt", "lang": "en"},
+{"oracle_id": "bolt", "name": "Lightning Bolt", "printed_name": "Молния", "lang": "ru"},
+{"oracle_id": "helix", "name": "Lightning Helix", "lang": "en"},
+{"oracle_id": "jace", "name": "Jace, the Mind Sculptor", "lang": "en"},
+{"oracle_id": "fireice", "name": "Fire // Ice", "lang": "en"},
+{"oracle_id": "opt", "name": "Opt", "lang": "en"},
+{"oracle_id": "ops", "name": "Ops", "lang": "en"}
+]`
+
+func testLibrary(t *testing.T) Library {
+	path := filepath.Join(t.TempDir(), "dump.json")
+	if err := os.WriteFile(path, []byte(libraryDump), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lib, err := NewInMemoryLibrary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lib
+}
+
+func TestResolve(t *testing.T) {
+	lib := testLibrary(t)
+	tests := map[string]string{
+		"lightning bolt":         "Lightning Bolt",
+		"Lightnig Bolt":          "Lightning Bolt",
+		"jace the mind sculptor": "Jace, the Mind Sculptor",
+		"Jace, the Mind":         "Jace, the Mind Sculptor",
+		"Lightning Hex":          "Lightning Helix",
+		"molniya":                "Молния",
+		"МОЛНИЯ":                 "Молния",
+		"ice":                    "Ice",
+	}
+	for name, expected := range tests {
+		known, err := lib.Resolve(name)
+		if err != nil {
+			t.Errorf("Resolve(%q): %s", name, err)
+			continue
+		}
+		if known != expected {
+			t.Errorf("Resolve(%q): expected %q, got %q", name, expected, known)
+		}
+		if _, err := lib.CardAliases(known); err != nil {
+			t.Errorf("no aliases of %q: %s", known, err)
+		}
+	}
+}
+
+func TestResolveSuggestions(t *testing.T) {
+	lib := testLibrary(t)
+	tests := map[string][]string{
+		"Lightning": {"Lightning Bolt", "Lightning Helix"},
+		"Opx":       {"Opt", "Ops"},
+		"Tarmogoyf": nil,
+	}
+	for name, expected := range tests {
+		_, err := lib.Resolve(name)
+		var unknown *UnknownCardError
+		if !errors.As(err, &unknown) {
+			t.Errorf("Resolve(%q): expected unknown card, got %v", name, err)
+			continue
+		}
+		if len(unknown.Suggestions) != len(expected) {
+			t.Errorf("Resolve(%q): expected suggestions %v, got %v", name, expected, unknown.Suggestions)
+			continue
+		}
+		for i := range expected {
+			if unknown.Suggestions[i] != expected[i] {
+				t.Errorf("Resolve(%q): expected suggestions %v, got %v", name, expected, unknown.Suggestions)
+				break
+			}
+		}
+	}
+}
+
+func TestResolveNames(t *testing.T) {
+	req := NewNamesRequest()
+	req.Cards["Lightnig Bolt"] = 2
+	req.Cards["Lightning Bolt"] = 1
+	req.Cards["LIGHTNING BOLT"] = 1
+	req.Cards["Tarmogoyf"] = 4
+	req.Requirements["Lightnig Bolt"] = CardRequirements{Preferred: CardFilter{Foil: OnlyFoil}}
+
+	result := &NamesResult{Resolved: make(map[string]string)}
+	resolved, err := resolveNames(testLibrary(t), req, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved.Cards) != 1 || resolved.Cards["Lightning Bolt"] != 4 {
+		t.Errorf("expected 4 Lightning Bolt, got %v", resolved.Cards)
+	}
+	if len(result.Resolved) != 1 || result.Resolved["Lightnig Bolt"] != "Lightning Bolt" {
+		t.Errorf("expected only correction of the typo, got %v", result.Resolved)
+	}
+	if len(result.UnknownCards) != 1 || result.UnknownCards[0].Name != "Tarmogoyf" {
+		t.Errorf("expected Tarmogoyf to be unknown, got %v", result.UnknownCards)
+	}
+	if r := resolved.Requirements["Lightning Bolt"]; !r.Required.empty() || !r.Preferred.empty() {
+		t.Errorf("expected preference of a part of the cards to be dropped, got %+v", r)
+	}
+
+	req.Requirements["Lightnig Bolt"] = CardRequirements{Required: CardFilter{Foil: OnlyFoil}}
+	if _, err := resolveNames(testLibrary(t), req, &NamesResult{Resolved: make(map[string]string)}); err == nil {
+		t.Error("expected error for different required filters of the same card")
+	}
+}
@@ -0,0 +1,62 @@
+package mtgbulk
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly"
+)
+
+// scrapeTimeout limits a single page request
+const scrapeTimeout = 20 * time.Second
+
+// contextTransport binds requests of a collector to the context so that cancelling the search stops scraping
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// scrapeTransport replaces network transport of scrapers if set; tests use it to replay recorded pages
+var scrapeTransport http.RoundTripper
+
+// networkTransport is shared by all scrapers, so connections to sellers are reused between searches
+var networkTransport = func() *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.ResponseHeaderTimeout = scrapeTimeout
+	return tr
+}()
+
+// newCollector creates a scraper whose requests are cancelled with the context
+func newCollector(ctx context.Context) *colly.Collector {
+	c := colly.NewCollector()
+	var base http.RoundTripper = networkTransport
+	if scrapeTransport != nil {
+		base = scrapeTransport
+	}
+	c.WithTransport(&contextTransport{ctx: ctx, base: base})
+	c.SetRequestTimeout(scrapeTimeout)
+	return c
+}
+
+// setFromIcon reads set code from classes of a Keyrune set icon like 'ss ss-c21 ss-rare', which shops use
+// instead of writing codes; empty string is returned if there is no set class
+func setFromIcon(class string) string {
+	for _, c := range strings.Fields(class) {
+		code := strings.TrimPrefix(c, "ss-")
+		if code == c || !setCodeRe.MatchString(strings.ToUpper(code)) {
+			continue
+		}
+		switch code {
+		case "common", "uncommon", "rare", "mythic", "timeshifted", "foil", "grad", "fw", "2x", "3x", "4x", "5x", "6x":
+			continue
+		}
+		return strings.ToUpper(code)
+	}
+	return ""
+}
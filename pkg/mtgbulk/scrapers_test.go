@@ -0,0 +1,162 @@
+package mtgbulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Scrapers are tested offline against pages of the shops kept in testdata, their results are compared
+// with golden files. Real sites are searched on demand to detect changed markup:
+//
+//	go test -run TestSearch            compares results on the kept pages with golden files
+//	go test -run TestSearch -update    rewrites golden files from the current results
+//	go test -run TestSearch -live      searches at real sites and fails if selectors find nothing
+var (
+	updateGolden = flag.Bool("update", false, "rewrite golden files of scraper tests")
+	liveScrape   = flag.Bool("live", false, "run scraper tests against real sites to detect changed markup")
+)
+
+var solRingAliases = map[string]bool{"sol ring": true, "кольцо солнца": true}
+
+func TestSearchMtgSale(t *testing.T) {
+	testScraper(t, "mtgsale_sol_ring", map[string]string{
+		"/home/search-results?Name=Sol%20Ring&Lang=Any&Type=Any&Color=Any&Rarity=Any": "mtgsale_sol_ring.html",
+	}, func(ctx context.Context) (CardResult, error) {
+		return searchMtgSale(ctx, "Sol Ring")
+	})
+}
+
+func TestSearchMtgTrade(t *testing.T) {
+	testScraper(t, "mtgtrade_sol_ring", map[string]string{
+		"/search/?query=sol+ring":        "mtgtrade_sol_ring.html",
+		"/search/?query=sol+ring&page=2": "mtgtrade_sol_ring_page2.html",
+	}, func(ctx context.Context) (CardResult, error) {
+		return searchMtgTrade(ctx, "Sol Ring")
+	})
+}
+
+func TestSearchSpellMarket(t *testing.T) {
+	testScraper(t, "spellmarket_sol_ring", map[string]string{
+		"/search?search=Sol%20Ring&limit=1000": "spellmarket_sol_ring.html",
+	}, func(ctx context.Context) (CardResult, error) {
+		return searchSpellMarket(ctx, "Sol Ring", solRingAliases)
+	})
+}
+
+func TestSearchAutumnsMagic(t *testing.T) {
+	testScraper(t, "autumnsmagic_sol_ring", map[string]string{
+		"/catalog?search=sol+ring": "autumnsmagic_sol_ring.html",
+	}, func(ctx context.Context) (CardResult, error) {
+		return searchAutumnsMagic(ctx, "Sol Ring", solRingAliases)
+	})
+}
+
+func TestSearchTopDeck(t *testing.T) {
+	testScraper(t, "topdeck_sol_ring", map[string]string{
+		"/apps/toptrade/singles/search?q=sol+ring": "topdeck_sol_ring.html",
+	}, func(ctx context.Context) (CardResult, error) {
+		return searchTopDeck(ctx, "Sol Ring")
+	})
+}
+
+// testScraper runs the search on pages from testdata, which maps request URI (path and query) to a file,
+// and compares the result with testdata/<golden>.golden.json
+func testScraper(t *testing.T, golden string, pages map[string]string, search func(ctx context.Context) (CardResult, error)) {
+	if !*liveScrape {
+		replayPages(t, pages)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	res, err := search(ctx)
+	if err != nil {
+		t.Fatalf("search has failed: %s", err)
+	}
+	if *liveScrape {
+		// real offers change all the time, so only check that selectors still find something
+		if !res.Available || len(res.Prices) == 0 {
+			t.Errorf("nothing has been found, markup of the site has probably changed")
+		}
+		return
+	}
+	checkGolden(t, golden, res)
+}
+
+// replayTransport sends every request to the fixture server regardless of the original host
+type replayTransport struct {
+	server *url.URL
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.server.Scheme
+	req.URL.Host = t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// replayPages serves pages from testdata and routes scrapers to them until the test ends
+func replayPages(t *testing.T, pages map[string]string) {
+	var mutex sync.Mutex
+	served := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, found := pages[r.URL.RequestURI()]
+		if !found {
+			t.Errorf("unexpected request %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+		mutex.Lock()
+		served[r.URL.RequestURI()] = true
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeFile(w, r, filepath.Join("testdata", file))
+	}))
+	serverURL, _ := url.Parse(server.URL)
+	scrapeTransport = replayTransport{server: serverURL}
+	t.Cleanup(func() {
+		scrapeTransport = nil
+		server.Close()
+		for uri := range pages {
+			if !served[uri] {
+				t.Errorf("page for %s has not been requested", uri)
+			}
+		}
+	})
+}
+
+func checkGolden(t *testing.T, name string, res CardResult) {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.Bytes()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read golden file, run with -update to create it: %s", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("result differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package mtgbulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// scryfallBulkURL lists bulk data files of Scryfall; changed by tests
+var scryfallBulkURL = "https://api.scryfall.com/bulk-data"
+
+// scryfallAllCards is the type of the bulk file with cards in every language, the only one having Russian names
+const scryfallAllCards = "all_cards"
+
+type scryfallBulk struct {
+	Data []struct {
+		Type        string    `json:"type"`
+		DownloadURI string    `json:"download_uri"`
+		UpdatedAt   time.Time `json:"updated_at"`
+		Size        int64     `json:"size"`
+	} `json:"data"`
+}
+
+// scryfallGet sends the headers required by Scryfall API
+func scryfallGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "tgbots-mtgbulk/1.0")
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Unexpected status of %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// RefreshScryfallDump downloads the bulk file of all cards unless the dump is already up to date or force is set;
+// it tells if the dump has been downloaded. The dump keeps the time of download, so it is up to date while the bulk
+// file at Scryfall is older, and card indexes built before the download are older than the dump
+func RefreshScryfallDump(ctx context.Context, dumpPath string, force bool) (bool, error) {
+	resp, err := scryfallGet(ctx, scryfallBulkURL)
+	if err != nil {
+		return false, fmt.Errorf("Cannot get list of Scryfall bulk files: %w", err)
+	}
+	var bulk scryfallBulk
+	err = json.NewDecoder(resp.Body).Decode(&bulk)
+	resp.Body.Close()
+	if err != nil {
+		return false, fmt.Errorf("Cannot decode list of Scryfall bulk files: %w", err)
+	}
+
+	for _, b := range bulk.Data {
+		if b.Type != scryfallAllCards {
+			continue
+		}
+		if st, err := os.Stat(dumpPath); err == nil && !force && !st.ModTime().Before(b.UpdatedAt) {
+			logger.Debugw("Scryfall dump is up to date",
+				"path", dumpPath,
+				"updated", b.UpdatedAt)
+			return false, nil
+		}
+		logger.Debugw("downloading Scryfall dump",
+			"url", b.DownloadURI,
+			"size", b.Size)
+		if err := downloadFile(ctx, b.DownloadURI, dumpPath); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("No %q bulk file at Scryfall", scryfallAllCards)
+}
+
+// downloadFile replaces the file only when it is completely downloaded
+func downloadFile(ctx context.Context, url, path string) error {
+	resp, err := scryfallGet(ctx, url)
+	if err != nil {
+		return fmt.Errorf("Cannot download Scryfall dump: %w", err)
+	}
+	defer resp.Body.Close()
+
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("Cannot create Scryfall dump: %w", err)
+	}
+	defer os.Remove(tmpPath)
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return fmt.Errorf("Cannot download Scryfall dump: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Cannot write Scryfall dump: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("Cannot replace Scryfall dump: %w", err)
+	}
+	return nil
+}
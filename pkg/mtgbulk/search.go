@@ -0,0 +1,149 @@
+package mtgbulk
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SellerConcurrency limits simultaneous searches at a single seller so that sites are not flooded
+var SellerConcurrency = 2
+
+// SellerTimeout limits a single card search at a seller; a slow seller does not hold the whole request
+var SellerTimeout = 2 * time.Minute
+
+// Progress reports a finished card search at a seller
+type Progress struct {
+	Done  int
+	Total int
+
+	Card   string
+	Seller string
+	Err    error
+	Cached bool // result has been taken from cache
+}
+
+// SearchFailure describes a card search which has failed at a seller
+type SearchFailure struct {
+	Card   string
+	Seller string
+	Err    error
+}
+
+var limitsMutex sync.Mutex
+var sellerLimits = make(map[string]chan struct{})
+
+func sellerLimit(name string) chan struct{} {
+	limitsMutex.Lock()
+	defer limitsMutex.Unlock()
+
+	key := strings.ToLower(name)
+	limit, found := sellerLimits[key]
+	if !found {
+		limit = make(chan struct{}, max(SellerConcurrency, 1))
+		sellerLimits[key] = limit
+	}
+	return limit
+}
+
+// searchAt runs the search at the seller respecting its concurrency limit and timeout
+func searchAt(ctx context.Context, s Seller, q CardQuery) (CardResult, error) {
+	limit := sellerLimit(s.Name())
+	select {
+	case limit <- struct{}{}:
+	case <-ctx.Done():
+		return newCardResult(), ctx.Err()
+	}
+	defer func() { <-limit }()
+
+	if SellerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, SellerTimeout)
+		defer cancel()
+	}
+	res, err := s.Search(ctx, q)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	now := time.Now()
+	for i := range res.Prices {
+		res.Prices[i].FetchedAt = now
+	}
+	return res, err
+}
+
+// searchCached answers from the cache of the request if possible and caches successful searches
+func searchCached(ctx context.Context, s Seller, q CardQuery, req NamesRequest) (CardResult, bool, error) {
+	if req.Cache != nil && !req.ForceRefresh {
+		if res, found := req.Cache.Get(ctx, s.Name(), q.Name); found {
+			return res, true, nil
+		}
+	}
+	res, err := searchAt(ctx, s, q)
+	if err == nil && req.Cache != nil {
+		req.Cache.Set(ctx, s.Name(), q.Name, res)
+	}
+	return res, false, err
+}
+
+// searchAll looks for every card at every seller concurrently; offers found before a failure are kept
+func searchAll(ctx context.Context, queries []CardQuery, sellers []Seller, req NamesRequest) (map[string]CardResult, []SearchFailure, int) {
+	results := make(map[string]CardResult, len(queries))
+	for _, q := range queries {
+		results[q.Name] = newCardResult()
+	}
+	failures := make([]SearchFailure, 0)
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	total := len(queries) * len(sellers)
+	done := 0
+	cachedCount := 0
+
+	// progress is reported by a separate goroutine, so a slow callback does not hold the workers;
+	// the channel has room for every report, so sending never blocks
+	progress := make(chan Progress, total)
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		for p := range progress {
+			if req.Progress != nil {
+				req.Progress(p)
+			}
+		}
+	}()
+
+	for _, q := range queries {
+		for _, s := range sellers {
+			wg.Add(1)
+			go func(q CardQuery, s Seller) {
+				defer wg.Done()
+				res, cached, err := searchCached(ctx, s, q, req)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+				cardRes := results[q.Name]
+				cardRes.merge(res)
+				results[q.Name] = cardRes
+				if err != nil {
+					logger.Errorw("search at seller failed",
+						"card", q.Name,
+						"seller", s.Name(),
+						"err", err)
+					failures = append(failures, SearchFailure{Card: q.Name, Seller: s.Name(), Err: err})
+				}
+				if cached {
+					cachedCount++
+				}
+				done++
+				progress <- Progress{Done: done, Total: total, Card: q.Name, Seller: s.Name(), Err: err, Cached: cached}
+			}(q, s)
+		}
+	}
+	wg.Wait()
+	close(progress)
+	<-progressDone
+
+	return results, failures, cachedCount
+}
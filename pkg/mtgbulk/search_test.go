@@ -0,0 +1,95 @@
+package mtgbulk
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeSeller struct {
+	name    string
+	price   float32
+	err     error
+	delay   time.Duration
+	running int32
+	peak    int32
+	calls   int32
+}
+
+func (s *fakeSeller) Name() string     { return s.name }
+func (s *fakeSeller) Kind() SellerKind { return Shop }
+
+func (s *fakeSeller) Search(ctx context.Context, q CardQuery) (CardResult, error) {
+	atomic.AddInt32(&s.calls, 1)
+	n := atomic.AddInt32(&s.running, 1)
+	defer atomic.AddInt32(&s.running, -1)
+	for {
+		peak := atomic.LoadInt32(&s.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&s.peak, peak, n) {
+			break
+		}
+	}
+
+	res := newCardResult()
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return res, ctx.Err()
+	}
+	if s.err != nil {
+		return res, s.err
+	}
+	res.Available = true
+	res.Prices = append(res.Prices, CardPrice{Price: s.price, Quantity: 1, Platform: s.name, Trader: s.name})
+	return res, nil
+}
+
+func TestSearchAll(t *testing.T) {
+	good := &fakeSeller{name: "FakeGood", price: 10, delay: 10 * time.Millisecond}
+	broken := &fakeSeller{name: "FakeBroken", err: errors.New("site is down")}
+	slow := &fakeSeller{name: "FakeSlow", price: 1, delay: time.Minute}
+
+	oldTimeout := SellerTimeout
+	SellerTimeout = 100 * time.Millisecond
+	defer func() { SellerTimeout = oldTimeout }()
+
+	queries := []CardQuery{{Name: "Opt"}, {Name: "Shock"}, {Name: "Duress"}, {Name: "Ponder"}}
+	calls := 0
+	req := NewNamesRequest()
+	req.Progress = func(p Progress) {
+		calls++
+		if p.Total != 12 || p.Done != calls {
+			t.Errorf("unexpected progress %+v after %d calls", p, calls)
+		}
+	}
+	results, failures, _ := searchAll(context.Background(), queries, []Seller{good, broken, slow}, req)
+
+	if calls != 12 {
+		t.Errorf("expected 12 progress calls, got %d", calls)
+	}
+	if len(failures) != 8 {
+		t.Errorf("expected 8 failures of broken and slow sellers, got %d", len(failures))
+	}
+	for _, q := range queries {
+		res := results[q.Name]
+		if !res.Available || len(res.Prices) != 1 || res.Prices[0].Platform != "FakeGood" {
+			t.Errorf("expected offer of the good seller for %q, got %+v", q.Name, res)
+		}
+	}
+	if good.peak > int32(SellerConcurrency) {
+		t.Errorf("seller concurrency limit %d exceeded: %d", SellerConcurrency, good.peak)
+	}
+}
+
+func TestSearchAllCancelled(t *testing.T) {
+	slow := &fakeSeller{name: "FakeCancelled", delay: time.Minute}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, failures, _ := searchAll(ctx, []CardQuery{{Name: "Opt"}}, []Seller{slow}, NewNamesRequest())
+	if len(failures) != 1 || !errors.Is(failures[0].Err, context.Canceled) {
+		t.Errorf("expected cancelled search, got %+v", failures)
+	}
+}
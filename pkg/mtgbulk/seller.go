@@ -0,0 +1,118 @@
+package mtgbulk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type SellerKind int
+
+const (
+	// Shop sells its own cards
+	Shop SellerKind = iota
+	// Marketplace hosts offers of many traders
+	Marketplace SellerKind = iota
+)
+
+func (k SellerKind) String() string {
+	switch k {
+	case Shop:
+		return "shop"
+	case Marketplace:
+		return "marketplace"
+	}
+	return ""
+}
+
+// CardQuery describes a card to be searched; aliases are lowercase names of the card in all known languages
+type CardQuery struct {
+	Name        string
+	EnglishName string
+	Aliases     map[string]bool
+}
+
+// Seller is a site where cards are searched
+type Seller interface {
+	Name() string
+	Kind() SellerKind
+	// Search returns offers of the card; partially collected offers may be returned along with an error
+	Search(ctx context.Context, q CardQuery) (CardResult, error)
+}
+
+var registry = struct {
+	sync.Mutex
+	sellers map[string]Seller
+}{sellers: make(map[string]Seller)}
+
+func sellerKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// RegisterSeller makes the seller available for requests; names are case insensitive and must be unique
+func RegisterSeller(s Seller) {
+	registry.Lock()
+	defer registry.Unlock()
+	key := sellerKey(s.Name())
+	if _, found := registry.sellers[key]; found {
+		panic(fmt.Sprintf("seller %q is already registered", s.Name()))
+	}
+	registry.sellers[key] = s
+}
+
+// Sellers returns all registered sellers sorted by name
+func Sellers() []Seller {
+	registry.Lock()
+	defer registry.Unlock()
+	result := make([]Seller, 0, len(registry.sellers))
+	for _, s := range registry.sellers {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// SellerByName looks for a registered seller
+func SellerByName(name string) (Seller, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+	s, found := registry.sellers[sellerKey(name)]
+	return s, found
+}
+
+// selectSellers returns sellers enabled for the request: the listed ones or all registered, except disabled ones
+func selectSellers(enabled, disabled []string) ([]Seller, error) {
+	selected := Sellers()
+	if len(enabled) > 0 {
+		selected = make([]Seller, 0, len(enabled))
+		for _, name := range enabled {
+			s, found := SellerByName(name)
+			if !found {
+				return nil, fmt.Errorf("Unknown seller %q", name)
+			}
+			selected = append(selected, s)
+		}
+	}
+
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		if _, found := SellerByName(name); !found {
+			return nil, fmt.Errorf("Unknown seller %q", name)
+		}
+		skip[sellerKey(name)] = true
+	}
+	result := make([]Seller, 0, len(selected))
+	for _, s := range selected {
+		if !skip[sellerKey(s.Name())] {
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("No sellers are enabled")
+	}
+	return result, nil
+}
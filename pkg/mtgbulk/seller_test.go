@@ -0,0 +1,41 @@
+package mtgbulk
+
+import "testing"
+
+func sellerNames(sellers []Seller) []string {
+	names := make([]string, 0, len(sellers))
+	for _, s := range sellers {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+func TestSelectSellers(t *testing.T) {
+	all, err := selectSellers(nil, nil)
+	if err != nil || len(all) != len(Sellers()) {
+		t.Errorf("expected all sellers, got %v, error %v", sellerNames(all), err)
+	}
+
+	selected, err := selectSellers([]string{"mtgsale", "TopDeck"}, []string{"topdeck"})
+	if err != nil || len(selected) != 1 || selected[0].Name() != "MtgSale" {
+		t.Errorf("expected only MtgSale, got %v, error %v", sellerNames(selected), err)
+	}
+
+	if _, err := selectSellers([]string{"nosuchshop"}, nil); err == nil {
+		t.Error("expected error for unknown seller")
+	}
+	if _, err := selectSellers([]string{"MtgSale"}, []string{"MtgSale"}); err == nil {
+		t.Error("expected error when every seller is disabled")
+	}
+}
+
+func TestSellerFullName(t *testing.T) {
+	shop := CardPrice{Platform: "MtgSale", Trader: "mtgsale"}
+	if n := shop.SellerFullName(); n != "mtgsale" {
+		t.Errorf("unexpected shop name %q", n)
+	}
+	market := CardPrice{Platform: "TopDeck", Trader: "someone"}
+	if n := market.SellerFullName(); n != "someone@TopDeck" {
+		t.Errorf("unexpected marketplace trader name %q", n)
+	}
+}
@@ -1,6 +1,7 @@
 package mtgbulk
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,10 +11,30 @@ import (
 	"github.com/gocolly/colly"
 )
 
-func searchSpellMarket(searchName string, names map[string]bool) CardResult {
+const spellMarketName = "SpellMarket"
+
+type spellMarketSeller struct{}
+
+func init() {
+	RegisterSeller(spellMarketSeller{})
+}
+
+func (spellMarketSeller) Name() string {
+	return spellMarketName
+}
+
+func (spellMarketSeller) Kind() SellerKind {
+	return Shop
+}
+
+func (spellMarketSeller) Search(ctx context.Context, q CardQuery) (CardResult, error) {
+	return searchSpellMarket(ctx, q.Name, q.Aliases)
+}
+
+func searchSpellMarket(ctx context.Context, searchName string, names map[string]bool) (CardResult, error) {
 	result := newCardResult()
 	addr := spellMarketSearchURL(searchName)
-	c := colly.NewCollector()
+	c := newCollector(ctx)
 
 	currency1 := &http.Cookie{Name: "currency", Value: "RUB"}
 	currency2 := &http.Cookie{Name: "prmn_currency", Value: "RUB"}
@@ -45,21 +66,26 @@ func searchSpellMarket(searchName string, names map[string]bool) CardResult {
 			return
 		}
 
+		foil := e.ChildText(".foil") != ""
 		logger.Debugw("card found",
 			"searchName", searchName,
 			"name", name,
 			"price", price,
-			"qty", qty)
+			"qty", qty,
+			"foil", foil)
 
 		result.Available = true
 		result.Prices = append(result.Prices, CardPrice{
-			Price:    float32(price),
-			Foil:     false, // TODO
-			Currency: RUR,
-			Quantity: qty,
-			Platform: SpellMarket,
-			Trader:   "spellmarket",
-			URL:      addr, // TODO: correct it! - it's just a search result, but we can get a direct link to a card at a seller
+			Price:     float32(price),
+			Foil:      foil,
+			Currency:  RUR,
+			Quantity:  qty,
+			Condition: ParseCondition(e.ChildText(".condition")),
+			Language:  languageCode(e.ChildText(".lang")),
+			Set:       setFromIcon(e.ChildAttr(".set i", "class")),
+			Platform:  spellMarketName,
+			Trader:    "spellmarket",
+			URL:       addr, // TODO: correct it! - it's just a search result, but we can get a direct link to a card at a seller
 		})
 	})
 
@@ -70,7 +96,7 @@ func searchSpellMarket(searchName string, names map[string]bool) CardResult {
 			"err", err)
 	}
 
-	return result
+	return result, err
 }
 
 func spellMarketSearchURL(searchName string) string {
@@ -0,0 +1,42 @@
+package mtgbulk
+
+import (
+	"strings"
+)
+
+// SellerTerms describes conditions of ordering from a seller
+type SellerTerms struct {
+	DeliveryFee int
+	FreeFrom    int  // delivery is free for orders of this sum or more; never free if 0
+	MinOrder    int  // smallest accepted order sum; any if 0
+	Pickup      bool // order may be picked up without delivery
+}
+
+// Delivery returns the price of delivering an order of the given sum
+func (t SellerTerms) Delivery(sum float32, pickup bool) float32 {
+	if pickup && t.Pickup {
+		return 0
+	}
+	if t.FreeFrom > 0 && sum >= float32(t.FreeFrom) {
+		return 0
+	}
+	return float32(t.DeliveryFee)
+}
+
+// Accepts tells if an order of the given sum is not smaller than the minimal one
+func (t SellerTerms) Accepts(sum float32) bool {
+	return sum >= float32(t.MinOrder)
+}
+
+// termsFor looks for terms of the trader at first and then of its platform;
+// so terms of a marketplace apply to every its trader separately. DeliveryFee is used if there are no terms
+func (req NamesRequest) termsFor(p CardPrice) SellerTerms {
+	for _, key := range []string{p.SellerFullName(), p.Platform} {
+		for name, terms := range req.Terms {
+			if strings.EqualFold(name, key) {
+				return terms
+			}
+		}
+	}
+	return SellerTerms{DeliveryFee: req.DeliveryFee}
+}
@@ -1,6 +1,7 @@
 package mtgbulk
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -22,14 +23,38 @@ type topdeckCard struct {
 	Qty    int    `json:"qty"`
 	Cost   int    `json:"cost"`
 	Source string `json:"source"`
+	Foil   bool   `json:"foil"`
+	Lang   string `json:"lang"`
+	State  string `json:"state"` // condition like 'NM'
+	Set    string `json:"set"`
 }
 
-func searchTopDeck(cardname string) CardResult {
+const topDeckName = "TopDeck"
+
+type topDeckSeller struct{}
+
+func init() {
+	RegisterSeller(topDeckSeller{})
+}
+
+func (topDeckSeller) Name() string {
+	return topDeckName
+}
+
+func (topDeckSeller) Kind() SellerKind {
+	return Marketplace
+}
+
+func (topDeckSeller) Search(ctx context.Context, q CardQuery) (CardResult, error) {
+	return searchTopDeck(ctx, q.Name)
+}
+
+func searchTopDeck(ctx context.Context, cardname string) (CardResult, error) {
 	cardname = strings.ToLower(cardname)
 	result := newCardResult()
 	addr := topDeckSearchURL(cardname)
 
-	c := colly.NewCollector()
+	c := newCollector(ctx)
 
 	c.OnHTML("script", func(e *colly.HTMLElement) {
 		matches := re.FindAllSubmatch([]byte(e.Text), -1)
@@ -87,17 +112,21 @@ func searchTopDeck(cardname string) CardResult {
 				"ru_name", c.RusName,
 				"en_name", c.EngName,
 				"cost", c.Cost,
-				"qty", c.Qty)
+				"qty", c.Qty,
+				"foil", c.Foil)
 
 			result.Available = true
 			result.Prices = append(result.Prices, CardPrice{
-				Price:    float32(c.Cost),
-				Foil:     false,
-				Currency: RUR,
-				Quantity: c.Qty,
-				Platform: TopDeck,
-				Trader:   c.Seller.Name,
-				URL:      c.URL,
+				Price:     float32(c.Cost),
+				Foil:      c.Foil,
+				Currency:  RUR,
+				Quantity:  c.Qty,
+				Condition: ParseCondition(c.State),
+				Language:  languageCode(c.Lang),
+				Set:       strings.ToUpper(c.Set),
+				Platform:  topDeckName,
+				Trader:    c.Seller.Name,
+				URL:       c.URL,
 			})
 		}
 		_, err = dec.Token()
@@ -115,7 +144,7 @@ func searchTopDeck(cardname string) CardResult {
 			"err", err)
 	}
 
-	return result
+	return result, err
 }
 
 func topDeckSearchURL(cardname string) string {
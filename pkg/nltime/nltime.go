@@ -0,0 +1,479 @@
+// Package nltime finds date and time expressions in Russian and English texts
+// like "завтра в 15:30", "12 марта", "в пятницу вечером", "через 2 недели" or "tomorrow at 3pm"
+package nltime
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Result is a moment found in a text
+type Result struct {
+	// Time is the resolved moment in the location of 'now' passed to Parse;
+	// it is midnight of the day if the text names only a day
+	Time time.Time
+	// HasDate is set if the text names a day: relative, weekday or calendar date
+	HasDate bool
+	// HasTime is set if Time is an exact moment: the text names time of a day or a relative period
+	HasTime bool
+	// Spans are byte ranges [start, end) of the text forming the expression
+	Spans [][2]int
+}
+
+// Span returns byte range covering the whole expression
+func (r Result) Span() (int, int) {
+	start, end := -1, -1
+	for _, s := range r.Spans {
+		if start == -1 || s[0] < start {
+			start = s[0]
+		}
+		if s[1] > end {
+			end = s[1]
+		}
+	}
+	return start, end
+}
+
+// Strip removes the expression from the text it has been found in
+func (r Result) Strip(text string) string {
+	spans := append([][2]int{}, r.Spans...)
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] > spans[j][0] })
+	for _, s := range spans {
+		text = text[:s[0]] + " " + text[s[1]:]
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+type partKind int
+
+const (
+	partRelative partKind = iota
+	partDay
+	partClock
+	partDayTime
+)
+
+type match struct {
+	kind partKind
+	span [2]int
+	// filled depending on kind
+	period  func(time.Time) time.Time
+	day     func(now time.Time) (time.Time, bool) // returns midnight of the day; bool is set if the day may be moved forward
+	hour    int
+	minute  int
+	nextDay bool // clock belongs to the next day, e.g. midnight
+}
+
+type pattern struct {
+	re    *regexp.Regexp
+	parse func(text string, sub []int) (match, bool)
+}
+
+const unitRe = `(полчаса|секунд\p{L}*|сек|минут\p{L}*|мин|час\p{L}*|дн\p{L}*|день|сут\p{L}*|недел\p{L}*|месяц\p{L}*|год\p{L}*|лет|seconds?|secs?|minutes?|mins?|hours?|days?|weeks?|months?|years?)`
+
+const monthRe = `(январ\p{L}*|феврал\p{L}*|март\p{L}*|апрел\p{L}*|ма[йя]|июн\p{L}*|июл\p{L}*|август\p{L}*|сентябр\p{L}*|октябр\p{L}*|ноябр\p{L}*|декабр\p{L}*|january|february|march|april|may|june|july|august|september|october|november|december|jan|feb|mar|apr|jun|jul|aug|sept|sep|oct|nov|dec)`
+
+const weekdayRe = `(понедельник\p{L}*|вторник\p{L}*|сред[ауы]|четверг\p{L}*|пятниц[ауы]|суббот[ауы]|воскресень[еяю]|monday|tuesday|wednesday|thursday|friday|saturday|sunday)`
+
+const atRe = `(?:(?:в|во|к|at|by)\s+)`
+
+const meridiemRe = `(am|pm|a\.m\.|p\.m\.|утра|дня|вечера|ночи)`
+
+// patterns are ordered by priority: a match overlapping with an earlier one is ignored
+var patterns = []pattern{
+	{regexp.MustCompile(`(?i)(?:через|in)\s+(?:(\d+|\p{L}+)\s+)?` + unitRe), parseRelative},
+	{regexp.MustCompile(`(?i)(послезавтра|day after tomorrow|завтра|tomorrow|сегодня|today|tonight)`), parseRelativeDay},
+	{regexp.MustCompile(`(?i)(\d{1,2})(?:-?(?:го|е|st|nd|rd|th))?\s+(?:of\s+)?` + monthRe + `(?:,?\s+(\d{4}))?`), parseDayMonth},
+	{regexp.MustCompile(`(?i)` + monthRe + `\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?`), parseMonthDay},
+	{regexp.MustCompile(`(?i)` + atRe + `?(\d{1,2}):(\d{2})(?:\s*` + meridiemRe + `)?`), parseClock},
+	{regexp.MustCompile(`(?i)` + atRe + `(\d{1,2})\.(\d{2})(?:\s*` + meridiemRe + `)?`), parseClock},
+	{regexp.MustCompile(`(\d{1,2})\.(\d{1,2})(?:\.(\d{4}|\d{2}))?`), parseNumericDate},
+	{regexp.MustCompile(`(?i)(\d{1,2})(?:-?го)?\s+числа`), parseDayOfMonth},
+	{regexp.MustCompile(`(?i)(?:(?:в|во|on|next|this|в\s+следующ\p{L}*|в\s+эт\p{L}*)\s+)?` + weekdayRe), parseWeekday},
+	{regexp.MustCompile(`(?i)` + atRe + `?(\d{1,2})\s*(?:` + meridiemRe + `|(час(?:а|ов)?|o'clock))`), parseHour},
+	{regexp.MustCompile(`(?i)` + atRe + `?(полдень|полночь|noon|midnight)`), parseNamedClock},
+	{regexp.MustCompile(`(?i)(утром|днём|днем|вечером|ночью|in the morning|in the afternoon|in the evening|at night|morning|afternoon|evening)`), parseDayTime},
+}
+
+// Parse finds a date/time expression in the text and resolves it relative to now;
+// now defines the timezone the expression is interpreted in
+func Parse(text string, now time.Time) (Result, bool) {
+	matches := make([]match, 0)
+	for _, p := range patterns {
+		for _, sub := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			if !isWordBounded(text, sub[0], sub[1]) {
+				continue
+			}
+			m, ok := p.parse(text, sub)
+			if !ok {
+				continue
+			}
+			m.span = [2]int{sub[0], sub[1]}
+			if overlaps(matches, m.span) {
+				continue
+			}
+			matches = append(matches, m)
+		}
+	}
+	if len(matches) == 0 {
+		return Result{}, false
+	}
+
+	// taking only the first part of each kind
+	var relative, day, clock, dayTime *match
+	res := Result{}
+	for i := range matches {
+		m := &matches[i]
+		var slot **match
+		switch m.kind {
+		case partRelative:
+			slot = &relative
+		case partDay:
+			slot = &day
+		case partClock:
+			slot = &clock
+		case partDayTime:
+			slot = &dayTime
+		}
+		if *slot != nil {
+			continue
+		}
+		*slot = m
+		res.Spans = append(res.Spans, m.span)
+	}
+	if clock == nil && dayTime != nil {
+		clock = dayTime
+	} else if dayTime != nil && clock != nil {
+		// "завтра вечером в 7": the part of a day only moves the clock to the afternoon
+		if clock.hour < 12 && dayTime.hour >= 12 && dayTime.hour < 23 {
+			clock.hour += 12
+		}
+	}
+
+	if relative != nil {
+		res.Time = relative.period(now)
+		res.HasDate, res.HasTime = true, true
+		if clock != nil {
+			res.Time = setClock(res.Time, clock)
+		}
+		sort.Slice(res.Spans, func(i, j int) bool { return res.Spans[i][0] < res.Spans[j][0] })
+		return res, true
+	}
+
+	today := midnight(now)
+	if day != nil {
+		d, movable := day.day(now)
+		res.HasDate = true
+		if clock != nil {
+			res.HasTime = true
+			d = setClock(d, clock)
+			if movable && !d.After(now) && sameDay(d, today) {
+				d = d.AddDate(0, 0, 7)
+			}
+		} else if movable && sameDay(d, today) {
+			d = d.AddDate(0, 0, 7)
+		}
+		res.Time = d
+	} else {
+		res.HasTime = true
+		res.Time = setClock(today, clock)
+		if !res.Time.After(now) {
+			res.Time = setClock(today.AddDate(0, 0, 1), clock)
+		}
+	}
+	sort.Slice(res.Spans, func(i, j int) bool { return res.Spans[i][0] < res.Spans[j][0] })
+	return res, true
+}
+
+func overlaps(matches []match, span [2]int) bool {
+	for _, m := range matches {
+		if span[0] < m.span[1] && m.span[0] < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordBounded(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func group(text string, sub []int, n int) string {
+	if 2*n+1 >= len(sub) || sub[2*n] < 0 {
+		return ""
+	}
+	return strings.ToLower(text[sub[2*n]:sub[2*n+1]])
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func setClock(day time.Time, clock *match) time.Time {
+	d := day.Day()
+	if clock.nextDay {
+		d++
+	}
+	return time.Date(day.Year(), day.Month(), d, clock.hour, clock.minute, 0, 0, day.Location())
+}
+
+// AddMonths adds calendar months; the day is clamped to the length of the resulting month, so Jan 31 + 1 month is Feb 28/29
+func AddMonths(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := daysIn(first.Year(), first.Month()); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"один": 1, "одну": 1, "одна": 1, "два": 2, "две": 2, "пару": 2, "три": 3, "четыре": 4, "пять": 5,
+	"шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10}
+
+func parseRelative(text string, sub []int) (match, bool) {
+	q := 1
+	if qs := group(text, sub, 1); qs != "" {
+		var err error
+		if q, err = strconv.Atoi(qs); err != nil {
+			var known bool
+			if q, known = numberWords[qs]; !known {
+				return match{}, false
+			}
+		}
+	}
+	unit := group(text, sub, 2)
+	var period func(time.Time) time.Time
+	switch {
+	case unit == "полчаса":
+		period = func(t time.Time) time.Time { return t.Add(time.Duration(q) * 30 * time.Minute) }
+	case strings.HasPrefix(unit, "сек") || strings.HasPrefix(unit, "sec"):
+		period = func(t time.Time) time.Time { return t.Add(time.Duration(q) * time.Second) }
+	case strings.HasPrefix(unit, "мин") || strings.HasPrefix(unit, "min"):
+		period = func(t time.Time) time.Time { return t.Add(time.Duration(q) * time.Minute) }
+	case strings.HasPrefix(unit, "час") || strings.HasPrefix(unit, "hour"):
+		period = func(t time.Time) time.Time { return t.Add(time.Duration(q) * time.Hour) }
+	case strings.HasPrefix(unit, "дн") || unit == "день" || strings.HasPrefix(unit, "сут") || strings.HasPrefix(unit, "day"):
+		period = func(t time.Time) time.Time { return t.AddDate(0, 0, q) }
+	case strings.HasPrefix(unit, "недел") || strings.HasPrefix(unit, "week"):
+		period = func(t time.Time) time.Time { return t.AddDate(0, 0, 7*q) }
+	case strings.HasPrefix(unit, "месяц") || strings.HasPrefix(unit, "month"):
+		period = func(t time.Time) time.Time { return AddMonths(t, q) }
+	case strings.HasPrefix(unit, "год") || unit == "лет" || strings.HasPrefix(unit, "year"):
+		period = func(t time.Time) time.Time { return AddMonths(t, 12*q) }
+	default:
+		return match{}, false
+	}
+	return match{kind: partRelative, period: period}, true
+}
+
+func parseRelativeDay(text string, sub []int) (match, bool) {
+	offset := 0
+	switch group(text, sub, 1) {
+	case "послезавтра", "day after tomorrow":
+		offset = 2
+	case "завтра", "tomorrow":
+		offset = 1
+	case "tonight":
+		return match{kind: partDayTime, hour: 20}, true
+	}
+	return match{kind: partDay, day: func(now time.Time) (time.Time, bool) {
+		return midnight(now).AddDate(0, 0, offset), false
+	}}, true
+}
+
+var monthPrefixes = []struct {
+	prefix string
+	month  time.Month
+}{
+	{"янв", time.January}, {"фев", time.February}, {"мар", time.March}, {"апр", time.April},
+	{"ма", time.May}, {"июн", time.June}, {"июл", time.July}, {"авг", time.August},
+	{"сен", time.September}, {"окт", time.October}, {"ноя", time.November}, {"дек", time.December},
+	{"jan", time.January}, {"feb", time.February}, {"mar", time.March}, {"apr", time.April},
+	{"may", time.May}, {"jun", time.June}, {"jul", time.July}, {"aug", time.August},
+	{"sep", time.September}, {"oct", time.October}, {"nov", time.November}, {"dec", time.December}}
+
+func monthByName(name string) time.Month {
+	for _, p := range monthPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.month
+		}
+	}
+	return 0
+}
+
+// calendarDay returns a match for a date; a date without a year which has passed is moved to the next year
+// having such a day, so 29 February waits for a leap year. Impossible dates like 31 February are not matched
+func calendarDay(day int, month time.Month, year int) (match, bool) {
+	if month < time.January || month > time.December || day < 1 {
+		return match{}, false
+	}
+	// 2000 is a leap year, so it has the longest February
+	if day > daysIn(2000, month) || (year != 0 && day > daysIn(year, month)) {
+		return match{}, false
+	}
+	return match{kind: partDay, day: func(now time.Time) (time.Time, bool) {
+		if year != 0 {
+			return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), false
+		}
+		for y := now.Year(); ; y++ {
+			if day > daysIn(y, month) {
+				continue
+			}
+			if d := time.Date(y, month, day, 0, 0, 0, 0, now.Location()); !d.Before(midnight(now)) {
+				return d, false
+			}
+		}
+	}}, true
+}
+
+func parseDayMonth(text string, sub []int) (match, bool) {
+	day, _ := strconv.Atoi(group(text, sub, 1))
+	year, _ := strconv.Atoi(group(text, sub, 3))
+	return calendarDay(day, monthByName(group(text, sub, 2)), year)
+}
+
+func parseMonthDay(text string, sub []int) (match, bool) {
+	day, _ := strconv.Atoi(group(text, sub, 2))
+	year, _ := strconv.Atoi(group(text, sub, 3))
+	return calendarDay(day, monthByName(group(text, sub, 1)), year)
+}
+
+func parseNumericDate(text string, sub []int) (match, bool) {
+	day, _ := strconv.Atoi(group(text, sub, 1))
+	month, _ := strconv.Atoi(group(text, sub, 2))
+	year, _ := strconv.Atoi(group(text, sub, 3))
+	if year != 0 && year < 100 {
+		year += 2000
+	}
+	return calendarDay(day, time.Month(month), year)
+}
+
+func parseDayOfMonth(text string, sub []int) (match, bool) {
+	day, _ := strconv.Atoi(group(text, sub, 1))
+	if day < 1 || day > 31 {
+		return match{}, false
+	}
+	return match{kind: partDay, day: func(now time.Time) (time.Time, bool) {
+		y, m := now.Year(), now.Month()
+		if day < now.Day() {
+			m++
+		}
+		for day > daysIn(y, m) {
+			m++
+		}
+		return time.Date(y, m, day, 0, 0, 0, 0, now.Location()), false
+	}}, true
+}
+
+var weekdayPrefixes = []struct {
+	prefix  string
+	weekday time.Weekday
+}{
+	{"пон", time.Monday}, {"вто", time.Tuesday}, {"сре", time.Wednesday}, {"чет", time.Thursday},
+	{"пят", time.Friday}, {"суб", time.Saturday}, {"вос", time.Sunday},
+	{"mon", time.Monday}, {"tue", time.Tuesday}, {"wed", time.Wednesday}, {"thu", time.Thursday},
+	{"fri", time.Friday}, {"sat", time.Saturday}, {"sun", time.Sunday}}
+
+func parseWeekday(text string, sub []int) (match, bool) {
+	name := group(text, sub, 1)
+	for _, p := range weekdayPrefixes {
+		if !strings.HasPrefix(name, p.prefix) {
+			continue
+		}
+		wd := p.weekday
+		return match{kind: partDay, day: func(now time.Time) (time.Time, bool) {
+			diff := (int(wd) - int(now.Weekday()) + 7) % 7
+			return midnight(now).AddDate(0, 0, diff), true
+		}}, true
+	}
+	return match{}, false
+}
+
+func applyMeridiem(hour int, meridiem string) (int, bool) {
+	switch meridiem {
+	case "pm", "p.m.", "дня", "вечера":
+		if hour > 12 {
+			return hour, meridiem == "дня" && hour < 18
+		}
+		if hour < 12 {
+			hour += 12
+		}
+	case "am", "a.m.", "утра", "ночи":
+		if hour > 12 {
+			return hour, false
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	}
+	return hour, hour < 24
+}
+
+func parseClock(text string, sub []int) (match, bool) {
+	hour, _ := strconv.Atoi(group(text, sub, 1))
+	minute, _ := strconv.Atoi(group(text, sub, 2))
+	hour, ok := applyMeridiem(hour, group(text, sub, 3))
+	if !ok || minute > 59 {
+		return match{}, false
+	}
+	return match{kind: partClock, hour: hour, minute: minute}, true
+}
+
+func parseHour(text string, sub []int) (match, bool) {
+	hour, _ := strconv.Atoi(group(text, sub, 1))
+	hour, ok := applyMeridiem(hour, group(text, sub, 2))
+	if !ok {
+		return match{}, false
+	}
+	return match{kind: partClock, hour: hour}, true
+}
+
+func parseNamedClock(text string, sub []int) (match, bool) {
+	switch group(text, sub, 1) {
+	case "полдень", "noon":
+		return match{kind: partClock, hour: 12}, true
+	default:
+		return match{kind: partClock, hour: 0, nextDay: true}, true
+	}
+}
+
+func parseDayTime(text string, sub []int) (match, bool) {
+	hour := 9
+	switch group(text, sub, 1) {
+	case "днём", "днем", "in the afternoon", "afternoon":
+		hour = 14
+	case "вечером", "in the evening", "evening":
+		hour = 19
+	case "ночью", "at night":
+		hour = 23
+	}
+	return match{kind: partDayTime, hour: hour}, true
+}
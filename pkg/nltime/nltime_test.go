@@ -0,0 +1,142 @@
+package nltime
+
+import (
+	"testing"
+	"time"
+)
+
+// Monday, 19 Oct 2026 10:00 MSK
+var testNow = time.Date(2026, time.October, 19, 10, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, testNow.Location())
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected time.Time
+		hasTime  bool
+	}{
+		{"через 5 минут", testNow.Add(5 * time.Minute), true},
+		{"через час", testNow.Add(time.Hour), true},
+		{"через полчаса", testNow.Add(30 * time.Minute), true},
+		{"через две недели", at(time.November, 2, 10, 0), true},
+		{"через 2 дня в 9:15", at(time.October, 21, 9, 15), true},
+		{"in 3 hours", testNow.Add(3 * time.Hour), true},
+		{"in a month", at(time.November, 19, 10, 0), true},
+		{"в 15:30", at(time.October, 19, 15, 30), true},
+		{"в 9:00", at(time.October, 20, 9, 0), true},
+		{"at 3pm", at(time.October, 19, 15, 0), true},
+		{"в 7 вечера", at(time.October, 19, 19, 0), true},
+		{"в полдень", at(time.October, 19, 12, 0), true},
+		{"в полночь", at(time.October, 20, 0, 0), true},
+		{"завтра", at(time.October, 20, 0, 0), false},
+		{"послезавтра в 10:00", at(time.October, 21, 10, 0), true},
+		{"tomorrow at 8 am", at(time.October, 20, 8, 0), true},
+		{"day after tomorrow", at(time.October, 21, 0, 0), false},
+		{"завтра вечером", at(time.October, 20, 19, 0), true},
+		{"завтра вечером в 7", at(time.October, 20, 19, 0), true},
+		{"в пятницу", at(time.October, 23, 0, 0), false},
+		{"в понедельник", at(time.October, 26, 0, 0), false},
+		{"в понедельник в 12:00", at(time.October, 19, 12, 0), true},
+		{"в понедельник в 9:00", at(time.October, 26, 9, 0), true},
+		{"on sunday at 11:00", at(time.October, 25, 11, 0), true},
+		{"12 марта", time.Date(2027, time.March, 12, 0, 0, 0, 0, testNow.Location()), false},
+		{"12 ноября в 18:00", at(time.November, 12, 18, 0), true},
+		{"1 января 2028", time.Date(2028, time.January, 1, 0, 0, 0, 0, testNow.Location()), false},
+		{"december 5th", at(time.December, 5, 0, 0), false},
+		{"25.12", at(time.December, 25, 0, 0), false},
+		{"25.12.2026 в 20:00", at(time.December, 25, 20, 0), true},
+		{"25 числа", at(time.October, 25, 0, 0), false},
+		{"29 февраля", time.Date(2028, time.February, 29, 0, 0, 0, 0, testNow.Location()), false},
+		{"29.02.2028", time.Date(2028, time.February, 29, 0, 0, 0, 0, testNow.Location()), false},
+	}
+	for _, c := range cases {
+		res, found := Parse(c.text, testNow)
+		if !found {
+			t.Errorf("'%s': nothing found", c.text)
+			continue
+		}
+		if !res.Time.Equal(c.expected) || res.HasTime != c.hasTime {
+			t.Errorf("'%s': got %s (time %v), expected %s (time %v)", c.text, res.Time, res.HasTime, c.expected, c.hasTime)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	cases := map[string]string{
+		"напомни завтра в 15:30 купить молоко":   "напомни купить молоко",
+		"погода в Москве послезавтра":            "погода в Москве",
+		"remind me to call mom tomorrow at 5 pm": "remind me to call mom",
+		"через 10 минут выключить чайник":        "выключить чайник",
+	}
+	for text, expected := range cases {
+		res, found := Parse(text, testNow)
+		if !found {
+			t.Errorf("'%s': nothing found", text)
+			continue
+		}
+		if stripped := res.Strip(text); stripped != expected {
+			t.Errorf("'%s': stripped to '%s', expected '%s'", text, stripped, expected)
+		}
+	}
+}
+
+func TestNothingFound(t *testing.T) {
+	for _, text := range []string{"погода в Москве", "купить 2 молока", "remind me in Paris", "версия 1.50",
+		"31.02.2027", "31 февраля", "29.02.2027", "april 31"} {
+		if res, found := Parse(text, testNow); found {
+			t.Errorf("'%s': unexpectedly found %s", text, res.Time)
+		}
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	jan31 := time.Date(2027, time.January, 31, 9, 0, 0, 0, time.UTC)
+	if res := AddMonths(jan31, 1); !res.Equal(time.Date(2027, time.February, 28, 9, 0, 0, 0, time.UTC)) {
+		t.Fatal(res)
+	}
+	if res := AddMonths(jan31, 13); !res.Equal(time.Date(2028, time.February, 29, 9, 0, 0, 0, time.UTC)) {
+		t.Fatal(res)
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected Recurrence
+	}{
+		{"каждый понедельник в 9:00", Recurrence{Every: 1, Unit: Week, Weekday: time.Monday, HasWeekday: true}},
+		{"каждую среду", Recurrence{Every: 1, Unit: Week, Weekday: time.Wednesday, HasWeekday: true}},
+		{"по пятницам", Recurrence{Every: 1, Unit: Week, Weekday: time.Friday, HasWeekday: true}},
+		{"every 2 weeks", Recurrence{Every: 2, Unit: Week}},
+		{"каждый день в 8:00", Recurrence{Every: 1, Unit: Day}},
+		{"каждые три часа", Recurrence{Every: 3, Unit: Hour}},
+		{"every month", Recurrence{Every: 1, Unit: Month}},
+		{"ежедневно", Recurrence{Every: 1, Unit: Day}},
+	}
+	for _, c := range cases {
+		r, found := ParseRecurrence(c.text)
+		r.Span = [2]int{}
+		if !found || r != c.expected {
+			t.Errorf("'%s': got %+v (found %v), expected %+v", c.text, r, found, c.expected)
+		}
+	}
+	if r, found := ParseRecurrence("через неделю"); found {
+		t.Errorf("unexpectedly found %+v", r)
+	}
+}
+
+func TestRecurrenceFirst(t *testing.T) {
+	monday := Recurrence{Every: 1, Unit: Week, Weekday: time.Monday, HasWeekday: true}
+	if first := monday.First(testNow, 9, 0); !first.Equal(at(time.October, 26, 9, 0)) {
+		t.Fatal(first)
+	}
+	if first := monday.First(testNow, 12, 0); !first.Equal(at(time.October, 19, 12, 0)) {
+		t.Fatal(first)
+	}
+	if next := monday.Next(at(time.October, 19, 12, 0)); !next.Equal(at(time.October, 26, 12, 0)) {
+		t.Fatal(next)
+	}
+}
@@ -0,0 +1,153 @@
+package nltime
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unit is a unit of a recurrence period
+type Unit int
+
+const (
+	Minute Unit = iota
+	Hour
+	Day
+	Week
+	Month
+	Year
+)
+
+// Recurrence is a repeating schedule like "каждый понедельник" or "every 2 weeks"
+type Recurrence struct {
+	Every int
+	Unit  Unit
+	// Weekday is set for weekly recurrences bound to a day of a week
+	Weekday    time.Weekday
+	HasWeekday bool
+	// Span is a byte range [start, end) of the text forming the expression
+	Span [2]int
+}
+
+var recurrencePatterns = []struct {
+	re    *regexp.Regexp
+	parse func(text string, sub []int) (Recurrence, bool)
+}{
+	{regexp.MustCompile(`(?i)(?:кажд\p{L}*|every)\s+(?:(\d+|\p{L}+)\s+)?` + unitRe), parseEveryUnit},
+	{regexp.MustCompile(`(?i)(?:кажд\p{L}*|every)\s+` + weekdayRe), parseEveryWeekday},
+	{regexp.MustCompile(`(?i)по\s+(понедельникам|вторникам|средам|четвергам|пятницам|субботам|воскресеньям)`), parseEveryWeekday},
+	{regexp.MustCompile(`(?i)(ежедневно|daily|еженедельно|weekly|ежемесячно|monthly|ежегодно|yearly|annually)`), parseEveryWord},
+}
+
+// ParseRecurrence finds a recurrence expression in the text
+func ParseRecurrence(text string) (Recurrence, bool) {
+	for _, p := range recurrencePatterns {
+		for _, sub := range p.re.FindAllStringSubmatchIndex(text, -1) {
+			if !isWordBounded(text, sub[0], sub[1]) {
+				continue
+			}
+			r, ok := p.parse(text, sub)
+			if !ok {
+				continue
+			}
+			r.Span = [2]int{sub[0], sub[1]}
+			return r, true
+		}
+	}
+	return Recurrence{}, false
+}
+
+// Strip removes the recurrence expression from the text it has been found in
+func (r Recurrence) Strip(text string) string {
+	return Result{Spans: [][2]int{r.Span}}.Strip(text)
+}
+
+// Next returns the next moment of the schedule after t
+func (r Recurrence) Next(t time.Time) time.Time {
+	switch r.Unit {
+	case Minute:
+		return t.Add(time.Duration(r.Every) * time.Minute)
+	case Hour:
+		return t.Add(time.Duration(r.Every) * time.Hour)
+	case Day:
+		return t.AddDate(0, 0, r.Every)
+	case Week:
+		return t.AddDate(0, 0, 7*r.Every)
+	case Month:
+		return AddMonths(t, r.Every)
+	default:
+		return AddMonths(t, 12*r.Every)
+	}
+}
+
+// First returns the first moment of the schedule after now at the time of a day
+func (r Recurrence) First(now time.Time, hour, minute int) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	for !t.After(now) || (r.HasWeekday && t.Weekday() != r.Weekday) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+func parseEveryUnit(text string, sub []int) (Recurrence, bool) {
+	q := 1
+	if qs := group(text, sub, 1); qs != "" {
+		var err error
+		if q, err = strconv.Atoi(qs); err != nil {
+			var known bool
+			if q, known = numberWords[qs]; !known {
+				return Recurrence{}, false
+			}
+		}
+	}
+	if q < 1 {
+		return Recurrence{}, false
+	}
+	unit := group(text, sub, 2)
+	r := Recurrence{Every: q}
+	switch {
+	case unit == "полчаса":
+		r.Every, r.Unit = 30*q, Minute
+	case strings.HasPrefix(unit, "сек") || strings.HasPrefix(unit, "sec"):
+		return Recurrence{}, false
+	case strings.HasPrefix(unit, "мин") || strings.HasPrefix(unit, "min"):
+		r.Unit = Minute
+	case strings.HasPrefix(unit, "час") || strings.HasPrefix(unit, "hour"):
+		r.Unit = Hour
+	case strings.HasPrefix(unit, "дн") || unit == "день" || strings.HasPrefix(unit, "сут") || strings.HasPrefix(unit, "day"):
+		r.Unit = Day
+	case strings.HasPrefix(unit, "недел") || strings.HasPrefix(unit, "week"):
+		r.Unit = Week
+	case strings.HasPrefix(unit, "месяц") || strings.HasPrefix(unit, "month"):
+		r.Unit = Month
+	default:
+		r.Unit = Year
+	}
+	return r, true
+}
+
+func parseEveryWeekday(text string, sub []int) (Recurrence, bool) {
+	name := group(text, sub, 1)
+	for _, p := range weekdayPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return Recurrence{Every: 1, Unit: Week, Weekday: p.weekday, HasWeekday: true}, true
+		}
+	}
+	return Recurrence{}, false
+}
+
+func parseEveryWord(text string, sub []int) (Recurrence, bool) {
+	r := Recurrence{Every: 1}
+	switch group(text, sub, 1) {
+	case "ежедневно", "daily":
+		r.Unit = Day
+	case "еженедельно", "weekly":
+		r.Unit = Week
+	case "ежемесячно", "monthly":
+		r.Unit = Month
+	default:
+		r.Unit = Year
+	}
+	return r, true
+}
@@ -1,6 +1,9 @@
 package tgbotbase
 
 import (
+	"context"
+	"expvar"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
@@ -9,18 +12,18 @@ import (
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
-var botUserName string
+type Bot struct {
+	dealers  []MessageDealer
+	cfg      Config
+	userName string
+	metrics  *expvar.Map
 
-func thisBotUserName() string {
-	if botUserName == "" {
-		panic("bot username not yet initialized")
-	}
-	return botUserName
-}
+	outbox    Outbox
+	outboxSeq int
 
-type Bot struct {
-	dealers []MessageDealer
-	cfg     Config
+	chats      ChatRegistry
+	properties PropertyStorage
+	keys       KeyPrefix
 
 	bot         *tgbotapi.BotAPI
 	botChannels struct {
@@ -32,7 +35,8 @@ type Bot struct {
 
 func NewBot(cfg Config) *Bot {
 	b := &Bot{dealers: make([]MessageDealer, 0),
-		cfg: cfg}
+		cfg:     cfg,
+		metrics: new(expvar.Map).Init()}
 
 	botToken := cfg.TGBot.Token
 	log.Printf("Setting up a bot with token: %s", botToken)
@@ -69,8 +73,8 @@ func NewBot(cfg Config) *Bot {
 		}
 	}
 
-	botUserName = b.bot.Self.UserName
-	log.Printf("Authorized on account %s", botUserName)
+	b.userName = b.bot.Self.UserName
+	log.Printf("Authorized on account %s", b.userName)
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
@@ -84,9 +88,14 @@ func NewBot(cfg Config) *Bot {
 	return b
 }
 
+// UserName returns Telegram username of the bot; it is empty if the bot has not connected to Telegram
+func (b *Bot) UserName() string {
+	return b.userName
+}
+
 func (b *Bot) AddHandler(d MessageDealer) {
 	log.Printf("Preparing '%s' handler", d.name())
-	d.init(b.botChannels.out_msg_chan, b.botChannels.service_chan)
+	d.init(b)
 	b.dealers = append(b.dealers, d)
 }
 
@@ -103,9 +112,14 @@ func (b *Bot) Start() {
 		select {
 		case update := <-b.botChannels.in_msg_chan:
 			log.Printf("Received an update from tgbotapi")
+			b.metrics.Add("updates", 1)
 			if b.cfg.TGBot.Verbose {
 				dumpUpdate(update)
 			}
+			if update.CallbackQuery != nil {
+				b.dispatchCallback(*update.CallbackQuery)
+				continue
+			}
 			if update.Message == nil {
 				log.Print("Message: empty. Skipping")
 				continue
@@ -125,27 +139,242 @@ func (b *Bot) Start() {
 	log.Print("Main cycle has been aborted")
 }
 
+// callbackDealer is a dealer which may process presses of inline keyboard buttons
+type callbackDealer interface {
+	acceptCallback(tgbotapi.CallbackQuery) bool
+}
+
+func (b *Bot) dispatchCallback(q tgbotapi.CallbackQuery) {
+	log.Printf("Received callback query with data '%s'", q.Data)
+	b.metrics.Add("callbacks", 1)
+	for _, d := range b.dealers {
+		if cd, ok := d.(callbackDealer); ok && cd.acceptCallback(q) {
+			return
+		}
+	}
+	log.Printf("No handler for callback data '%s'", q.Data)
+	b.answerCallback(q, "")
+}
+
+// answerCallback stops the loading indicator on the pressed button; it is answered directly as Telegram expects it quickly
+func (b *Bot) answerCallback(q tgbotapi.CallbackQuery, text string) {
+	if b.bot == nil || b.cfg.TGBot.RedirectMsgToLog {
+		log.Printf("Callback answer: %s", text)
+		return
+	}
+	if _, err := b.bot.AnswerCallbackQuery(tgbotapi.NewCallback(q.ID, text)); err != nil {
+		log.Printf("Could not answer callback query due to error: %s", err)
+	}
+}
+
 func (b *Bot) Send(msg tgbotapi.Chattable) {
 	b.botChannels.out_msg_chan <- msg
 }
 
+// SendWithAck sends the message and returns a channel with its delivery result
+func (b *Bot) SendWithAck(msg tgbotapi.Chattable) <-chan Delivery {
+	return SendWithAck(b.botChannels.out_msg_chan, msg)
+}
+
+// SetOutbox makes text messages durable: they are stored in the outbox until delivered and resent after restart
+func (b *Bot) SetOutbox(o Outbox) {
+	b.outbox = o
+}
+
+// SetChatRegistry enables chat tracking: chats and members are recorded, messages to chats
+// the bot has been removed from are dropped
+func (b *Bot) SetChatRegistry(r ChatRegistry) {
+	b.chats = r
+	b.AddHandler(NewEngagementMessageDealer(NewChatTracker(r)))
+}
+
+// ChatRegistry returns registry of the bot chats; nil if chats are not tracked
+func (b *Bot) ChatRegistry() ChatRegistry {
+	return b.chats
+}
+
+// Properties returns property storage of the bot; nil if Redis is not configured
+func (b *Bot) Properties() PropertyStorage {
+	return b.properties
+}
+
+// KeyPrefix is prepended to Redis keys of the bot data, so bots of one kind at a host keep their data apart
+func (b *Bot) KeyPrefix() KeyPrefix {
+	return b.keys
+}
+
+// outgoingMsg is a message in the sending queue together with its delivery state
+type outgoingMsg struct {
+	msg      tgbotapi.Chattable
+	ack      chan Delivery
+	record   *OutboxRecord
+	attempts int
+}
+
 func (b *Bot) serveReplies() {
 	log.Print("Started serving replies")
-	msg, notClosed := <-b.botChannels.out_msg_chan
-	for ; notClosed; msg, notClosed = <-b.botChannels.out_msg_chan {
+	retryCh := make(chan outgoingMsg)
+
+	if b.outbox != nil {
+		pending, err := b.outbox.Pending(context.TODO())
+		if err != nil {
+			log.Printf("Could not load pending outbox messages due to error: %s", err)
+		}
+		log.Printf("Loaded %d pending outbox messages", len(pending))
+		for i := range pending {
+			rec := pending[i]
+			go func() {
+				retryCh <- outgoingMsg{msg: rec.Message, record: &rec, attempts: rec.Attempts}
+			}()
+		}
+	}
+
+	for {
+		var out outgoingMsg
+		select {
+		case msg, notClosed := <-b.botChannels.out_msg_chan:
+			if !notClosed {
+				log.Print("Finished serving replies")
+				return
+			}
+			out = b.newOutgoing(msg)
+		case out = <-retryCh:
+		}
+
 		log.Printf("Will send a reply")
+		if !b.chatActive(out.msg) {
+			log.Printf("Bot has been removed from the chat, dropping reply %+v", out.msg)
+			b.metrics.Add("dropped_inactive", 1)
+			b.dropped(out, ErrChatInactive)
+			continue
+		}
 		if b.cfg.TGBot.RedirectMsgToLog {
-			log.Printf("Reply: +%v", msg)
+			log.Printf("Reply: +%v", out.msg)
+			b.delivered(out, tgbotapi.Message{})
+			continue
+		}
+
+		sent, err := b.send(out.msg)
+		if err != nil {
+			log.Printf("Could not sent reply %+v due to error: %s", out.msg, err)
+			b.metrics.Add("send_errors", 1)
+			b.failed(out, err, retryCh)
 		} else {
-			_, err := b.bot.Send(msg)
-			if err != nil {
-				log.Printf("Could not sent reply %+v due to error: %s", msg, err)
+			b.metrics.Add("sent", 1)
+			b.delivered(out, sent)
+		}
+		time.Sleep(1 * time.Second) // just in case I accidentally start spamming Telegram API
+	}
+}
+
+func (b *Bot) send(msg tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if mg, ok := msg.(*MediaGroup); ok {
+		return b.sendMediaGroup(mg)
+	}
+	return b.bot.Send(msg)
+}
+
+func (b *Bot) newOutgoing(msg tgbotapi.Chattable) outgoingMsg {
+	out := outgoingMsg{msg: msg}
+	if tracked, ok := msg.(*trackedMessage); ok {
+		out.msg = tracked.Chattable
+		out.ack = tracked.ack
+	}
+
+	if b.outbox == nil {
+		return out
+	}
+	text, ok := out.msg.(tgbotapi.MessageConfig)
+	if !ok {
+		return out
+	}
+	b.outboxSeq++
+	rec := OutboxRecord{
+		ID:      fmt.Sprintf("%d-%d", time.Now().UnixNano(), b.outboxSeq),
+		Created: time.Now(),
+		Message: text}
+	if err := b.outbox.Put(context.TODO(), rec); err != nil {
+		log.Printf("Could not store message in outbox, sending without persistence; error: %s", err)
+		return out
+	}
+	out.record = &rec
+	return out
+}
+
+func (b *Bot) delivered(out outgoingMsg, sent tgbotapi.Message) {
+	if out.record != nil {
+		if err := b.outbox.Remove(context.TODO(), out.record.ID); err != nil {
+			log.Printf("Could not remove delivered message '%s' from outbox due to error: %s", out.record.ID, err)
+		}
+	}
+	if out.ack != nil {
+		out.ack <- Delivery{Message: sent}
+	}
+}
+
+func (b *Bot) chatActive(msg tgbotapi.Chattable) bool {
+	if b.chats == nil {
+		return true
+	}
+	chat, known := chatOf(msg)
+	if !known {
+		return true
+	}
+	active, err := b.chats.IsActive(context.TODO(), chat)
+	if err != nil {
+		log.Printf("Could not check if chat %d is active, error: %s", chat, err)
+	}
+	return active
+}
+
+func (b *Bot) dropped(out outgoingMsg, err error) {
+	if out.record != nil {
+		if err := b.outbox.Remove(context.TODO(), out.record.ID); err != nil {
+			log.Printf("Could not remove dropped message '%s' from outbox due to error: %s", out.record.ID, err)
+		}
+	}
+	if out.ack != nil {
+		out.ack <- Delivery{Err: err}
+	}
+}
+
+func (b *Bot) failed(out outgoingMsg, err error, retryCh chan<- outgoingMsg) {
+	if b.chats != nil && isKickedError(err) {
+		if chat, known := chatOf(out.msg); known {
+			log.Printf("Bot has been kicked from chat %d, marking it as removed", chat)
+			if err := b.chats.BotRemoved(context.TODO(), chat); err != nil {
+				log.Printf("Could not mark chat %d as removed due to error: %s", chat, err)
+			}
+		}
+	}
+	out.attempts++
+	if out.attempts >= outboxMaxAttempts || isPermanentSendError(err) {
+		log.Printf("Giving up sending a message after %d attempts, last error: %s", out.attempts, err)
+		b.metrics.Add("dead_letters", 1)
+		if out.record != nil {
+			out.record.Attempts = out.attempts
+			if err := b.outbox.DeadLetter(context.TODO(), *out.record, err.Error()); err != nil {
+				log.Printf("Could not move message '%s' to dead letters due to error: %s", out.record.ID, err)
 			}
-			time.Sleep(1 * time.Second) // just in case I accidentally start spamming Telegram API
 		}
+		if out.ack != nil {
+			out.ack <- Delivery{Err: fmt.Errorf("%w: %s", ErrNotSent, err)}
+		}
+		return
 	}
 
-	log.Print("Finished serving replies")
+	if out.record != nil {
+		out.record.Attempts = out.attempts
+		if err := b.outbox.Put(context.TODO(), *out.record); err != nil {
+			log.Printf("Could not update outbox message '%s' due to error: %s", out.record.ID, err)
+		}
+	}
+	backoff := outboxBackoff(out.attempts, err)
+	log.Printf("Message will be resent in %s (attempt %d)", backoff, out.attempts+1)
+	b.metrics.Add("retries", 1)
+	time.AfterFunc(backoff, func() {
+		retryCh <- out
+	})
 }
 
 func dumpUpdate(update tgbotapi.Update) {
@@ -6,6 +6,7 @@ type Config struct {
 		SkipConnect      bool
 		Verbose          bool
 		RedirectMsgToLog bool
+		DurableOutbox    bool // requires Redis; keeps undelivered text messages across restarts
 	}
 
 	Proxy_SOCKS5 struct {
@@ -0,0 +1,206 @@
+package tgbotbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// ChatInfo describes a chat the bot has ever been in
+type ChatInfo struct {
+	ID      ChatID
+	Title   string
+	Type    string
+	Active  bool // false if the bot has been removed from the chat
+	Members int  // members seen by the bot
+	Updated time.Time
+}
+
+// ChatRegistry keeps track of chats the bot is in and of their members
+type ChatRegistry interface {
+	ChatSeen(ctx context.Context, chat *tgbotapi.Chat) error
+	BotRemoved(ctx context.Context, chat ChatID) error
+	MemberJoined(ctx context.Context, chat ChatID, user *tgbotapi.User) error
+	MemberLeft(ctx context.Context, chat ChatID, user *tgbotapi.User) error
+	IsActive(ctx context.Context, chat ChatID) (bool, error)
+	Chats(ctx context.Context) ([]ChatInfo, error)
+}
+
+// ErrChatInactive is reported for messages to chats the bot has been removed from
+var ErrChatInactive = errors.New("bot has been removed from the chat")
+
+// chatSeenRefresh is how often the registry is updated for a chat with unchanged title and type
+const chatSeenRefresh = time.Hour
+
+// chatTracker feeds the registry with chat membership changes
+type chatTracker struct {
+	registry ChatRegistry
+
+	// seen keeps chats recently written to the registry, so most messages do not touch the registry
+	mutex sync.Mutex
+	seen  map[int64]seenChat
+}
+
+type seenChat struct {
+	title, kind string
+	at          time.Time
+}
+
+var _ EngagementHandler = &chatTracker{}
+var _ MembershipHandler = &chatTracker{}
+
+// NewChatTracker creates a handler for EngagementMessageDealer which records chats and members into the registry
+func NewChatTracker(registry ChatRegistry) *chatTracker {
+	return &chatTracker{registry: registry, seen: make(map[int64]seenChat)}
+}
+
+// recentlySeen tells if the chat has been written to the registry lately with the same title and type,
+// remembering it as written otherwise
+func (t *chatTracker) recentlySeen(chat *tgbotapi.Chat) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := time.Now()
+	s, found := t.seen[chat.ID]
+	if found && s.title == chat.Title && s.kind == chat.Type && now.Sub(s.at) < chatSeenRefresh {
+		return true
+	}
+	t.seen[chat.ID] = seenChat{title: chat.Title, kind: chat.Type, at: now}
+	return false
+}
+
+func (t *chatTracker) forget(chat *tgbotapi.Chat) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.seen, chat.ID)
+}
+
+func (t *chatTracker) Name() string {
+	return "chat tracker"
+}
+
+func (t *chatTracker) Engaged(chat *tgbotapi.Chat, user *tgbotapi.User) {
+	log.Printf("Chat tracker: bot has been added to chat %d '%s' by %s", chat.ID, chat.Title, user.UserName)
+	if err := t.registry.ChatSeen(context.TODO(), chat); err != nil {
+		log.Printf("Chat tracker: could not register chat %d due to error: %s", chat.ID, err)
+	}
+}
+
+func (t *chatTracker) Disengaged(chat *tgbotapi.Chat, user *tgbotapi.User) {
+	log.Printf("Chat tracker: bot has been removed from chat %d '%s' by %s", chat.ID, chat.Title, user.UserName)
+	t.forget(chat)
+	if err := t.registry.BotRemoved(context.TODO(), ChatID(chat.ID)); err != nil {
+		log.Printf("Chat tracker: could not mark chat %d as removed due to error: %s", chat.ID, err)
+	}
+}
+
+func (t *chatTracker) Seen(chat *tgbotapi.Chat) {
+	if t.recentlySeen(chat) {
+		return
+	}
+	if err := t.registry.ChatSeen(context.TODO(), chat); err != nil {
+		log.Printf("Chat tracker: could not update chat %d due to error: %s", chat.ID, err)
+		t.forget(chat)
+	}
+}
+
+func (t *chatTracker) Joined(chat *tgbotapi.Chat, user *tgbotapi.User) {
+	if err := t.registry.MemberJoined(context.TODO(), ChatID(chat.ID), user); err != nil {
+		log.Printf("Chat tracker: could not add member %d to chat %d due to error: %s", user.ID, chat.ID, err)
+	}
+}
+
+func (t *chatTracker) Left(chat *tgbotapi.Chat, user *tgbotapi.User) {
+	if err := t.registry.MemberLeft(context.TODO(), ChatID(chat.ID), user); err != nil {
+		log.Printf("Chat tracker: could not remove member %d from chat %d due to error: %s", user.ID, chat.ID, err)
+	}
+}
+
+// chatsHandler lists known chats to bot owners by /chats command
+type chatsHandler struct {
+	BaseHandler
+	registry ChatRegistry
+	owners   map[string]bool
+}
+
+var _ IncomingMessageHandler = &chatsHandler{}
+
+// NewChatsHandler creates /chats command handler; owners are Telegram usernames allowed to use it.
+// Registry is nil if chats are not tracked, then owners are told that tracking requires Redis
+func NewChatsHandler(registry ChatRegistry, owners []string) *chatsHandler {
+	h := &chatsHandler{
+		registry: registry,
+		owners:   make(map[string]bool, len(owners))}
+	for _, o := range owners {
+		h.owners[o] = true
+	}
+	return h
+}
+
+func (h *chatsHandler) Init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- ServiceMsg) HandlerTrigger {
+	h.OutMsgCh = outMsgCh
+	return NewHandlerTrigger(nil, []string{"chats"})
+}
+
+func (h *chatsHandler) Name() string {
+	return "chats"
+}
+
+func (h *chatsHandler) HandleOne(msg tgbotapi.Message) {
+	// channel posts have no sender
+	if msg.From == nil || !h.owners[msg.From.UserName] {
+		log.Printf("Sender of the message is not in the list of owners, skipping /chats request")
+		return
+	}
+	if h.registry == nil {
+		h.Replier().Reply(msg, "chat tracking requires Redis")
+		return
+	}
+
+	chats, err := h.registry.Chats(context.TODO())
+	if err != nil {
+		log.Printf("Could not load chats due to error: %s", err)
+		h.Replier().Reply(msg, "could not load chats")
+		return
+	}
+	sort.Slice(chats, func(i, j int) bool {
+		if chats[i].Active != chats[j].Active {
+			return chats[i].Active
+		}
+		return chats[i].Updated.After(chats[j].Updated)
+	})
+
+	lines := make([]string, 0, len(chats)+1)
+	lines = append(lines, fmt.Sprintf("Chats: %d", len(chats)))
+	for _, c := range chats {
+		state := "active"
+		if !c.Active {
+			state = "removed"
+		}
+		lines = append(lines, fmt.Sprintf("%d [%s] %s; members seen: %d; %s since %s", c.ID, c.Type, c.Title, c.Members, state, c.Updated.Format("2006-01-02")))
+	}
+	h.Replier().Reply(msg, strings.Join(lines, "\n"))
+}
+
+// chatOf returns chat ID of a message if it is known for this type of message
+func chatOf(msg tgbotapi.Chattable) (ChatID, bool) {
+	switch m := msg.(type) {
+	case tgbotapi.MessageConfig:
+		return ChatID(m.ChatID), true
+	case tgbotapi.PhotoConfig:
+		return ChatID(m.ChatID), true
+	case tgbotapi.DocumentConfig:
+		return ChatID(m.ChatID), true
+	case tgbotapi.LocationConfig:
+		return ChatID(m.ChatID), true
+	case *MediaGroup:
+		return m.Chat, true
+	}
+	return 0, false
+}
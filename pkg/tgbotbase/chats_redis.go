@@ -0,0 +1,109 @@
+package tgbotbase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+type RedisChatRegistry struct {
+	client  *redis.Client
+	botName string
+}
+
+var _ ChatRegistry = &RedisChatRegistry{}
+
+func NewRedisChatRegistry(pool RedisPool, botName string) *RedisChatRegistry {
+	return &RedisChatRegistry{
+		client:  pool.GetConnByName("chats"),
+		botName: botName}
+}
+
+func (r *RedisChatRegistry) chatKey(chat ChatID) string {
+	return fmt.Sprintf("chats:%s:chat:%d", r.botName, chat)
+}
+
+func (r *RedisChatRegistry) membersKey(chat ChatID) string {
+	return fmt.Sprintf("chats:%s:members:%d", r.botName, chat)
+}
+
+func (r *RedisChatRegistry) ChatSeen(ctx context.Context, chat *tgbotapi.Chat) error {
+	title := chat.Title
+	if title == "" {
+		title = strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+	}
+	key := r.chatKey(ChatID(chat.ID))
+	active, err := r.client.HGet(ctx, key, "active").Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	fields := map[string]interface{}{
+		"title": title,
+		"type":  chat.Type,
+	}
+	if active != "1" { // keeping time of the last activity change
+		fields["active"] = "1"
+		fields["updated"] = time.Now().Unix()
+	}
+	return r.client.HSet(ctx, key, fields).Err()
+}
+
+func (r *RedisChatRegistry) BotRemoved(ctx context.Context, chat ChatID) error {
+	return r.client.HSet(ctx, r.chatKey(chat), "active", "0", "updated", time.Now().Unix()).Err()
+}
+
+func (r *RedisChatRegistry) MemberJoined(ctx context.Context, chat ChatID, user *tgbotapi.User) error {
+	return r.client.HSet(ctx, r.membersKey(chat), strconv.Itoa(user.ID), user.UserName).Err()
+}
+
+func (r *RedisChatRegistry) MemberLeft(ctx context.Context, chat ChatID, user *tgbotapi.User) error {
+	return r.client.HDel(ctx, r.membersKey(chat), strconv.Itoa(user.ID)).Err()
+}
+
+func (r *RedisChatRegistry) IsActive(ctx context.Context, chat ChatID) (bool, error) {
+	active, err := r.client.HGet(ctx, r.chatKey(chat), "active").Result()
+	if err == redis.Nil {
+		return true, nil // unknown chats are considered to be active
+	}
+	if err != nil {
+		return true, err
+	}
+	return active != "0", nil
+}
+
+func (r *RedisChatRegistry) Chats(ctx context.Context) ([]ChatInfo, error) {
+	prefix := fmt.Sprintf("chats:%s:chat:", r.botName)
+	keys, err := GetAllKeys(ctx, r.client, prefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ChatInfo, 0, len(keys))
+	for _, k := range keys {
+		id, err := strconv.ParseInt(strings.TrimPrefix(k, prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		fields, err := r.client.HGetAll(ctx, k).Result()
+		if err != nil {
+			return nil, err
+		}
+		members, err := r.client.HLen(ctx, r.membersKey(ChatID(id))).Result()
+		if err != nil {
+			return nil, err
+		}
+		updated, _ := strconv.ParseInt(fields["updated"], 10, 64)
+		result = append(result, ChatInfo{
+			ID:      ChatID(id),
+			Title:   fields["title"],
+			Type:    fields["type"],
+			Active:  fields["active"] != "0",
+			Members: int(members),
+			Updated: time.Unix(updated, 0)})
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package tgbotbase
+
+import (
+	"context"
+	"testing"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// countingRegistry counts ChatSeen calls; other methods are not used by the tests
+type countingRegistry struct {
+	ChatRegistry
+	seen int
+}
+
+func (r *countingRegistry) ChatSeen(ctx context.Context, chat *tgbotapi.Chat) error {
+	r.seen++
+	return nil
+}
+
+func TestChatTrackerSeenOnce(t *testing.T) {
+	registry := &countingRegistry{}
+	tracker := NewChatTracker(registry)
+	chat := &tgbotapi.Chat{ID: 1, Type: "group", Title: "chat"}
+	for i := 0; i < 3; i++ {
+		tracker.Seen(chat)
+	}
+	if registry.seen != 1 {
+		t.Errorf("expected the chat to be written once, got %d", registry.seen)
+	}
+
+	renamed := &tgbotapi.Chat{ID: 1, Type: "group", Title: "renamed"}
+	tracker.Seen(renamed)
+	tracker.Seen(&tgbotapi.Chat{ID: 2, Type: "private"})
+	if registry.seen != 3 {
+		t.Errorf("expected renamed and new chats to be written, got %d writes", registry.seen)
+	}
+}
+
+func TestChatsHandlerWithoutRegistry(t *testing.T) {
+	out := make(chan tgbotapi.Chattable, 1)
+	h := NewChatsHandler(nil, []string{"owner"})
+	h.Init(out, nil)
+
+	h.HandleOne(tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}, Text: "/chats"})
+	h.HandleOne(tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}, From: &tgbotapi.User{UserName: "owner"}, Text: "/chats"})
+	select {
+	case msg := <-out:
+		tracked := msg.(*trackedMessage)
+		if reply, ok := tracked.Chattable.(tgbotapi.MessageConfig); !ok || reply.Text != "chat tracking requires Redis" {
+			t.Errorf("unexpected reply %+v", msg)
+		}
+	default:
+		t.Error("expected a reply to the owner")
+	}
+}
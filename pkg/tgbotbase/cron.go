@@ -10,6 +10,8 @@ import (
 // Cron interface declares interfaces for communication with some cron daemon
 type Cron interface {
 	AddJob(when time.Time, job CronJob)
+	// RemoveJob cancels the job scheduled at the time; job should be the same value which has been passed to AddJob
+	RemoveJob(when time.Time, job CronJob)
 }
 
 // CronJob provides a piece of work which should be done once its time has come
@@ -23,8 +25,9 @@ type cronJobDesc struct {
 }
 
 type cron struct {
-	newJobCh chan cronJobDesc
-	timer    *time.Timer
+	newJobCh    chan cronJobDesc
+	removeJobCh chan cronJobDesc
+	timer       *time.Timer
 
 	jobs           map[time.Time][]CronJob
 	sortedJobTimes []time.Time
@@ -38,6 +41,12 @@ func (c *cron) AddJob(t time.Time, job CronJob) {
 		job:      job}
 }
 
+func (c *cron) RemoveJob(t time.Time, job CronJob) {
+	c.removeJobCh <- cronJobDesc{
+		execTime: t,
+		job:      job}
+}
+
 func (c *cron) executeJobs(jobsToExecute map[time.Time][]CronJob, now time.Time) {
 	for scheduledTime, jobs := range jobsToExecute {
 		log.Printf("cron: Executing %d jobs at time %s (scheduled %s; diff %s)", len(jobs), now, scheduledTime, now.Sub(scheduledTime))
@@ -62,6 +71,38 @@ func (c *cron) processNewJob(execTime time.Time, job CronJob) {
 	}
 }
 
+func (c *cron) processRemoveJob(execTime time.Time, job CronJob) {
+	jobs, found := c.jobs[execTime]
+	if !found {
+		log.Printf("cron: No jobs at time %s to remove", execTime)
+		return
+	}
+	left := make([]CronJob, 0, len(jobs))
+	for _, j := range jobs {
+		if j != job {
+			left = append(left, j)
+		}
+	}
+	if len(left) == len(jobs) {
+		log.Printf("cron: Job to remove has not been found at time %s", execTime)
+		return
+	}
+	if len(left) > 0 {
+		c.jobs[execTime] = left
+		return
+	}
+
+	log.Printf("cron: Last job at time %s has been removed", execTime)
+	delete(c.jobs, execTime)
+	for i, t := range c.sortedJobTimes {
+		if t == execTime {
+			c.sortedJobTimes = append(c.sortedJobTimes[:i], c.sortedJobTimes[i+1:]...)
+			break
+		}
+	}
+	c.resetTimer(time.Now())
+}
+
 func (c *cron) resetTimer(now time.Time) {
 	log.Printf("cron: timer is going to be reset")
 	nextTimer := maxTimerDuration
@@ -86,6 +127,9 @@ func (c *cron) run() {
 		case j := <-c.newJobCh:
 			log.Printf("cron: Received new job for time %s", j.execTime)
 			c.processNewJob(j.execTime, j.job)
+		case j := <-c.removeJobCh:
+			log.Printf("cron: Received job removal for time %s", j.execTime)
+			c.processRemoveJob(j.execTime, j.job)
 		case now := <-c.timer.C:
 			log.Printf("cron: New trigger tick: %s; registered times: %d", now, len(c.sortedJobTimes))
 			pos := sort.Search(len(c.sortedJobTimes), func(i int) bool {
@@ -120,6 +164,7 @@ func NewCron() Cron {
 	now := time.Now()
 	c := cron{
 		newJobCh:       make(chan cronJobDesc, 0),
+		removeJobCh:    make(chan cronJobDesc, 0),
 		jobs:           make(map[time.Time][]CronJob, 0),
 		sortedJobTimes: []time.Time{now.Add(maxTimerDuration)}, // setting bit value for sort.Search to work correctly
 		timer:          time.NewTimer(maxTimerDuration)}
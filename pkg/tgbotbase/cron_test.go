@@ -114,3 +114,25 @@ func TestRepeatXTimes(t *testing.T) {
 		t.Fatal(j.count, repeatN)
 	}
 }
+
+func TestRemoveJob(t *testing.T) {
+	c := NewCron()
+	removed := &testCronCountingJob{}
+	kept := &testCronCountingJob{}
+
+	when := time.Now().Add(200 * time.Millisecond)
+	later := when.Add(100 * time.Millisecond)
+	c.AddJob(when, removed)
+	c.AddJob(when, kept)
+	c.AddJob(later, removed)
+	c.RemoveJob(when, removed)
+	c.RemoveJob(later, removed)
+
+	time.Sleep(500 * time.Millisecond)
+	if atomic.LoadInt32(&removed.count) != 0 {
+		t.Fatal(removed.count)
+	}
+	if atomic.LoadInt32(&kept.count) != 1 {
+		t.Fatal(kept.count)
+	}
+}
@@ -13,15 +13,17 @@ type ServiceMsg struct {
 }
 
 type MessageDealer interface {
-	init(chan<- tgbotapi.Chattable, chan<- ServiceMsg)
+	init(*Bot)
 	accept(tgbotapi.Message)
 	run()
 	name() string
 }
 
 type HandlerTrigger struct {
-	re   *regexp.Regexp
-	cmds map[string]bool
+	re        *regexp.Regexp
+	cmds      map[string]bool
+	locations bool
+	documents bool
 }
 
 func NewHandlerTrigger(re *regexp.Regexp, cmds []string) HandlerTrigger {
@@ -34,7 +36,28 @@ func NewHandlerTrigger(re *regexp.Regexp, cmds []string) HandlerTrigger {
 		cmds: cmdmap}
 }
 
+// WithLocations makes the trigger accept messages with a location shared in a private chat;
+// locations in groups are shared with other members rather than with the bot
+func (t HandlerTrigger) WithLocations() HandlerTrigger {
+	t.locations = true
+	return t
+}
+
+// WithDocuments makes the trigger accept messages with a file attached
+func (t HandlerTrigger) WithDocuments() HandlerTrigger {
+	t.documents = true
+	return t
+}
+
 func (t *HandlerTrigger) canHandle(msg tgbotapi.Message) bool {
+	if t.locations && msg.Location != nil && msg.Chat != nil && msg.Chat.IsPrivate() {
+		log.Printf("Message %d contains location", msg.MessageID)
+		return true
+	}
+	if t.documents && msg.Document != nil {
+		log.Printf("Message %d contains document '%s'", msg.MessageID, msg.Document.FileName)
+		return true
+	}
 	text := strings.ToLower(msg.Text)
 	if t.re != nil && t.re.MatchString(text) {
 		log.Printf("Message text '%s' matched regexp '%s'", msg.Text, t.re)
@@ -57,10 +80,20 @@ type IncomingMessageHandler interface {
 	Name() string
 }
 
+// CallbackHandler may be additionally implemented by IncomingMessageHandler to process presses of inline keyboard buttons
+type CallbackHandler interface {
+	// CallbackPrefix returns prefix of callback data of the buttons the handler is responsible for
+	CallbackPrefix() string
+	// HandleCallback processes the button press and returns a text shown to the user; it may be empty
+	HandleCallback(q tgbotapi.CallbackQuery) string
+}
+
 type IncomingMessageDealer struct {
-	handler IncomingMessageHandler
-	trigger HandlerTrigger
-	inMsgCh chan tgbotapi.Message
+	handler      IncomingMessageHandler
+	trigger      HandlerTrigger
+	inMsgCh      chan tgbotapi.Message
+	inCallbackCh chan tgbotapi.CallbackQuery
+	bot          *Bot
 }
 
 func NewIncomingMessageDealer(h IncomingMessageHandler) *IncomingMessageDealer {
@@ -68,9 +101,14 @@ func NewIncomingMessageDealer(h IncomingMessageHandler) *IncomingMessageDealer {
 	return d
 }
 
-func (d *IncomingMessageDealer) init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- ServiceMsg) {
-	d.trigger = d.handler.Init(outMsgCh, srvCh)
+func (d *IncomingMessageDealer) init(b *Bot) {
+	d.trigger = d.handler.Init(b.botChannels.out_msg_chan, b.botChannels.service_chan)
+	if fh, ok := d.handler.(FileHandler); ok {
+		fh.SetFileDownloader(b)
+	}
 	d.inMsgCh = make(chan tgbotapi.Message, 0)
+	d.inCallbackCh = make(chan tgbotapi.CallbackQuery, 0)
+	d.bot = b
 }
 
 func (d *IncomingMessageDealer) accept(msg tgbotapi.Message) {
@@ -79,10 +117,26 @@ func (d *IncomingMessageDealer) accept(msg tgbotapi.Message) {
 	}
 }
 
+// acceptCallback passes the button press to the handler if it is responsible for the button
+func (d *IncomingMessageDealer) acceptCallback(q tgbotapi.CallbackQuery) bool {
+	h, ok := d.handler.(CallbackHandler)
+	if !ok || !strings.HasPrefix(q.Data, h.CallbackPrefix()) {
+		return false
+	}
+	d.inCallbackCh <- q
+	return true
+}
+
 func (d *IncomingMessageDealer) run() {
 	go func() {
-		for msg := range d.inMsgCh {
-			d.handler.HandleOne(msg)
+		for {
+			select {
+			case msg := <-d.inMsgCh:
+				d.handler.HandleOne(msg)
+			case q := <-d.inCallbackCh:
+				text := d.handler.(CallbackHandler).HandleCallback(q)
+				d.bot.answerCallback(q, text)
+			}
 		}
 	}()
 }
@@ -110,8 +164,8 @@ func NewBackgroundMessageDealer(h BackgroundMessageHandler) MessageDealer {
 	return &BackgroundMessageDealer{h: h}
 }
 
-func (d *BackgroundMessageDealer) init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- ServiceMsg) {
-	d.h.Init(outMsgCh, srvCh)
+func (d *BackgroundMessageDealer) init(b *Bot) {
+	d.h.Init(b.botChannels.out_msg_chan, b.botChannels.service_chan)
 }
 
 func (d *BackgroundMessageDealer) accept(tgbotapi.Message) {
@@ -132,29 +186,47 @@ type EngagementHandler interface {
 	Disengaged(chat *tgbotapi.Chat, user *tgbotapi.User)
 }
 
+// MembershipHandler may be additionally implemented by EngagementHandler to follow every chat and its members
+type MembershipHandler interface {
+	Seen(chat *tgbotapi.Chat)
+	Joined(chat *tgbotapi.Chat, user *tgbotapi.User)
+	Left(chat *tgbotapi.Chat, user *tgbotapi.User)
+}
+
 type EngagementMessageDealer struct {
-	h EngagementHandler
+	h           EngagementHandler
+	botUserName string
 }
 
 func NewEngagementMessageDealer(h EngagementHandler) MessageDealer {
 	return &EngagementMessageDealer{h: h}
 }
 
-func (d *EngagementMessageDealer) init(outMsgCh chan<- tgbotapi.Chattable, srvCh chan<- ServiceMsg) {
-
+func (d *EngagementMessageDealer) init(b *Bot) {
+	d.botUserName = b.UserName()
 }
 
 func (d *EngagementMessageDealer) accept(msg tgbotapi.Message) {
+	membership, followsMembers := d.h.(MembershipHandler)
+	if followsMembers && msg.Chat != nil {
+		membership.Seen(msg.Chat)
+	}
+
 	if msg.NewChatMembers != nil {
 		for _, m := range *msg.NewChatMembers {
-			if m.IsBot && m.UserName == thisBotUserName() {
+			if m.IsBot && m.UserName == d.botUserName {
 				d.h.Engaged(msg.Chat, msg.From)
+			} else if followsMembers {
+				member := m
+				membership.Joined(msg.Chat, &member)
 			}
 		}
 	}
 	if msg.LeftChatMember != nil {
-		if msg.LeftChatMember.UserName == thisBotUserName() {
+		if msg.LeftChatMember.UserName == d.botUserName {
 			d.h.Disengaged(msg.Chat, msg.From)
+		} else if followsMembers {
+			membership.Left(msg.Chat, msg.LeftChatMember)
 		}
 	}
 }
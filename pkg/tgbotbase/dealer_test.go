@@ -0,0 +1,21 @@
+package tgbotbase
+
+import (
+	"testing"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+func TestTriggerLocationsInPrivateChats(t *testing.T) {
+	trigger := NewHandlerTrigger(nil, nil).WithLocations()
+	location := &tgbotapi.Location{Latitude: 56.33, Longitude: 44.01}
+
+	private := tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1, Type: "private"}, Location: location}
+	if !trigger.canHandle(private) {
+		t.Error("expected location in private chat to be handled")
+	}
+	group := tgbotapi.Message{Chat: &tgbotapi.Chat{ID: -1, Type: "group"}, Location: location}
+	if trigger.canHandle(group) {
+		t.Error("expected location in group to be skipped")
+	}
+}
@@ -0,0 +1,50 @@
+package tgbotbase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxDownloadSize is the largest file Telegram lets bots download
+const maxDownloadSize = 20 << 20
+
+// FileDownloader fetches contents of files sent to the bot
+type FileDownloader interface {
+	DownloadFile(fileID string) ([]byte, error)
+}
+
+// FileHandler may be additionally implemented by IncomingMessageHandler to download documents sent to the bot
+type FileHandler interface {
+	SetFileDownloader(FileDownloader)
+}
+
+// DownloadFile fetches the file via Telegram using the same connection as the bot, including proxy
+func (b *Bot) DownloadFile(fileID string) ([]byte, error) {
+	if b.bot == nil {
+		return nil, errors.New("bot is not connected to Telegram")
+	}
+	url, err := b.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.bot.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading file %s has failed with status %s", fileID, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("file %s is larger than %d bytes", fileID, maxDownloadSize)
+	}
+	return data, nil
+}
+
+var _ FileDownloader = &Bot{}
@@ -0,0 +1,130 @@
+package tgbotbase
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HostConfig describes infrastructure shared by all bots running in one process
+type HostConfig struct {
+	Redis RedisConfig
+	HTTP  struct {
+		Listen string
+	}
+}
+
+// Host runs several bots in one process. Every bot has its own token, handlers and properties,
+// while cron, Redis pool, metrics and HTTP server are shared. Redis keys of a bot are prefixed
+// with its name (see Bot.KeyPrefix), so several bots of one kind do not share their data
+type Host struct {
+	cfg HostConfig
+
+	Cron  Cron
+	Redis RedisPool // nil if Redis is not configured
+
+	// standalone host runs a single bot whose keys are not prefixed, as before bots were hosted together
+	standalone bool
+
+	metrics *expvar.Map
+	mux     *http.ServeMux
+	bots    map[string]*Bot
+}
+
+func NewHost(ctx context.Context, cfg HostConfig) *Host {
+	h := &Host{
+		cfg:     cfg,
+		Cron:    NewCron(),
+		metrics: new(expvar.Map).Init(),
+		mux:     http.NewServeMux(),
+		bots:    make(map[string]*Bot)}
+
+	if cfg.Redis.Server != "" {
+		h.Redis = NewRedisPool(ctx, cfg.Redis)
+	} else {
+		log.Printf("Host: Redis is not configured, running without it")
+	}
+
+	h.mux.HandleFunc("/metrics", h.serveMetrics)
+	return h
+}
+
+// NewStandaloneHost creates a host for a single bot, which keeps its Redis keys without a prefix
+func NewStandaloneHost(ctx context.Context, cfg HostConfig) *Host {
+	h := NewHost(ctx, cfg)
+	h.standalone = true
+	return h
+}
+
+// NewBot connects a new bot to Telegram and registers it at the host under the given name
+func (h *Host) NewBot(name string, cfg Config) (*Bot, error) {
+	if _, found := h.bots[name]; found {
+		return nil, fmt.Errorf("bot %q is already registered", name)
+	}
+	if h.standalone && len(h.bots) > 0 {
+		return nil, fmt.Errorf("bot %q cannot be added, standalone host runs a single bot", name)
+	}
+	if strings.ContainsAny(name, ":*?[]\\ ") {
+		return nil, fmt.Errorf("bot name %q cannot be a part of Redis keys", name)
+	}
+	if cfg.TGBot.DurableOutbox && h.Redis == nil {
+		return nil, fmt.Errorf("bot %q requires durable outbox but Redis is not configured", name)
+	}
+	log.Printf("Host: setting up bot '%s'", name)
+	b := NewBot(cfg)
+	if !h.standalone {
+		b.keys = KeyPrefix(name)
+	}
+	if cfg.TGBot.DurableOutbox {
+		b.SetOutbox(NewRedisOutbox(h.Redis, name))
+	}
+	if h.Redis != nil {
+		b.SetChatRegistry(NewRedisChatRegistry(h.Redis, name))
+		b.properties = NewRedisPropertyStorage(h.Redis, b.keys)
+	}
+	h.bots[name] = b
+	h.metrics.Set(name, b.metrics)
+	return b, nil
+}
+
+// Handle registers an HTTP handler at the shared HTTP server
+func (h *Host) Handle(pattern string, handler http.Handler) {
+	h.mux.Handle(pattern, handler)
+}
+
+func (h *Host) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(w, h.metrics.String())
+}
+
+// Start runs HTTP server (if configured) and all registered bots; blocks until every bot stops
+func (h *Host) Start() error {
+	if len(h.bots) == 0 {
+		return fmt.Errorf("no bots registered at host")
+	}
+
+	if h.cfg.HTTP.Listen != "" {
+		go func() {
+			log.Printf("Host: HTTP server listening on %s", h.cfg.HTTP.Listen)
+			err := http.ListenAndServe(h.cfg.HTTP.Listen, h.mux)
+			log.Printf("Host: HTTP server stopped with error: %s", err)
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for name, b := range h.bots {
+		wg.Add(1)
+		go func(name string, b *Bot) {
+			defer wg.Done()
+			log.Printf("Host: starting bot '%s'", name)
+			b.Start()
+			log.Printf("Host: bot '%s' has stopped", name)
+		}(name, b)
+	}
+	wg.Wait()
+	return nil
+}
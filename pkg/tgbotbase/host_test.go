@@ -0,0 +1,50 @@
+package tgbotbase
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	if k := KeyPrefix("").Key("reminders"); k != "reminders" {
+		t.Errorf("expected key without prefix, got %q", k)
+	}
+	p := KeyPrefix("family")
+	if k := p.Key("tg:property:lang:*:*"); k != "family:tg:property:lang:*:*" {
+		t.Errorf("unexpected prefixed key %q", k)
+	}
+	if k := p.Trim("family:tg:property:lang:1:2"); k != "tg:property:lang:1:2" {
+		t.Errorf("unexpected trimmed key %q", k)
+	}
+}
+
+func TestHostBotKeys(t *testing.T) {
+	cfg := Config{}
+	cfg.TGBot.SkipConnect = true
+
+	host := NewHost(context.Background(), HostConfig{})
+	for _, name := range []string{"towarisch", "towarisch-family"} {
+		b, err := host.NewBot(name, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.KeyPrefix() != KeyPrefix(name) {
+			t.Errorf("expected keys of %q to be prefixed with its name, got %q", name, b.KeyPrefix())
+		}
+	}
+	if _, err := host.NewBot("bad:name", cfg); err == nil {
+		t.Error("expected error for a name which cannot be a part of Redis keys")
+	}
+
+	standalone := NewStandaloneHost(context.Background(), HostConfig{})
+	b, err := standalone.NewBot("towarisch", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.KeyPrefix() != "" {
+		t.Errorf("expected standalone bot keys without prefix, got %q", b.KeyPrefix())
+	}
+	if _, err := standalone.NewBot("other", cfg); err == nil {
+		t.Error("expected standalone host to reject the second bot")
+	}
+}
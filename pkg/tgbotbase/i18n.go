@@ -0,0 +1,183 @@
+package tgbotbase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LangProperty is a property with preferred language of replies, set per user, chat or user in chat
+const LangProperty = "lang"
+
+// Plural keeps forms of a message depending on a number; unused forms may be left empty
+type Plural struct {
+	One, Few, Many, Other string
+}
+
+// Catalog keeps translations of bot messages; messages are fmt formats identified by keys
+type Catalog struct {
+	defaultLang string
+	texts       map[string]map[string]string // lang -> key -> format
+	plurals     map[string]map[string]Plural // lang -> key -> forms
+}
+
+func NewCatalog(defaultLang string) *Catalog {
+	return &Catalog{
+		defaultLang: defaultLang,
+		texts:       make(map[string]map[string]string),
+		plurals:     make(map[string]map[string]Plural)}
+}
+
+// Add registers messages for the language, overriding already known keys
+func (c *Catalog) Add(lang string, texts map[string]string) {
+	if c.texts[lang] == nil {
+		c.texts[lang] = make(map[string]string, len(texts))
+	}
+	for k, v := range texts {
+		c.texts[lang][k] = v
+	}
+}
+
+// AddPlurals registers messages with plural forms for the language
+func (c *Catalog) AddPlurals(lang string, plurals map[string]Plural) {
+	if c.plurals[lang] == nil {
+		c.plurals[lang] = make(map[string]Plural, len(plurals))
+	}
+	for k, v := range plurals {
+		c.plurals[lang][k] = v
+	}
+}
+
+// Text returns formatted message; falls back to the default language and then to the key itself
+func (c *Catalog) Text(lang, key string, args ...interface{}) string {
+	format, found := c.texts[lang][key]
+	if !found {
+		format, found = c.texts[c.defaultLang][key]
+	}
+	if !found {
+		log.Printf("i18n: no text '%s' for language '%s'", key, lang)
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Plural returns formatted message in the form matching n
+func (c *Catalog) Plural(lang, key string, n int, args ...interface{}) string {
+	forms, found := c.plurals[lang][key]
+	if !found {
+		lang = c.defaultLang
+		forms, found = c.plurals[lang][key]
+	}
+	if !found {
+		log.Printf("i18n: no plural '%s' for language '%s'", key, lang)
+		return key
+	}
+	format := forms.pick(pluralCategory(lang, n))
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+type pluralCat int
+
+const (
+	pluralOne pluralCat = iota
+	pluralFew
+	pluralMany
+	pluralOther
+)
+
+// pluralCategory implements CLDR cardinal rules for integers of the supported languages
+func pluralCategory(lang string, n int) pluralCat {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case "ru", "uk", "be":
+		mod10, mod100 := n%10, n%100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return pluralOne
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return pluralFew
+		default:
+			return pluralMany
+		}
+	default:
+		if n == 1 {
+			return pluralOne
+		}
+		return pluralOther
+	}
+}
+
+func (p Plural) pick(cat pluralCat) string {
+	var form string
+	switch cat {
+	case pluralOne:
+		form = p.One
+	case pluralFew:
+		form = p.Few
+	case pluralMany:
+		form = p.Many
+	}
+	if form == "" {
+		form = p.Other
+	}
+	if form == "" {
+		form = p.Many
+	}
+	return form
+}
+
+// Localizer chooses language of replies using LangProperty
+type Localizer struct {
+	catalog *Catalog
+	props   PropertyStorage
+}
+
+func NewLocalizer(catalog *Catalog, props PropertyStorage) *Localizer {
+	return &Localizer{catalog: catalog, props: props}
+}
+
+// Lang returns language code preferred by the user in the chat; user may be 0 for chat-wide messages
+func (l *Localizer) Lang(ctx context.Context, user UserID, chat ChatID) string {
+	lang, err := l.props.GetProperty(ctx, LangProperty, user, chat)
+	if err != nil {
+		log.Printf("i18n: could not get language for user %d chat %d, error: %s", user, chat, err)
+	}
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return l.catalog.defaultLang
+	}
+	return lang
+}
+
+// For returns texts in the language preferred by the user in the chat
+func (l *Localizer) For(ctx context.Context, user UserID, chat ChatID) Texts {
+	return Texts{Lang: l.Lang(ctx, user, chat), catalog: l.catalog}
+}
+
+// Texts are messages of a catalog in a single language
+type Texts struct {
+	Lang    string
+	catalog *Catalog
+}
+
+// In returns texts of the catalog in the language
+func (c *Catalog) In(lang string) Texts {
+	return Texts{Lang: lang, catalog: c}
+}
+
+func (t Texts) T(key string, args ...interface{}) string {
+	return t.catalog.Text(t.Lang, key, args...)
+}
+
+func (t Texts) N(key string, n int, args ...interface{}) string {
+	return t.catalog.Plural(t.Lang, key, n, args...)
+}
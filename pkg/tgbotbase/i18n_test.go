@@ -0,0 +1,38 @@
+package tgbotbase
+
+import "testing"
+
+func TestPluralRussian(t *testing.T) {
+	c := NewCatalog("ru")
+	c.AddPlurals("ru", map[string]Plural{"pluses": {One: "%d плюс", Few: "%d плюса", Many: "%d плюсов"}})
+	c.AddPlurals("en", map[string]Plural{"pluses": {One: "%d plus", Other: "%d pluses"}})
+
+	cases := map[int]string{0: "0 плюсов", 1: "1 плюс", 2: "2 плюса", 5: "5 плюсов", 11: "11 плюсов", 12: "12 плюсов", 21: "21 плюс", 22: "22 плюса", 111: "111 плюсов"}
+	for n, expected := range cases {
+		if res := c.In("ru").N("pluses", n, n); res != expected {
+			t.Fatal(n, res, expected)
+		}
+	}
+	if res := c.In("en").N("pluses", 1, 1); res != "1 plus" {
+		t.Fatal(res)
+	}
+	if res := c.In("en").N("pluses", 21, 21); res != "21 pluses" {
+		t.Fatal(res)
+	}
+}
+
+func TestTextFallback(t *testing.T) {
+	c := NewCatalog("ru")
+	c.Add("ru", map[string]string{"hello": "Привет, %s", "bye": "Пока"})
+	c.Add("en", map[string]string{"hello": "Hello, %s"})
+
+	if res := c.In("en").T("hello", "Ilya"); res != "Hello, Ilya" {
+		t.Fatal(res)
+	}
+	if res := c.In("en").T("bye"); res != "Пока" {
+		t.Fatal(res)
+	}
+	if res := c.In("de").T("unknown"); res != "unknown" {
+		t.Fatal(res)
+	}
+}
@@ -0,0 +1,138 @@
+package tgbotbase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// Uploads are kept in memory so no temporary files are left behind and failed sends can be retried
+
+// NewPhotoFromBytes prepares a photo upload from in-memory data
+func NewPhotoFromBytes(chat ChatID, name string, data []byte) tgbotapi.PhotoConfig {
+	return tgbotapi.NewPhotoUpload(int64(chat), tgbotapi.FileBytes{Name: name, Bytes: data})
+}
+
+// NewPhotoFromReader reads everything from r and prepares a photo upload
+func NewPhotoFromReader(chat ChatID, name string, r io.Reader) (tgbotapi.PhotoConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return tgbotapi.PhotoConfig{}, err
+	}
+	return NewPhotoFromBytes(chat, name, data), nil
+}
+
+// NewDocumentFromBytes prepares a document upload from in-memory data
+func NewDocumentFromBytes(chat ChatID, name string, data []byte) tgbotapi.DocumentConfig {
+	return tgbotapi.NewDocumentUpload(int64(chat), tgbotapi.FileBytes{Name: name, Bytes: data})
+}
+
+// NewDocumentFromReader reads everything from r and prepares a document upload
+func NewDocumentFromReader(chat ChatID, name string, r io.Reader) (tgbotapi.DocumentConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return tgbotapi.DocumentConfig{}, err
+	}
+	return NewDocumentFromBytes(chat, name, data), nil
+}
+
+// MediaFile is a single photo of an album
+type MediaFile struct {
+	Name    string
+	Data    []byte
+	Caption string
+}
+
+// MediaGroup is an album of photos uploaded from memory.
+// tgbotapi can send albums only of already uploaded files, so Bot uploads MediaGroup itself
+type MediaGroup struct {
+	tgbotapi.MediaGroupConfig // makes MediaGroup Chattable; not used for sending
+	Chat                      ChatID
+	Photos                    []MediaFile
+}
+
+// NewMediaGroupUpload prepares an album; Telegram accepts from 2 to 10 photos, a single photo is sent as usual
+func NewMediaGroupUpload(chat ChatID, photos []MediaFile) *MediaGroup {
+	return &MediaGroup{
+		MediaGroupConfig: tgbotapi.NewMediaGroup(int64(chat), nil),
+		Chat:             chat,
+		Photos:           photos}
+}
+
+const maxMediaGroupSize = 10
+
+func (b *Bot) sendMediaGroup(mg *MediaGroup) (tgbotapi.Message, error) {
+	switch {
+	case len(mg.Photos) == 0:
+		return tgbotapi.Message{}, errors.New("empty media group")
+	case len(mg.Photos) == 1:
+		photo := NewPhotoFromBytes(mg.Chat, mg.Photos[0].Name, mg.Photos[0].Data)
+		photo.Caption = mg.Photos[0].Caption
+		return b.bot.Send(photo)
+	case len(mg.Photos) > maxMediaGroupSize:
+		return tgbotapi.Message{}, fmt.Errorf("media group contains %d photos, max is %d", len(mg.Photos), maxMediaGroupSize)
+	}
+
+	type inputMedia struct {
+		Type    string `json:"type"`
+		Media   string `json:"media"`
+		Caption string `json:"caption,omitempty"`
+	}
+	media := make([]inputMedia, 0, len(mg.Photos))
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for i, p := range mg.Photos {
+		field := fmt.Sprintf("photo%d", i)
+		media = append(media, inputMedia{Type: "photo", Media: "attach://" + field, Caption: p.Caption})
+		part, err := w.CreateFormFile(field, p.Name)
+		if err != nil {
+			return tgbotapi.Message{}, err
+		}
+		if _, err := part.Write(p.Data); err != nil {
+			return tgbotapi.Message{}, err
+		}
+	}
+	mediaJSON, err := json.Marshal(media)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	w.WriteField("chat_id", fmt.Sprintf("%d", mg.Chat))
+	w.WriteField("media", string(mediaJSON))
+	if err := w.Close(); err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	endpoint := fmt.Sprintf(tgbotapi.APIEndpoint, b.bot.Token, "sendMediaGroup")
+	resp, err := b.bot.Client.Post(endpoint, w.FormDataContentType(), body)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var apiResp tgbotapi.APIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	if !apiResp.Ok {
+		return tgbotapi.Message{}, apiError(apiResp)
+	}
+	var sent []tgbotapi.Message
+	if err := json.Unmarshal(apiResp.Result, &sent); err != nil || len(sent) == 0 {
+		return tgbotapi.Message{}, fmt.Errorf("unexpected sendMediaGroup result: %s", apiResp.Result)
+	}
+	return sent[0], nil
+}
+
+// apiError keeps parameters of the failed response, so that retries can follow RetryAfter of Telegram
+func apiError(resp tgbotapi.APIResponse) tgbotapi.Error {
+	err := tgbotapi.Error{Message: resp.Description}
+	if resp.Parameters != nil {
+		err.ResponseParameters = *resp.Parameters
+	}
+	return err
+}
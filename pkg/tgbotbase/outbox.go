@@ -0,0 +1,90 @@
+package tgbotbase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// Delivery is an acknowledgement of an outgoing message: either the message sent to Telegram or an error
+type Delivery struct {
+	Message tgbotapi.Message
+	Err     error
+}
+
+// ErrNotSent is reported when a message could not be delivered after all retries
+var ErrNotSent = errors.New("message has not been delivered")
+
+// trackedMessage wraps an outgoing message together with a channel for its delivery acknowledgement.
+// It stays Chattable so it can be passed through usual outgoing message channels
+type trackedMessage struct {
+	tgbotapi.Chattable
+	ack chan Delivery
+}
+
+// SendWithAck pushes the message into the outgoing channel and returns a channel where exactly one
+// Delivery will be reported once the message is sent or finally failed
+func SendWithAck(outMsgCh chan<- tgbotapi.Chattable, msg tgbotapi.Chattable) <-chan Delivery {
+	ack := make(chan Delivery, 1)
+	outMsgCh <- &trackedMessage{Chattable: msg, ack: ack}
+	return ack
+}
+
+// OutboxRecord is a durable representation of an outgoing text message
+type OutboxRecord struct {
+	ID       string
+	Attempts int
+	Created  time.Time
+	Message  tgbotapi.MessageConfig
+}
+
+// Outbox keeps outgoing messages until they are delivered; failed messages go to a dead-letter list
+type Outbox interface {
+	Put(ctx context.Context, rec OutboxRecord) error
+	Remove(ctx context.Context, id string) error
+	DeadLetter(ctx context.Context, rec OutboxRecord, reason string) error
+	Pending(ctx context.Context) ([]OutboxRecord, error)
+}
+
+const (
+	outboxMaxAttempts = 5
+	outboxBaseBackoff = 2 * time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+)
+
+func outboxBackoff(attempts int, err error) time.Duration {
+	var tgErr tgbotapi.Error
+	if errors.As(err, &tgErr) && tgErr.RetryAfter > 0 {
+		return time.Duration(tgErr.RetryAfter) * time.Second
+	}
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+// isPermanentSendError tells if there is no sense in retrying the message
+func isPermanentSendError(err error) bool {
+	var tgErr tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	return strings.HasPrefix(tgErr.Message, "Forbidden") || strings.HasPrefix(tgErr.Message, "Bad Request")
+}
+
+// isKickedError tells if the bot cannot write to the chat anymore
+func isKickedError(err error) bool {
+	var tgErr tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	return strings.HasPrefix(tgErr.Message, "Forbidden: bot was kicked") ||
+		strings.HasPrefix(tgErr.Message, "Forbidden: bot is not a member")
+}
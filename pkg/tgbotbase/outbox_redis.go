@@ -0,0 +1,80 @@
+package tgbotbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisOutbox struct {
+	client  *redis.Client
+	botName string
+}
+
+var _ Outbox = &RedisOutbox{}
+
+func NewRedisOutbox(pool RedisPool, botName string) *RedisOutbox {
+	return &RedisOutbox{
+		client:  pool.GetConnByName("outbox"),
+		botName: botName}
+}
+
+func (o *RedisOutbox) pendingKey() string {
+	return fmt.Sprintf("outbox:%s:pending", o.botName)
+}
+
+func (o *RedisOutbox) deadKey() string {
+	return fmt.Sprintf("outbox:%s:dead", o.botName)
+}
+
+func (o *RedisOutbox) Put(ctx context.Context, rec OutboxRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return o.client.HSet(ctx, o.pendingKey(), rec.ID, data).Err()
+}
+
+func (o *RedisOutbox) Remove(ctx context.Context, id string) error {
+	return o.client.HDel(ctx, o.pendingKey(), id).Err()
+}
+
+type deadLetter struct {
+	OutboxRecord
+	Reason string
+	Died   time.Time
+}
+
+func (o *RedisOutbox) DeadLetter(ctx context.Context, rec OutboxRecord, reason string) error {
+	data, err := json.Marshal(deadLetter{OutboxRecord: rec, Reason: reason, Died: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = o.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, o.deadKey(), data)
+		pipe.HDel(ctx, o.pendingKey(), rec.ID)
+		return nil
+	})
+	return err
+}
+
+func (o *RedisOutbox) Pending(ctx context.Context) ([]OutboxRecord, error) {
+	values, err := o.client.HGetAll(ctx, o.pendingKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]OutboxRecord, 0, len(values))
+	for id, v := range values {
+		var rec OutboxRecord
+		if err := json.Unmarshal([]byte(v), &rec); err != nil {
+			log.Printf("Outbox record '%s' of bot '%s' cannot be decoded, error: %s", id, o.botName, err)
+			continue
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
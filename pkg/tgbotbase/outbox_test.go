@@ -0,0 +1,108 @@
+package tgbotbase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+type testMemoryOutbox struct {
+	pending map[string]OutboxRecord
+	dead    []OutboxRecord
+}
+
+func (o *testMemoryOutbox) Put(ctx context.Context, rec OutboxRecord) error {
+	o.pending[rec.ID] = rec
+	return nil
+}
+
+func (o *testMemoryOutbox) Remove(ctx context.Context, id string) error {
+	delete(o.pending, id)
+	return nil
+}
+
+func (o *testMemoryOutbox) DeadLetter(ctx context.Context, rec OutboxRecord, reason string) error {
+	delete(o.pending, rec.ID)
+	o.dead = append(o.dead, rec)
+	return nil
+}
+
+func (o *testMemoryOutbox) Pending(ctx context.Context) ([]OutboxRecord, error) {
+	result := make([]OutboxRecord, 0, len(o.pending))
+	for _, rec := range o.pending {
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+func newTestBot() *Bot {
+	var cfg Config
+	cfg.TGBot.SkipConnect = true
+	cfg.TGBot.RedirectMsgToLog = true
+	return NewBot(cfg)
+}
+
+func TestSendWithAckDelivered(t *testing.T) {
+	b := newTestBot()
+	outbox := &testMemoryOutbox{pending: make(map[string]OutboxRecord)}
+	b.SetOutbox(outbox)
+	go b.serveReplies()
+
+	select {
+	case d := <-b.SendWithAck(tgbotapi.NewMessage(1, "hello")):
+		if d.Err != nil {
+			t.Fatal(d.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no delivery acknowledgement")
+	}
+	if len(outbox.pending) != 0 {
+		t.Fatal("delivered message is still in outbox", outbox.pending)
+	}
+}
+
+func TestFailedGoesToDeadLetters(t *testing.T) {
+	b := newTestBot()
+	outbox := &testMemoryOutbox{pending: make(map[string]OutboxRecord)}
+	b.SetOutbox(outbox)
+
+	out := b.newOutgoing(&trackedMessage{Chattable: tgbotapi.NewMessage(1, "hello"), ack: make(chan Delivery, 1)})
+	if out.record == nil || len(outbox.pending) != 1 {
+		t.Fatal("text message has not been persisted")
+	}
+	b.failed(out, tgbotapi.Error{Message: "Forbidden: bot was kicked from the group chat"}, nil)
+
+	d := <-out.ack
+	if !errors.Is(d.Err, ErrNotSent) {
+		t.Fatal(d.Err)
+	}
+	if len(outbox.pending) != 0 || len(outbox.dead) != 1 {
+		t.Fatal(outbox.pending, outbox.dead)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	if d := outboxBackoff(1, errors.New("timeout")); d != outboxBaseBackoff {
+		t.Fatal(d)
+	}
+	if d := outboxBackoff(3, errors.New("timeout")); d != 4*outboxBaseBackoff {
+		t.Fatal(d)
+	}
+	if d := outboxBackoff(100, errors.New("timeout")); d != outboxMaxBackoff {
+		t.Fatal(d)
+	}
+	retryAfter := tgbotapi.Error{Message: "Too Many Requests"}
+	retryAfter.RetryAfter = 7
+	if d := outboxBackoff(1, retryAfter); d != 7*time.Second {
+		t.Fatal(d)
+	}
+
+	// media groups are sent without tgbotapi, their errors must keep RetryAfter as well
+	resp := tgbotapi.APIResponse{Description: "Too Many Requests", Parameters: &tgbotapi.ResponseParameters{RetryAfter: 9}}
+	if d := outboxBackoff(1, apiError(resp)); d != 9*time.Second {
+		t.Fatal(d)
+	}
+}
@@ -12,23 +12,26 @@ import (
 
 type RedisPropertyStorage struct {
 	client *redis.Client
+	keys   KeyPrefix
 }
 
-func NewRedisPropertyStorage(pool RedisPool) *RedisPropertyStorage {
-	r := &RedisPropertyStorage{client: pool.GetConnByName("property")}
+// NewRedisPropertyStorage keeps properties under keys with the prefix, so bots at a host have their own properties
+func NewRedisPropertyStorage(pool RedisPool, keys KeyPrefix) *RedisPropertyStorage {
+	r := &RedisPropertyStorage{client: pool.GetConnByName("property"),
+		keys: keys}
 	return r
 }
 
-func redisPropertyKey(name string, user UserID, chat ChatID) string {
+func (r *RedisPropertyStorage) redisPropertyKey(name string, user UserID, chat ChatID) string {
 	if strings.Contains(name, ":") {
 		panic(fmt.Sprintf("Property key %q contains forbidden symbol %q", name, ":"))
 	}
-	return fmt.Sprintf("tg:property:%s:%d:%d", name, user, chat)
+	return r.keys.Key(fmt.Sprintf("tg:property:%s:%d:%d", name, user, chat))
 }
 
 func (r *RedisPropertyStorage) SetPropertyForUserInChat(ctx context.Context, name string, user UserID, chat ChatID, value interface{}) error {
 	log.Printf("Setting property '%s' for user %d chat %d with value: %v", name, user, chat, value)
-	key := redisPropertyKey(name, user, chat)
+	key := r.redisPropertyKey(name, user, chat)
 	return r.client.Set(ctx, key, value, 0).Err()
 }
 
@@ -46,7 +49,7 @@ func (r *RedisPropertyStorage) GetProperty(ctx context.Context, name string, use
 	log.Printf("Getting property '%s' for user %d chat %d", name, user, chat)
 
 	// checking specific property value for this user in this chat
-	res := r.client.Get(ctx, redisPropertyKey(name, user, chat))
+	res := r.client.Get(ctx, r.redisPropertyKey(name, user, chat))
 	err := res.Err()
 	if err != nil {
 		if err == redis.Nil {
@@ -59,7 +62,7 @@ func (r *RedisPropertyStorage) GetProperty(ctx context.Context, name string, use
 	}
 
 	// checking user-defined property (for any chat, set via direct msg)
-	res = r.client.Get(ctx, redisPropertyKey(name, user, ChatID(user)))
+	res = r.client.Get(ctx, r.redisPropertyKey(name, user, ChatID(user)))
 	err = res.Err()
 	if err != nil {
 		if err == redis.Nil {
@@ -72,7 +75,7 @@ func (r *RedisPropertyStorage) GetProperty(ctx context.Context, name string, use
 	}
 
 	// checking chat-defined property (default property for this chat)
-	res = r.client.Get(ctx, redisPropertyKey(name, 0, chat))
+	res = r.client.Get(ctx, r.redisPropertyKey(name, 0, chat))
 	err = res.Err()
 	if err != nil {
 		if err == redis.Nil {
@@ -90,7 +93,7 @@ func (r *RedisPropertyStorage) GetProperty(ctx context.Context, name string, use
 
 func (r *RedisPropertyStorage) GetEveryHavingProperty(ctx context.Context, name string) ([]PropertyValue, error) {
 	log.Printf("Getting property '%s' for every chat", name)
-	pattern := fmt.Sprintf("tg:property:%s:*:*", name)
+	pattern := r.keys.Key(fmt.Sprintf("tg:property:%s:*:*", name))
 	keys, err := GetAllKeys(ctx, r.client, pattern)
 	if err != nil {
 		return nil, err
@@ -103,7 +106,7 @@ func (r *RedisPropertyStorage) GetEveryHavingProperty(ctx context.Context, name
 			continue
 		}
 
-		parts := strings.Split(k, ":")
+		parts := strings.Split(r.keys.Trim(k), ":")
 		if len(parts) != 5 {
 			log.Printf("Key '%s' has unexpected number of parts", k)
 			continue
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"strings"
+	"sync"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -13,6 +14,25 @@ type RedisPool interface {
 	GetConnByName(dbName string) *redis.Client
 }
 
+// KeyPrefix separates Redis keys of bots sharing Redis DBs at a host; empty prefix leaves keys as they are
+type KeyPrefix string
+
+// Key prepends the prefix to the key or to the pattern of keys
+func (p KeyPrefix) Key(key string) string {
+	if p == "" {
+		return key
+	}
+	return string(p) + ":" + key
+}
+
+// Trim removes the prefix from a key found by a pattern made with Key
+func (p KeyPrefix) Trim(key string) string {
+	if p == "" {
+		return key
+	}
+	return strings.TrimPrefix(key, string(p)+":")
+}
+
 type RedisConfig struct {
 	Server string
 	Pass   string
@@ -21,11 +41,15 @@ type RedisConfig struct {
 type RedisPoolImpl struct {
 	cfg RedisConfig
 	db  map[string]int
+
+	connMutex sync.Mutex
+	conns     map[int]*redis.Client
 }
 
 func NewRedisPool(ctx context.Context, cfg RedisConfig) RedisPool {
 	impl := RedisPoolImpl{cfg: cfg,
-		db: make(map[string]int, 10)}
+		db:    make(map[string]int, 10),
+		conns: make(map[int]*redis.Client)}
 
 	// loading dictionary for db discovery
 	opts := redis.Options{Addr: cfg.Server,
@@ -53,11 +77,20 @@ func NewRedisPool(ctx context.Context, cfg RedisConfig) RedisPool {
 	return &impl
 }
 
+// GetConnByID returns a client for the DB; clients are cached so handlers of several bots share connections
 func (pool *RedisPoolImpl) GetConnByID(dbID int) *redis.Client {
+	pool.connMutex.Lock()
+	defer pool.connMutex.Unlock()
+
+	if conn, found := pool.conns[dbID]; found {
+		return conn
+	}
 	opts := redis.Options{Addr: pool.cfg.Server,
 		Password: pool.cfg.Pass,
 		DB:       dbID}
-	return redis.NewClient(&opts)
+	conn := redis.NewClient(&opts)
+	pool.conns[dbID] = conn
+	return conn
 }
 
 func (pool *RedisPoolImpl) GetConnByName(dbName string) *redis.Client {
@@ -0,0 +1,50 @@
+package tgbotbase
+
+import (
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// Replier sends, edits, deletes and pins messages; every operation returns a channel
+// with its Delivery, so callers may learn IDs of sent messages and edit them later
+type Replier struct {
+	out chan<- tgbotapi.Chattable
+}
+
+func NewReplier(outMsgCh chan<- tgbotapi.Chattable) Replier {
+	return Replier{out: outMsgCh}
+}
+
+// Replier returns Replier working via handler's outgoing channel
+func (h BaseHandler) Replier() Replier {
+	return NewReplier(h.OutMsgCh)
+}
+
+func (r Replier) Send(msg tgbotapi.Chattable) <-chan Delivery {
+	return SendWithAck(r.out, msg)
+}
+
+// Reply sends a text message as a reply to the given one
+func (r Replier) Reply(to tgbotapi.Message, text string) <-chan Delivery {
+	msg := tgbotapi.NewMessage(to.Chat.ID, text)
+	msg.ReplyToMessageID = to.MessageID
+	return r.Send(msg)
+}
+
+func (r Replier) EditText(chat ChatID, messageID int, text string) <-chan Delivery {
+	return r.Send(tgbotapi.NewEditMessageText(int64(chat), messageID, text))
+}
+
+func (r Replier) EditCaption(chat ChatID, messageID int, caption string) <-chan Delivery {
+	return r.Send(tgbotapi.NewEditMessageCaption(int64(chat), messageID, caption))
+}
+
+func (r Replier) Delete(chat ChatID, messageID int) <-chan Delivery {
+	return r.Send(tgbotapi.NewDeleteMessage(int64(chat), messageID))
+}
+
+func (r Replier) Pin(chat ChatID, messageID int, silent bool) <-chan Delivery {
+	return r.Send(tgbotapi.PinChatMessageConfig{
+		ChatID:              int64(chat),
+		MessageID:           messageID,
+		DisableNotification: silent})
+}
@@ -0,0 +1,93 @@
+package tgbotutil
+
+import "sort"
+
+// fuzzyGram is the length of substrings which keys close to the query must share with it
+const fuzzyGram = 3
+
+// fuzzyPad marks the beginning and the end of a key, so short keys have grams too
+const fuzzyPad = '\x00'
+
+type fuzzyPosting struct {
+	key   int32
+	count int32 // occurrences of the gram in the key
+}
+
+// FuzzyIndex finds keys within edit distance of a query without comparing it to every key:
+// every edit changes at most 3 trigrams, so a close key shares enough trigrams with the query
+// and only such keys are compared by Levenshtein distance
+type FuzzyIndex struct {
+	keys  []string
+	lens  []int
+	grams map[string][]fuzzyPosting
+}
+
+func NewFuzzyIndex() *FuzzyIndex {
+	return &FuzzyIndex{grams: make(map[string][]fuzzyPosting)}
+}
+
+// fuzzyGrams counts trigrams of the key padded at both ends
+func fuzzyGrams(key string) map[string]int32 {
+	runes := []rune(key)
+	padded := make([]rune, 0, len(runes)+2*(fuzzyGram-1))
+	for i := 0; i < fuzzyGram-1; i++ {
+		padded = append(padded, fuzzyPad)
+	}
+	padded = append(padded, runes...)
+	for i := 0; i < fuzzyGram-1; i++ {
+		padded = append(padded, fuzzyPad)
+	}
+	grams := make(map[string]int32, len(padded))
+	for i := 0; i+fuzzyGram <= len(padded); i++ {
+		grams[string(padded[i:i+fuzzyGram])]++
+	}
+	return grams
+}
+
+// Add puts the key into the index and returns its number; numbers go in the order of adding from 0
+func (f *FuzzyIndex) Add(key string) int {
+	n := len(f.keys)
+	f.keys = append(f.keys, key)
+	f.lens = append(f.lens, len([]rune(key)))
+	for g, count := range fuzzyGrams(key) {
+		f.grams[g] = append(f.grams[g], fuzzyPosting{key: int32(n), count: count})
+	}
+	return n
+}
+
+// Len returns number of keys in the index
+func (f *FuzzyIndex) Len() int {
+	return len(f.keys)
+}
+
+// Search calls found for every key not farther than maxDist from the query in the order of adding. Keys sharing no trigram with
+// the query are skipped even if maxDist allows them, which happens when maxDist exceeds a quarter of the length
+func (f *FuzzyIndex) Search(query string, maxDist int, found func(key, dist int)) {
+	queryLen := len([]rune(query))
+	common := make(map[int32]int)
+	for g, count := range fuzzyGrams(query) {
+		for _, p := range f.grams[g] {
+			// the distance is at least the difference of lengths
+			if d := f.lens[p.key] - queryLen; d > maxDist || -d > maxDist {
+				continue
+			}
+			common[p.key] += int(min(count, p.count))
+		}
+	}
+	keys := make([]int32, 0, len(common))
+	for key := range common {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		shared := common[key]
+		// a key has its length plus 2 padded trigrams, every edit changes at most 3 of them
+		need := max(queryLen, f.lens[key]) + fuzzyGram - 1 - fuzzyGram*maxDist
+		if shared < need {
+			continue
+		}
+		if d := Levenshtein(query, f.keys[key]); d <= maxDist {
+			found(int(key), d)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package tgbotutil
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyIndex(t *testing.T) {
+	f := NewFuzzyIndex()
+	for _, k := range []string{"lightning bolt", "lightning axe", "lightning helix", "sol ring", "moskva", "murmansk"} {
+		f.Add(k)
+	}
+	type match struct{ key, dist int }
+	search := func(query string, maxDist int) []match {
+		var found []match
+		f.Search(query, maxDist, func(key, dist int) {
+			found = append(found, match{key, dist})
+		})
+		return found
+	}
+	if got := search("lightnig bolt", 3); !reflect.DeepEqual(got, []match{{0, 1}}) {
+		t.Errorf("unexpected matches of a typo: %v", got)
+	}
+	if got := search("sol rnig", 2); !reflect.DeepEqual(got, []match{{3, 2}}) {
+		t.Errorf("unexpected matches of swapped letters: %v", got)
+	}
+	if got := search("chandra", 1); len(got) != 0 {
+		t.Errorf("expected nothing, got %v", got)
+	}
+}
+
+// TestFuzzyIndexComplete compares the index with Levenshtein distance to every key
+func TestFuzzyIndexComplete(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	word := func() string {
+		b := make([]rune, 3+rnd.Intn(10))
+		for i := range b {
+			b[i] = rune('a' + rnd.Intn(4))
+		}
+		return string(b)
+	}
+	keys := make([]string, 500)
+	f := NewFuzzyIndex()
+	for i := range keys {
+		keys[i] = word()
+		f.Add(keys[i])
+	}
+	for i := 0; i < 200; i++ {
+		query := word()
+		maxDist := len(query) / 4
+		found := make(map[int]bool)
+		f.Search(query, maxDist, func(key, dist int) { found[key] = true })
+		for k, key := range keys {
+			if close := Levenshtein(query, key) <= maxDist; close != found[k] {
+				t.Fatalf("query %q, key %q: expected found %v", query, key, close)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package tgbotutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// latinToCyrillic is ordered so that longer sequences are tried first
+var latinToCyrillic = []struct {
+	latin, cyrillic string
+}{
+	{"shch", "щ"}, {"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"yu", "ю"}, {"ya", "я"}, {"yo", "ё"}, {"ye", "е"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"}, {"g", "г"}, {"h", "х"},
+	{"i", "и"}, {"j", "дж"}, {"k", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"}, {"o", "о"}, {"p", "п"},
+	{"q", "к"}, {"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"}, {"v", "в"}, {"w", "в"}, {"x", "кс"},
+	{"y", "ы"}, {"z", "з"},
+}
+
+// ToLatin transliterates Cyrillic letters of lowercase text into Latin ones, other symbols are kept
+func ToLatin(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if l, found := cyrillicToLatin[r]; found {
+			b.WriteString(l)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ToCyrillic transliterates Latin letters of lowercase text into Cyrillic ones, other symbols are kept;
+// 'y' after a vowel becomes 'й'
+func ToCyrillic(s string) string {
+	var b strings.Builder
+	prevVowel := false
+	for len(s) > 0 {
+		matched := false
+		for _, t := range latinToCyrillic {
+			if !strings.HasPrefix(s, t.latin) {
+				continue
+			}
+			c := t.cyrillic
+			if t.latin == "y" && prevVowel {
+				c = "й"
+			}
+			b.WriteString(c)
+			s = s[len(t.latin):]
+			prevVowel = strings.ContainsAny(t.latin[len(t.latin)-1:], "aeiou")
+			matched = true
+			break
+		}
+		if !matched {
+			r := []rune(s)[0]
+			b.WriteRune(r)
+			s = s[len(string(r)):]
+			prevVowel = false
+		}
+	}
+	return b.String()
+}
+
+// NormalizeName lowercases the name, replaces 'ё' with 'е', treats hyphens and punctuation as spaces and collapses them
+func NormalizeName(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Levenshtein returns edit distance between the strings counted in runes
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
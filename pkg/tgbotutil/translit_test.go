@@ -0,0 +1,54 @@
+package tgbotutil
+
+import "testing"
+
+func TestToLatin(t *testing.T) {
+	tests := map[string]string{
+		"нижний новгород": "nizhniy novgorod",
+		"ростов-на-дону":  "rostov-na-donu",
+		"щёлково":         "shchelkovo",
+		"london":          "london",
+	}
+	for in, expected := range tests {
+		if out := ToLatin(in); out != expected {
+			t.Errorf("ToLatin(%q): expected %q, got %q", in, expected, out)
+		}
+	}
+}
+
+func TestToCyrillic(t *testing.T) {
+	tests := map[string]string{
+		"moskva":           "москва",
+		"nizhniy novgorod": "нижний новгород",
+		"shchelkovo":       "щелково",
+		"yaroslavl":        "ярославл",
+	}
+	for in, expected := range tests {
+		if out := ToCyrillic(in); out != expected {
+			t.Errorf("ToCyrillic(%q): expected %q, got %q", in, expected, out)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	if n := NormalizeName("  Ростов-на-Дону, Ёлки "); n != "ростов на дону елки" {
+		t.Errorf("unexpected normalized name %q", n)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		d    int
+	}{
+		{"москва", "москве", 1},
+		{"kazan", "kazan", 0},
+		{"", "abc", 3},
+		{"saint petersburg", "sankt peterburg", 3},
+	}
+	for _, test := range tests {
+		if d := Levenshtein(test.a, test.b); d != test.d {
+			t.Errorf("Levenshtein(%q, %q): expected %d, got %d", test.a, test.b, test.d, d)
+		}
+	}
+}
@@ -1,9 +1,6 @@
 package tgbotutil
 
 import (
-	"io"
-	"io/ioutil"
-	"net/http"
 	"strings"
 )
 
@@ -15,25 +12,3 @@ func EscapeMarkdown(s string) string {
 	}
 	return s
 }
-
-func LoadPicToTmp(url, prefix string) (string, error) {
-	tmp, err := ioutil.TempFile("", prefix)
-	if err != nil {
-		return "", err
-	}
-	defer tmp.Close()
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	// Write the body to file
-	_, err = io.Copy(tmp, resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	return tmp.Name(), nil
-}
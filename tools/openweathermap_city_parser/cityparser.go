@@ -1,29 +1,40 @@
 package main
 
 import (
+	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"os"
 	"strings"
-
-	"github.com/go-redis/redis/v8"
 )
 
-const city_file = "city.list.json"
-const redis_addr = "localhost:6379"
-const redis_db = 0 // common db with settings shared between bots
-
+// cityInfo is a record of city.list.json published by OpenWeatherMap
 type cityInfo struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
-	// there's something else but we don't need it
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Country string `json:"country"`
+	Coord   struct {
+		Lon float64 `json:"lon"`
+		Lat float64 `json:"lat"`
+	} `json:"coord"`
+}
+
+// clean keeps the field from breaking tab separated lines
+func clean(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '\t' || r == '\n' || r == '\r' }), " ")
 }
 
 func main() {
-	content, err := ioutil.ReadFile(city_file)
+	in := flag.String("in", "city.list.json", "city list downloaded from OpenWeatherMap")
+	out := flag.String("out", "cities.tsv", "city index to set as 'cities' in [weather] section of towarisch config")
+	flag.Parse()
+
+	content, err := os.ReadFile(*in)
 	if err != nil {
-		log.Fatalf("Could not read contents of file '%s' due to error: %s", city_file, err)
+		log.Fatalf("Could not read contents of file '%s' due to error: %s", *in, err)
 	}
 	cities := make([]cityInfo, 0, 250000)
 	err = json.Unmarshal(content, &cities)
@@ -35,18 +46,22 @@ func main() {
 		panic("Zero cities")
 	}
 
-	log.Printf("Connecting to Redis (%s DB: %d)...", redis_addr, redis_db)
-	opts := &redis.Options{
-		Addr: redis_addr,
-		DB:   redis_db}
-	conn := redis.NewClient(opts)
-	log.Printf("Redis connected")
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Could not create '%s' due to error: %s", *out, err)
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# OpenWeatherMap city index: ID, name, country, state, latitude, longitude, population, alternate names.\n")
 	for _, city := range cities {
-		key := fmt.Sprintf("openweathermap:city:%s", strings.ToLower(city.Name))
-		err = conn.HSet(key, "id", city.ID).Err()
-		if err != nil {
-			log.Printf("Could not store info about city %s (ID: %d)", city.Name, city.ID)
-		}
+		// the city list has no population, equal names are disambiguated by asking the user
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.4f\t%.4f\t0\t\n", city.ID, clean(city.Name), clean(city.Country), clean(city.State),
+			city.Coord.Lat, city.Coord.Lon)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalf("Could not write '%s' due to error: %s", *out, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Could not close '%s' due to error: %s", *out, err)
 	}
-	log.Printf("File parsing and saving has been finished")
+	log.Printf("City index has been written into %s", *out)
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/ilyalavrinov/tgbots/pkg/mtgbulk"
+)
+
+func main() {
+	dump := flag.String("dump", "scryfall.all.dump", "Scryfall dump of all cards to download or refresh")
+	index := flag.String("index", "scryfall.index.tsv", "card index to set as 'index' in [library] section of mtgbulkbuy config")
+	force := flag.Bool("force", false, "download the dump even if it is up to date")
+	flag.Parse()
+
+	downloaded, err := mtgbulk.RefreshScryfallDump(context.Background(), *dump, *force)
+	if err != nil {
+		log.Fatalf("Could not refresh Scryfall dump '%s' due to error: %s", *dump, err)
+	}
+	if downloaded {
+		log.Printf("Scryfall dump has been downloaded into %s", *dump)
+	} else {
+		log.Printf("Scryfall dump %s is up to date", *dump)
+	}
+
+	if err := mtgbulk.BuildLibraryIndex(*dump, *index); err != nil {
+		log.Fatalf("Could not build card index '%s' due to error: %s", *index, err)
+	}
+	log.Printf("Card index has been written into %s", *index)
+}